@@ -0,0 +1,284 @@
+package bsc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// BLS signatures of fast finality votes, as in ethereum 2: public keys are
+// compressed G1 points, signatures compressed G2 points, and messages are
+// hashed to G2 with the proof of possession ciphersuite.
+
+const (
+	blsPublicKeyLength = 48
+	blsSignatureLength = 96
+)
+
+var (
+	blsDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+	// blsP is the modulus of the base field
+	blsP, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	// blsPHalf is (p-1)/2, the largest "positive" element of the field
+	blsPHalf = new(big.Int).Rsh(blsP, 1)
+
+	errBLSInfinity = errors.New("bls: point at infinity")
+	errBLSSubgroup = errors.New("bls: point not in the subgroup")
+)
+
+type BLSPublicKey [blsPublicKeyLength]byte
+
+type BLSSignature [blsSignatureLength]byte
+
+// blsFastAggregateVerify verifies sig as the aggregated signature of msg by
+// all of pubKeys
+func blsFastAggregateVerify(pubKeys []BLSPublicKey, msg []byte, sig BLSSignature) error {
+	if len(pubKeys) == 0 {
+		return errors.New("bls: no public keys")
+	}
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	aggKey := g1.Zero()
+	for _, pubKey := range pubKeys {
+		p, err := decompressG1(g1, pubKey[:])
+		if err != nil {
+			return fmt.Errorf("public key %x: %v", pubKey, err)
+		}
+		g1.Add(aggKey, aggKey, p)
+	}
+	s, err := decompressG2(g2, sig[:])
+	if err != nil {
+		return fmt.Errorf("signature: %v", err)
+	}
+	h, err := hashToG2(g2, msg)
+	if err != nil {
+		return err
+	}
+	// e(aggKey, h) == e(g, s)
+	if !bls12381.NewPairingEngine().AddPair(aggKey, h).AddPairInv(g1.One(), s).Check() {
+		return errors.New("bls: invalid signature")
+	}
+	return nil
+}
+
+// decompressG1 decodes a compressed G1 point of the subgroup, other than
+// the point at infinity
+func decompressG1(g1 *bls12381.G1, in []byte) (*bls12381.PointG1, error) {
+	if len(in) != 48 {
+		return nil, fmt.Errorf("bls: invalid compressed g1 length: %d", len(in))
+	}
+	largest, err := compressionFlags(in)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(append([]byte{in[0] & 0x1f}, in[1:]...))
+	if x.Cmp(blsP) >= 0 {
+		return nil, errors.New("bls: invalid field element")
+	}
+	// y^2 = x^3 + 4
+	rhs := new(big.Int).Exp(x, big.NewInt(3), blsP)
+	rhs.Add(rhs, big.NewInt(4)).Mod(rhs, blsP)
+	y := fpSqrt(rhs)
+	if y == nil {
+		return nil, errors.New("bls: point not on curve")
+	}
+	if (y.Cmp(blsPHalf) > 0) != largest {
+		y.Sub(blsP, y)
+	}
+	out := make([]byte, 96)
+	x.FillBytes(out[:48])
+	y.FillBytes(out[48:])
+	p, err := g1.FromBytes(out)
+	if err != nil {
+		return nil, err
+	}
+	if !g1.InCorrectSubgroup(p) {
+		return nil, errBLSSubgroup
+	}
+	return p, nil
+}
+
+// decompressG2 decodes a compressed G2 point of the subgroup, other than
+// the point at infinity
+func decompressG2(g2 *bls12381.G2, in []byte) (*bls12381.PointG2, error) {
+	if len(in) != 96 {
+		return nil, fmt.Errorf("bls: invalid compressed g2 length: %d", len(in))
+	}
+	largest, err := compressionFlags(in)
+	if err != nil {
+		return nil, err
+	}
+	x := fp2{
+		new(big.Int).SetBytes(in[48:]),
+		new(big.Int).SetBytes(append([]byte{in[0] & 0x1f}, in[1:48]...)),
+	}
+	if x[0].Cmp(blsP) >= 0 || x[1].Cmp(blsP) >= 0 {
+		return nil, errors.New("bls: invalid field element")
+	}
+	// y^2 = x^3 + 4(1 + i)
+	rhs := x.mul(x).mul(x).add(fp2{big.NewInt(4), big.NewInt(4)})
+	y, ok := rhs.sqrt()
+	if !ok {
+		return nil, errors.New("bls: point not on curve")
+	}
+	if y.isLargest() != largest {
+		y = y.neg()
+	}
+	out := make([]byte, 192)
+	x[1].FillBytes(out[:48])
+	x[0].FillBytes(out[48:96])
+	y[1].FillBytes(out[96:144])
+	y[0].FillBytes(out[144:])
+	p, err := g2.FromBytes(out)
+	if err != nil {
+		return nil, err
+	}
+	if !g2.InCorrectSubgroup(p) {
+		return nil, errBLSSubgroup
+	}
+	return p, nil
+}
+
+// compressionFlags checks the flags of a compressed point, and returns if
+// its y coordinate is the lexicographically largest
+func compressionFlags(in []byte) (largest bool, err error) {
+	if in[0]&0x80 == 0 {
+		return false, errors.New("bls: point not compressed")
+	}
+	if in[0]&0x40 != 0 {
+		return false, errBLSInfinity
+	}
+	return in[0]&0x20 != 0, nil
+}
+
+// hashToG2 hashes msg to a G2 point (hash_to_curve of RFC 9380)
+func hashToG2(g2 *bls12381.G2, msg []byte) (*bls12381.PointG2, error) {
+	uniform := expandMessageXMD(msg, blsDST, 256)
+	var us [2]*bls12381.PointG2
+	for i := range us {
+		// an element of Fp2 in the encoding of bls12381, c1 || c0
+		in := make([]byte, 96)
+		for j := 0; j < 2; j++ {
+			e := new(big.Int).SetBytes(uniform[(2*i+j)*64 : (2*i+j+1)*64])
+			e.Mod(e, blsP).FillBytes(in[(1-j)*48 : (2-j)*48])
+		}
+		p, err := g2.MapToCurve(in)
+		if err != nil {
+			return nil, err
+		}
+		us[i] = p
+	}
+	// clearing the cofactor of the sum and of each point are the same
+	return g2.Add(g2.New(), us[0], us[1]), nil
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380 with sha256
+func expandMessageXMD(msg, dst []byte, length int) []byte {
+	const blockSize = 64
+	ell := (length + sha256.Size - 1) / sha256.Size
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, blockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	out := make([]byte, 0, ell*sha256.Size)
+	bi := make([]byte, sha256.Size)
+	for i := 1; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j] // b0 xor b(i-1), b0 for b1
+		}
+		h.Reset()
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length]
+}
+
+// fpSqrt returns a square root of a in the base field, or nil if there is
+// none; p = 3 mod 4
+func fpSqrt(a *big.Int) *big.Int {
+	e := new(big.Int).Add(blsP, big.NewInt(1))
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(a, e, blsP)
+	if new(big.Int).Exp(y, big.NewInt(2), blsP).Cmp(a) != 0 {
+		return nil
+	}
+	return y
+}
+
+// fp2 is an element c0 + c1*i of the quadratic extension field, i^2 = -1
+type fp2 [2]*big.Int
+
+func (a fp2) add(b fp2) fp2 {
+	return fp2{
+		new(big.Int).Mod(new(big.Int).Add(a[0], b[0]), blsP),
+		new(big.Int).Mod(new(big.Int).Add(a[1], b[1]), blsP),
+	}
+}
+
+func (a fp2) mul(b fp2) fp2 {
+	c0 := new(big.Int).Sub(new(big.Int).Mul(a[0], b[0]), new(big.Int).Mul(a[1], b[1]))
+	c1 := new(big.Int).Add(new(big.Int).Mul(a[0], b[1]), new(big.Int).Mul(a[1], b[0]))
+	return fp2{c0.Mod(c0, blsP), c1.Mod(c1, blsP)}
+}
+
+func (a fp2) neg() fp2 {
+	return fp2{
+		new(big.Int).Mod(new(big.Int).Neg(a[0]), blsP),
+		new(big.Int).Mod(new(big.Int).Neg(a[1]), blsP),
+	}
+}
+
+func (a fp2) exp(e *big.Int) fp2 {
+	r := fp2{big.NewInt(1), big.NewInt(0)}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if e.Bit(i) == 1 {
+			r = r.mul(a)
+		}
+	}
+	return r
+}
+
+func (a fp2) equal(b fp2) bool {
+	return a[0].Cmp(b[0]) == 0 && a[1].Cmp(b[1]) == 0
+}
+
+// isLargest tells if a is lexicographically larger than -a, comparing c1
+// first
+func (a fp2) isLargest() bool {
+	if a[1].Sign() != 0 {
+		return a[1].Cmp(blsPHalf) > 0
+	}
+	return a[0].Cmp(blsPHalf) > 0
+}
+
+// sqrt returns a square root of a, if there is one (algorithm 9 of
+// https://eprint.iacr.org/2012/685)
+func (a fp2) sqrt() (fp2, bool) {
+	e := new(big.Int).Sub(blsP, big.NewInt(3))
+	e.Rsh(e, 2)
+	a1 := a.exp(e)
+	alpha := a1.mul(a1).mul(a)
+	x0 := a1.mul(a)
+	var x fp2
+	if alpha.equal(fp2{new(big.Int).Sub(blsP, big.NewInt(1)), big.NewInt(0)}) {
+		x = fp2{new(big.Int).Mod(new(big.Int).Neg(x0[1]), blsP), x0[0]} // i * x0
+	} else {
+		e := new(big.Int).Sub(blsP, big.NewInt(1))
+		e.Rsh(e, 1)
+		b := alpha.add(fp2{big.NewInt(1), big.NewInt(0)}).exp(e)
+		x = b.mul(x0)
+	}
+	return x, x.mul(x).equal(a)
+}
@@ -0,0 +1,99 @@
+package bsc
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/pkg/errors"
+)
+
+// catchUp ...
+// forwards the blocks from next upto `to` to callback, finding those with
+// messages of the BMC by eth_getLogs over ranges of blocks instead of
+// looking into every block. The headers of the blocks of each range are
+// fetched in batches and verified by vr, if any, one after the other as in
+// receiveLoop, anchoring each range to the verified chain; only the blocks
+// with messages are fetched with their receipts, and the logs bloom of the
+// others is checked not to have any, for eth_getLogs not to skip them. It
+// returns the height of the next block to forward, short of `to` if it
+// keeps failing to scan logs
+func (r *receiver) catchUp(ctx context.Context, vr *Verifier, next, to uint64, callback func(*BlockNotification) error) (uint64, error) {
+	r.log.WithFields(log.Fields{"height": next, "target": to}).Info("catchUp: start")
+	for retry := 0; next <= to && retry < RPCCallRetry; {
+		if ctx.Err() != nil {
+			return next, nil
+		}
+		cl := r.client()
+		heights, last, err := r.scanner.Scan(ctx, cl, next, to)
+		if err != nil {
+			retry++
+			r.log.WithFields(log.Fields{"height": next, "range": r.scanner.Size(), "error": err}).Warn(
+				"catchUp: failed to scan logs")
+			continue
+		}
+		// and the header after last, which verifies it
+		headers, err := cl.GetHeadersByRange(ctx, next, last+1)
+		if err != nil {
+			retry++
+			r.log.WithFields(log.Fields{"height": next, "last": last, "error": err}).Warn(
+				"catchUp: failed to fetch headers")
+			continue
+		}
+		retry = 0
+		hits := make(map[uint64]bool, len(heights))
+		for _, height := range heights {
+			hits[height] = true
+		}
+		for i, header := range headers[:len(headers)-1] {
+			bn := &BlockNotification{Height: new(big.Int).SetUint64(next), Header: header, Hash: header.Hash()}
+			if vr != nil {
+				if err := vr.Verify(header, headers[i+1], nil); err != nil {
+					return next, errors.Wrapf(err, "vr.Verify: %v", err)
+				}
+				if err := vr.Update(header); err != nil {
+					return next, errors.Wrapf(err, "vr.Update: %v", err)
+				}
+			}
+			if !hits[next] && r.scanner.MayHaveMessages(header.Bloom) {
+				r.log.WithFields(log.Fields{"height": next}).Debug("catchUp: messages in logs bloom, not by eth_getLogs")
+				hits[next] = true
+			}
+			if hits[next] {
+				if bn.Receipts, err = r.fetchReceipts(header); err != nil {
+					return next, err
+				}
+			}
+			if err := callback(bn); err != nil {
+				return next, errors.Wrapf(err, "callback: %v", err)
+			}
+			atomic.StoreUint64(&r.verifiedHeight, next)
+			next++
+		}
+		if vr != nil {
+			r.saveVerifier(vr)
+		}
+		r.log.WithFields(log.Fields{"height": next, "target": to, "messages": len(heights)}).Debug("catchUp: catching up")
+	}
+	r.log.WithFields(log.Fields{"height": next}).Info("catchUp: complete")
+	return next, nil
+}
+
+// fetchReceipts returns the receipts of the block of header, checked
+// against its receipts root
+func (r *receiver) fetchReceipts(header *types.Header) (receipts types.Receipts, err error) {
+	for retry := 0; ; retry++ {
+		if receipts, err = r.client().GetBlockReceipts(header.Hash()); err == nil {
+			if err = evm.ValidateReceipts(header, receipts); err == nil {
+				return receipts, nil
+			}
+		}
+		if retry == RPCCallRetry {
+			return nil, errors.Wrapf(err, "GetBlockReceipts(%d): %v", header.Number, err)
+		}
+		r.log.WithFields(log.Fields{"height": header.Number, "error": err}).Warn("fetchReceipts: failed, retrying")
+	}
+}
@@ -0,0 +1,174 @@
+package bsc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBMC = "0xB4fC4b3b4e3157448B7D279f06BC8e340d63e2a9"
+
+// fakeBlocks serves the headers, the logs of eth_getLogs and the receipts
+// of the blocks of a test chain; the logs of the blocks of hidden are left
+// out of eth_getLogs
+type fakeBlocks struct {
+	headers  map[uint64]*ethTypes.Header
+	receipts map[ethCommon.Hash]*ethTypes.Receipt
+	hidden   map[uint64]bool
+}
+
+type fakeFilter struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+func (f *fakeBlocks) GetLogs(ctx context.Context, filter fakeFilter) ([]*ethTypes.Log, error) {
+	logs := []*ethTypes.Log{}
+	for _, receipt := range f.receipts {
+		for _, l := range receipt.Logs {
+			if l.BlockNumber >= uint64(filter.FromBlock) && l.BlockNumber <= uint64(filter.ToBlock) && !f.hidden[l.BlockNumber] {
+				logs = append(logs, l)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (f *fakeBlocks) GetBlockByNumber(ctx context.Context, number hexutil.Uint64, full bool) (*ethTypes.Header, error) {
+	return f.headers[uint64(number)], nil
+}
+
+func (f *fakeBlocks) GetBlockByHash(ctx context.Context, hash ethCommon.Hash, full bool) (map[string]interface{}, error) {
+	for txh, receipt := range f.receipts {
+		if receipt.BlockHash == hash {
+			return map[string]interface{}{"transactions": []string{txh.Hex()}, "gasUsed": "0x1"}, nil
+		}
+	}
+	return nil, fmt.Errorf("no block with messages: %v", hash)
+}
+
+func (f *fakeBlocks) GetTransactionReceipt(ctx context.Context, hash ethCommon.Hash) (*ethTypes.Receipt, error) {
+	return f.receipts[hash], nil
+}
+
+// newFakeBlocks returns the blocks from 1 upto last of c, of which those
+// at heights have a message of testBMC
+func newFakeBlocks(t *testing.T, c *testChain, last uint64, heights ...uint64) *fakeBlocks {
+	parsed, err := abi.JSON(strings.NewReader(evm.BMCABI))
+	require.NoError(t, err)
+	f := &fakeBlocks{
+		headers:  map[uint64]*ethTypes.Header{},
+		receipts: map[ethCommon.Hash]*ethTypes.Receipt{},
+		hidden:   map[uint64]bool{},
+	}
+	var parent *ethTypes.Header
+	for number := uint64(1); number <= last; number++ {
+		signer := c.inturn(number)
+		header := c.header(t, number, parent, signer, nil)
+		for _, height := range heights {
+			if height != number {
+				continue
+			}
+			txh := ethCommon.BigToHash(new(big.Int).SetUint64(number))
+			receipt := &ethTypes.Receipt{
+				Status: ethTypes.ReceiptStatusSuccessful, CumulativeGasUsed: 1, GasUsed: 1, TxHash: txh,
+				Logs: []*ethTypes.Log{{
+					Address:     ethCommon.HexToAddress(testBMC),
+					Topics:      []ethCommon.Hash{parsed.Events["Message"].ID},
+					Data:        []byte{1},
+					BlockNumber: number,
+					TxHash:      txh,
+				}},
+			}
+			receipt.Bloom = ethTypes.CreateBloom(ethTypes.Receipts{receipt})
+			header.Bloom = receipt.Bloom
+			header.ReceiptHash = ethTypes.DeriveSha(ethTypes.Receipts{receipt}, trie.NewStackTrie(nil))
+			header.GasUsed = 1
+			c.seal(t, header, signer)
+			receipt.BlockHash = header.Hash()
+			receipt.Logs[0].BlockHash = receipt.BlockHash
+			f.receipts[txh] = receipt
+		}
+		f.headers[number] = header
+		parent = header
+	}
+	return f
+}
+
+func newTestCatchUpReceiver(t *testing.T, f *fakeBlocks) *receiver {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", f))
+	clrpc := rpc.DialInProc(server)
+	t.Cleanup(clrpc.Close)
+	parser, err := evm.NewMessageParser(testBMC)
+	require.NoError(t, err)
+	return &receiver{
+		log:     log.New(),
+		cls:     []*evm.Client{{RPC: clrpc, Eth: ethclient.NewClient(clrpc)}},
+		scanner: evm.NewLogScanner(parser, 16),
+	}
+}
+
+func TestReceiver_CatchUp(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	f := newFakeBlocks(t, c, 40, 10, 20)
+	// the message at 20 is left out of eth_getLogs, but not of the bloom
+	f.hidden[20] = true
+	r := newTestCatchUpReceiver(t, f)
+	vr := newTestVerifier(c, parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}, 1, addresses(c.validators...), nil)
+
+	var bns []*BlockNotification
+	next, err := r.catchUp(context.Background(), vr, 1, 30, func(bn *BlockNotification) error {
+		bns = append(bns, bn)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 31, next)
+	assert.EqualValues(t, 31, vr.Next().Uint64(), "every block verified")
+	assert.Equal(t, f.headers[30].Hash(), vr.ParentHash())
+	require.Len(t, bns, 30)
+	for i, bn := range bns {
+		number := uint64(i + 1)
+		assert.EqualValues(t, number, bn.Height.Uint64())
+		assert.Equal(t, f.headers[number].Hash(), bn.Hash)
+		if number == 10 || number == 20 {
+			assert.Len(t, bn.Receipts, 1, "block with messages %d", number)
+		} else {
+			assert.Empty(t, bn.Receipts, "block without messages %d", number)
+		}
+	}
+}
+
+func TestReceiver_CatchUpUnverified(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	f := newFakeBlocks(t, c, 40, 10)
+	// an endpoint serves a block of another validator within the range
+	other := &testChain{chainID: c.chainID, validators: newTestValidators(t, 1)}
+	f.headers[25] = other.header(t, 25, f.headers[24], other.validators[0], nil)
+	r := newTestCatchUpReceiver(t, f)
+	vr := newTestVerifier(c, parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}, 1, addresses(c.validators...), nil)
+
+	var forwarded uint64
+	next, err := r.catchUp(context.Background(), vr, 1, 30, func(bn *BlockNotification) error {
+		forwarded = bn.Height.Uint64()
+		return nil
+	})
+	assert.Equal(t, errUnauthorizedValidator, errors.Cause(err))
+	assert.EqualValues(t, 24, next)
+	assert.EqualValues(t, 23, forwarded, "upto the block verified by the unauthorized one")
+}
@@ -6,17 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/db"
 	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/pkg/errors"
 )
@@ -28,6 +28,15 @@ const (
 	// TODO: adapt BlockHeightPollInterval depending on the value of BlockInterval or BlockFinalityConfirmations to avoid drift
 	MonitorBlockMaxConcurrency = 300 // number of concurrent requests to synchronize older blocks from source chain
 	RPCCallRetry               = 5
+	// CatchUpMargin is the number of the latest blocks the receiver
+	// verifies one by one, rather than catching up with by log ranges
+	CatchUpMargin = 100
+
+	// finality of the blocks the receiver relays: BlockFinalityConfirmations
+	// deep by default, or justified or finalized by fast finality votes
+	FinalityConfirmations = "confirmations"
+	FinalityJustified     = "justified"
+	FinalityFinalized     = "finalized"
 )
 
 func NewReceiver(
@@ -51,7 +60,18 @@ func NewReceiver(
 		r.opts.SyncConcurrency = MonitorBlockMaxConcurrency
 	}
 
-	r.cls, r.bmcs, err = newClients(urls, src.ContractAddress(), r.log)
+	r.cls, _, err = evm.NewClients(urls, src.ContractAddress(), r.log)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.LogRange > 0 {
+		parser, err := evm.NewMessageParser(src.ContractAddress())
+		if err != nil {
+			return nil, err
+		}
+		r.scanner = evm.NewLogScanner(parser, r.opts.LogRange)
+	}
+	r.Receiver, err = evm.NewReceiver(src, dst, r, r.log)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +81,39 @@ func NewReceiver(
 type ReceiverOptions struct {
 	SyncConcurrency uint64           `json:"syncConcurrency"`
 	Verifier        *VerifierOptions `json:"verifier"`
+	// Finality is one of FinalityConfirmations, the default, and
+	// FinalityJustified and FinalityFinalized since Plato
+	Finality string `json:"finality,omitempty"`
+	// LogRange, if set, is the number of blocks of the eth_getLogs ranges
+	// the receiver catches up with when it is far behind, instead of
+	// querying the logs of every block: the headers of all the blocks are
+	// still fetched, in batches, and verified, and those without messages
+	// by eth_getLogs are checked against their logs bloom
+	LogRange uint64 `json:"logRange,omitempty"`
+}
+
+// fastFinality tells if blocks are final by fast finality votes
+func (opts *ReceiverOptions) fastFinality() bool {
+	return opts.Finality == FinalityJustified || opts.Finality == FinalityFinalized
+}
+
+// ValidateReceiverOptions ...
+// checks the options of NewReceiver, rejecting unknown keys; the receiver
+// doesn't verify blocks without verifier options
+func ValidateReceiverOptions(rawOpts json.RawMessage) error {
+	opts := &ReceiverOptions{}
+	if err := chain.UnmarshalOptionsStrict(rawOpts, opts); err != nil {
+		return err
+	}
+	if opts.Verifier == nil {
+		return fmt.Errorf("missing verifier")
+	}
+	switch opts.Finality {
+	case "", FinalityConfirmations, FinalityJustified, FinalityFinalized:
+		return nil
+	default:
+		return fmt.Errorf("invalid finality: %q", opts.Finality)
+	}
 }
 
 func (opts *ReceiverOptions) Unmarshal(v map[string]interface{}) error {
@@ -72,22 +125,43 @@ func (opts *ReceiverOptions) Unmarshal(v map[string]interface{}) error {
 }
 
 type receiver struct {
+	*evm.Receiver
+
+	// latestHeight and verifiedHeight are accessed atomically
+	latestHeight   uint64
+	verifiedHeight uint64
+
 	log  log.Logger
 	src  chain.BTPAddress
 	dst  chain.BTPAddress
 	opts ReceiverOptions
-	cls  []*Client
-	bmcs []*BMC
+	cls  []*evm.Client
+	// scanner finds the blocks with messages when catching up, if set
+	scanner *evm.LogScanner
+
+	// store persists verifier snapshots, if set
+	store    db.Bucket
+	snapNext uint64
 }
 
-func (r *receiver) client() *Client {
-	randInt := rand.Intn(len(r.cls))
-	return r.cls[randInt]
+func (r *receiver) SetStateStore(store db.Bucket) {
+	r.store = store
 }
 
-func (r *receiver) bmcClient() *BMC {
-	randInt := rand.Intn(len(r.cls))
-	return r.bmcs[randInt]
+func (r *receiver) ReceiverStatus() *chain.ReceiverStatus {
+	return &chain.ReceiverStatus{
+		Height:         atomic.LoadUint64(&r.latestHeight),
+		VerifiedHeight: atomic.LoadUint64(&r.verifiedHeight),
+	}
+}
+
+func (r *receiver) client() *evm.Client {
+	return evm.PickClient(r.cls)
+}
+
+// Endpoints implements chain.EndpointReporter
+func (r *receiver) Endpoints() []chain.EndpointStatus {
+	return evm.Endpoints(r.cls)
 }
 
 type BnOptions struct {
@@ -100,8 +174,8 @@ func (r *receiver) newVerifier(opts *VerifierOptions) (vr *Verifier, err error)
 		mu:         sync.RWMutex{},
 		next:       big.NewInt(int64(opts.BlockHeight)),
 		parentHash: common.HexToHash(opts.BlockHash.String()),
-		validators: map[ethCommon.Address]bool{},
-		chainID:    r.client().chainID,
+		chainID:    r.client().ChainID,
+		forks:      newParliaForks(r.client().ChainID, opts),
 	}
 
 	// cross check input parent hash
@@ -114,8 +188,10 @@ func (r *receiver) newVerifier(opts *VerifierOptions) (vr *Verifier, err error)
 		return nil, fmt.Errorf("Unexpected Hash(%v): Got %v Expected %v", opts.BlockHeight, header.ParentHash.Hex(), vr.parentHash.Hex())
 	}
 
-	// cross check input validator data
-	roundedHeight := big.NewInt(int64(opts.BlockHeight - opts.BlockHeight%defaultEpochLength))
+	// cross check input validator data, of the epoch block of a height well
+	// after the upgrades that lengthen epochs
+	epochLength := vr.forks.epochLength(header.Time)
+	roundedHeight := big.NewInt(int64(opts.BlockHeight - opts.BlockHeight%epochLength))
 	header, err = r.client().GetHeaderByHeight(roundedHeight)
 	if err != nil {
 		err = errors.Wrapf(err, "GetHeaderByHeight: %v", err)
@@ -124,13 +200,139 @@ func (r *receiver) newVerifier(opts *VerifierOptions) (vr *Verifier, err error)
 	if !bytes.Equal(header.Extra, opts.ValidatorData) {
 		return nil, fmt.Errorf("Unexpected ValidatorData(%v): Got %v Expected %v", roundedHeight, header.Extra, opts.ValidatorData)
 	}
-	vr.validators, err = getValidatorMapFromHex(opts.ValidatorData)
+	validators, voteKeys, err := vr.forks.getValidatorsFromExtra(roundedHeight.Uint64(), opts.ValidatorData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getValidatorsFromExtra %v", err)
+	}
+	turnLength, err := vr.forks.getTurnLength(header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getTurnLength %v", err)
+	}
+	if turnLength == 0 {
+		turnLength = defaultTurnLength
+	}
+	vr.signers = &signers{
+		number:      opts.BlockHeight - 1,
+		validators:  validators,
+		voteKeys:    voteKeys,
+		turnLength:  turnLength,
+		epochLength: epochLength,
+		recents:     map[uint64]ethCommon.Address{},
+		known:       opts.BlockHeight,
+	}
+	return vr, nil
+}
+
+// loadVerifier ...
+// restores the verifier from its latest snapshot if it is usable to verify
+// blocks from `height`, otherwise creates it from the config anchor `opts`
+func (r *receiver) loadVerifier(opts *VerifierOptions, height uint64) (*Verifier, error) {
+	if vr, err := r.restoreVerifier(opts, height); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn(
+			"verifier snapshot: discarded, falling back to config")
+	} else if vr != nil {
+		return vr, nil
+	}
+	vr, err := r.newVerifier(opts)
 	if err != nil {
-		return nil, errors.Wrapf(err, "getValidatorMapFromHex %v", err)
+		return nil, err
 	}
+	r.snapNext = vr.Next().Uint64() // nothing to save until it advances
 	return vr, nil
 }
 
+func (r *receiver) restoreVerifier(opts *VerifierOptions, height uint64) (*Verifier, error) {
+	if r.store == nil {
+		return nil, nil
+	}
+	var ss verifierSnapshot
+	if ok, err := chain.LoadSnapshot(r.store, chain.VerifierSnapshotKey, &ss); err != nil || !ok {
+		return nil, err
+	}
+	if ss.Next <= opts.BlockHeight || ss.Next > height {
+		r.log.WithFields(log.Fields{"next": ss.Next, "height": height}).Debug(
+			"verifier snapshot: not usable")
+		return nil, nil
+	}
+
+	// cross check with the block the snapshot was taken at, and the epoch
+	// block of its validators
+	header, err := r.client().GetHeaderByHeight(new(big.Int).SetUint64(ss.Next - 1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHeaderByHeight: %v", err)
+	}
+	if header.Hash() != ss.ParentHash {
+		return nil, fmt.Errorf("Unexpected Hash(%v): Got %v Expected %v", ss.Next-1, header.Hash().Hex(), ss.ParentHash.Hex())
+	}
+	epochLength := ss.EpochLength
+	if epochLength == 0 {
+		epochLength = defaultEpochLength
+	}
+	epochHeight := (ss.Next - 1) - (ss.Next-1)%epochLength
+	if epochHeight != ss.Next-1 {
+		header, err = r.client().GetHeaderByHeight(new(big.Int).SetUint64(epochHeight))
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetHeaderByHeight: %v", err)
+		}
+	}
+	forks := newParliaForks(r.client().ChainID, opts)
+	validators, voteKeys, err := forks.getValidatorsFromExtra(epochHeight, header.Extra)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getValidatorsFromExtra %v", err)
+	}
+	turnLength, err := forks.getTurnLength(header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getTurnLength %v", err)
+	}
+	if turnLength == 0 {
+		turnLength = defaultTurnLength
+	}
+	if len(validators) != len(ss.Validators) {
+		return nil, fmt.Errorf("Unexpected Validators(%v): Got %d Expected %d", epochHeight, len(ss.Validators), len(validators))
+	}
+	for _, addr := range ss.Validators {
+		if !validators[addr] {
+			return nil, fmt.Errorf("Unexpected Validator(%v): %v", epochHeight, addr.Hex())
+		}
+	}
+	recents := ss.Recents
+	if recents == nil {
+		recents = map[uint64]ethCommon.Address{}
+	}
+
+	r.log.WithFields(log.Fields{"next": ss.Next}).Info("verifier snapshot: restored")
+	r.snapNext = ss.Next
+	return &Verifier{
+		mu:         sync.RWMutex{},
+		next:       new(big.Int).SetUint64(ss.Next),
+		parentHash: ss.ParentHash,
+		signers: &signers{
+			number:      ss.Next - 1,
+			validators:  validators,
+			voteKeys:    voteKeys,
+			turnLength:  turnLength,
+			epochLength: epochLength,
+			recents:     recents,
+		},
+		chainID:    r.client().ChainID,
+		forks:      forks,
+		checkpoint: &ss,
+	}, nil
+}
+
+// saveVerifier persists the state of the verifier if it has advanced
+func (r *receiver) saveVerifier(vr *Verifier) {
+	ss := vr.snapshot()
+	if r.store == nil || ss == nil || ss.Next == r.snapNext {
+		return
+	}
+	if err := chain.SaveSnapshot(r.store, chain.VerifierSnapshotKey, ss); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("verifier snapshot: failed to save")
+		return
+	}
+	r.snapNext = ss.Next
+}
+
 func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
 	if height == vr.Next().Int64() {
 		return nil
@@ -232,6 +434,7 @@ func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
 				}
 				prevHeader = next.Header
 			}
+			r.saveVerifier(vr)
 			r.log.WithFields(log.Fields{"height": vr.Next().String(), "target": height}).Debug("syncVerifier: syncing")
 		}
 	}
@@ -248,7 +451,7 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 
 	var vr *Verifier
 	if r.opts.Verifier != nil {
-		vr, err = r.newVerifier(r.opts.Verifier)
+		vr, err = r.loadVerifier(r.opts.Verifier, opts.StartHeight)
 		if err != nil {
 			return err
 		}
@@ -257,6 +460,9 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 			return errors.Wrapf(err, "receiveLoop: syncVerifier: %v", err)
 		}
 	}
+	if r.opts.fastFinality() && (vr == nil || vr.forks.Plato == noFork) {
+		return fmt.Errorf("receiveLoop: no fast finality for finality: %s", r.opts.Finality)
+	}
 
 	// block notification channel
 	// (buffered: to avoid deadlock)
@@ -275,12 +481,63 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 			r.log.WithFields(log.Fields{"error": err}).Error("receiveLoop: failed to GetBlockNumber")
 			return 0
 		}
+		if r.opts.fastFinality() {
+			return height
+		}
 		return height - BlockFinalityConfirmations
 	}
 	next, latest := opts.StartHeight, latestHeight()
+	atomic.StoreUint64(&r.latestHeight, latest)
 
 	// last unverified block notification
 	var lbn *BlockNotification
+	// verified block notifications, not final yet with fast finality
+	var pending []*BlockNotification
+	// height of the next block notification to forward
+	forwardNext := opts.StartHeight
+	forward := func() error {
+		for len(pending) > 0 && r.isFinal(vr, pending[0].Height.Uint64()) {
+			if err := callback(pending[0]); err != nil {
+				return errors.Wrapf(err, "receiveLoop: callback: %v", err)
+			}
+			atomic.StoreUint64(&r.verifiedHeight, pending[0].Height.Uint64())
+			forwardNext = pending[0].Height.Uint64() + 1
+			pending = pending[1:]
+		}
+		return nil
+	}
+	// rollback rolls vr back after a reorg, drops the pending block
+	// notifications it orphaned and withdraws the forwarded ones, and
+	// returns the height to fetch blocks from
+	rollback := func() (uint64, error) {
+		height, err := evm.Rollback(vr, vr.Next().Uint64(), r.client().GetHeaderByHeight)
+		if err == evm.ErrReorgTooDeep {
+			return 0, errors.Wrapf(err, "receiveLoop: rollback: %v", err)
+		} else if err != nil {
+			r.log.WithFields(log.Fields{"error": err}).Error("receiveLoop: failed to roll back")
+			return vr.Next().Uint64(), nil
+		}
+		for len(pending) > 0 && pending[len(pending)-1].Height.Uint64() >= height {
+			pending = pending[:len(pending)-1]
+		}
+		if forwardNext > height {
+			r.log.WithFields(log.Fields{"height": height, "orphaned": forwardNext - height}).Warn(
+				"receiveLoop: reorg, withdrawing blocks")
+			if err := callback(&BlockNotification{Height: new(big.Int).SetUint64(height), Orphaned: true}); err != nil {
+				return 0, errors.Wrapf(err, "receiveLoop: callback: %v", err)
+			}
+			atomic.StoreUint64(&r.verifiedHeight, height-1)
+			forwardNext = height
+		}
+		r.saveVerifier(vr)
+		return height, nil
+	}
+	if r.scanner != nil && next+CatchUpMargin < latest {
+		if next, err = r.catchUp(ctx, vr, next, latest-CatchUpMargin, callback); err != nil {
+			return errors.Wrapf(err, "receiveLoop: catchUp: %v", err)
+		}
+		forwardNext = next
+	}
 	// start monitor loop
 	for {
 		select {
@@ -288,11 +545,14 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 			return nil
 
 		case <-heightTicker.C:
-			latest++
+			if !r.opts.fastFinality() {
+				latest++
+			}
 
 		case <-heightPoller.C:
 			if height := latestHeight(); height > 0 {
 				latest = height
+				atomic.StoreUint64(&r.latestHeight, latest)
 				r.log.WithFields(log.Fields{"latest": latest, "next": next}).Debug("poll height")
 			}
 
@@ -302,11 +562,29 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 				if lbn != nil {
 					if bn.Height.Cmp(lbn.Height) == 0 {
 						if bn.Header.ParentHash != lbn.Header.ParentHash {
-							r.log.WithFields(log.Fields{"lbnParentHash": lbn.Header.ParentHash, "bnParentHash": bn.Header.ParentHash}).Error("verification failed on retry ")
+							r.log.WithFields(log.Fields{"lbnParentHash": lbn.Header.ParentHash, "bnParentHash": bn.Header.ParentHash}).Warn("verification failed on retry, reorg")
+							if vr == nil {
+								break
+							}
+							height, err := rollback()
+							if err != nil {
+								return err
+							}
+							// refetch from the first orphaned block
+							lbn, next = nil, height
 							break
 						}
 					} else {
 						if vr != nil {
+							if lbn.Header.ParentHash != vr.ParentHash() {
+								// lbn doesn't follow the verified blocks
+								height, err := rollback()
+								if err != nil {
+									return err
+								}
+								lbn, next = nil, height
+								break
+							}
 							if err := vr.Verify(lbn.Header, bn.Header, bn.Receipts); err != nil {
 								r.log.WithFields(log.Fields{
 									"height":     lbn.Height,
@@ -319,9 +597,11 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 							if err := vr.Update(lbn.Header); err != nil {
 								return errors.Wrapf(err, "receiveLoop: vr.Update: %v", err)
 							}
+							r.saveVerifier(vr)
 						}
-						if err := callback(lbn); err != nil {
-							return errors.Wrapf(err, "receiveLoop: callback: %v", err)
+						pending = append(pending, lbn)
+						if err := forward(); err != nil {
+							return err
 						}
 					}
 				}
@@ -445,107 +725,36 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 	}
 }
 
-func (r *receiver) hasBTPMessage(ctx context.Context, height *big.Int) (bool, error) {
-	ctxNew, cancel := context.WithTimeout(ctx, defaultReadTimeout)
-	defer cancel()
-	logs, err := r.client().eth.FilterLogs(ctxNew, ethereum.FilterQuery{
-		FromBlock: height,
-		ToBlock:   height,
-		Addresses: []ethCommon.Address{ethCommon.HexToAddress(r.src.ContractAddress())},
-	})
-	if err != nil {
-		return false, errors.Wrapf(err, "FilterLogs %v", err)
-	}
-	if len(logs) > 0 {
-		return true, nil
+// isFinal tells if the verified block at height is final by the finality
+// option
+func (r *receiver) isFinal(vr *Verifier, height uint64) bool {
+	switch r.opts.Finality {
+	case FinalityJustified:
+		return height <= vr.Justified()
+	case FinalityFinalized:
+		return height <= vr.Finalized()
+	default:
+		return true
 	}
-	return false, nil
 }
 
-func (r *receiver) Subscribe(
-	ctx context.Context, msgCh chan<- *chain.Message,
-	opts chain.SubscribeOptions) (errCh <-chan error, err error) {
-
-	opts.Seq++
-
-	_errCh := make(chan error)
-
-	go func() {
-		defer close(_errCh)
-		lastHeight := opts.Height - 1
-		if err := r.receiveLoop(ctx,
-			&BnOptions{
-				StartHeight: opts.Height,
-				Concurrency: r.opts.SyncConcurrency,
-			},
-			func(v *BlockNotification) error {
-				r.log.WithFields(log.Fields{"height": v.Height}).Debug("block notification")
-
-				if v.Height.Uint64() != lastHeight+1 {
-					r.log.Errorf("expected v.Height == %d, got %d", lastHeight+1, v.Height.Uint64())
-					return fmt.Errorf(
-						"block notification: expected=%d, got=%d",
-						lastHeight+1, v.Height.Uint64())
-				}
-
-				receipts := r.getRelayReceipts(v)
-				for _, receipt := range receipts {
-					events := receipt.Events[:0]
-					for _, event := range receipt.Events {
-						switch {
-						case event.Sequence == opts.Seq:
-							events = append(events, event)
-							opts.Seq++
-						case event.Sequence > opts.Seq:
-							r.log.WithFields(log.Fields{
-								"seq": log.Fields{"got": event.Sequence, "expected": opts.Seq},
-							}).Error("invalid event seq")
-							return fmt.Errorf("invalid event seq")
-						}
-					}
-					receipt.Events = events
-				}
-				if len(receipts) > 0 {
-					msgCh <- &chain.Message{Receipts: receipts}
-				}
-				lastHeight++
-				return nil
-			}); err != nil {
-			r.log.Errorf("receiveLoop terminated: %v", err)
-			_errCh <- err
-		}
-	}()
-
-	return _errCh, nil
+func (r *receiver) hasBTPMessage(ctx context.Context, height *big.Int) (bool, error) {
+	return r.client().HasLogs(ctx, height, r.src.ContractAddress())
 }
 
-func (r *receiver) getRelayReceipts(v *BlockNotification) []*chain.Receipt {
-	sc := common.HexToAddress(r.src.ContractAddress())
-	var receipts []*chain.Receipt
-	var events []*chain.Event
-	for i, receipt := range v.Receipts {
-		events := events[:0]
-		for _, log := range receipt.Logs {
-			if !bytes.Equal(log.Address.Bytes(), sc.Bytes()) {
-				continue
-			}
-			msg, err := r.bmcClient().ParseMessage(ethTypes.Log{
-				Data: log.Data, Topics: log.Topics,
+// ReceiveBlocks implements evm.BlockSource with the blocks verified by
+// receiveLoop
+func (r *receiver) ReceiveBlocks(ctx context.Context, height uint64, cb func(*evm.VerifiedBlock) error) error {
+	return r.receiveLoop(ctx,
+		&BnOptions{
+			StartHeight: height,
+			Concurrency: r.opts.SyncConcurrency,
+		},
+		func(v *BlockNotification) error {
+			return cb(&evm.VerifiedBlock{
+				Height:   v.Height.Uint64(),
+				Logs:     evm.ReceiptLogs(v.Receipts),
+				Orphaned: v.Orphaned,
 			})
-			if err == nil {
-				events = append(events, &chain.Event{
-					Next:     chain.BTPAddress(msg.Next),
-					Sequence: msg.Seq.Uint64(),
-					Message:  msg.Msg,
-				})
-			}
-		}
-		if len(events) > 0 {
-			rp := &chain.Receipt{}
-			rp.Index, rp.Height = uint64(i), v.Height.Uint64()
-			rp.Events = append(rp.Events, events...)
-			receipts = append(receipts, rp)
-		}
-	}
-	return receipts
+		})
 }
@@ -15,6 +15,7 @@ import (
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
 	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -38,19 +39,19 @@ func newTestReceiver(t *testing.T, src, dst chain.BTPAddress) chain.Receiver {
 	return receiver
 }
 
-func newTestClient(t *testing.T, bmcAddr string) *Client {
+func newTestClient(t *testing.T, bmcAddr string) *evm.Client {
 	url := "https://data-seed-prebsc-1-s1.binance.org:8545"
-	cls, _, err := newClients([]string{url}, bmcAddr, log.New())
+	cls, _, err := evm.NewClients([]string{url}, bmcAddr, log.New())
 	require.NoError(t, err)
 	return cls[0]
 }
 
 func TestMedianGasPrice(t *testing.T) {
 	url := "https://data-seed-prebsc-1-s1.binance.org:8545"
-	cls, _, err := newClients([]string{url}, BSC_BMC_PERIPHERY, log.New())
+	cls, _, err := evm.NewClients([]string{url}, BSC_BMC_PERIPHERY, log.New())
 	require.NoError(t, err)
 
-	_, _, err = cls[0].GetMedianGasPriceForBlock(context.Background())
+	_, _, err = evm.MedianGasPrice(context.Background(), evm.NewFeeClient(cls[0].RPC))
 	require.NoError(t, err)
 }
 
@@ -142,11 +143,17 @@ func TestVerify(t *testing.T) {
 		mu:         sync.RWMutex{},
 		next:       big.NewInt(int64(opts.BlockHeight)),
 		parentHash: common.BytesToHash(opts.BlockHash),
-		validators: map[ethCommon.Address]bool{},
 		chainID:    big.NewInt(97),
+		forks:      newParliaForks(big.NewInt(97), &opts),
 	}
-	vr.validators, err = getValidatorMapFromHex(opts.ValidatorData)
+	validators, err := getValidatorMapFromHex(opts.ValidatorData)
 	require.NoError(t, err)
+	vr.signers = &signers{
+		number:     opts.BlockHeight - 1,
+		validators: validators,
+		recents:    map[uint64]ethCommon.Address{},
+		known:      opts.BlockHeight,
+	}
 	cl := newTestClient(t, BSC_BMC_PERIPHERY)
 	header, err := cl.GetHeaderByHeight(big.NewInt(int64(opts.BlockHeight)))
 	require.NoError(t, err)
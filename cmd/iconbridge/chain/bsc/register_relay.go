@@ -5,4 +5,6 @@ import "github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
 func init() {
 	relay.Senders["bsc"] = NewSender
 	relay.Receivers["bsc"] = NewReceiver
+	relay.SenderOptionValidators["bsc"] = ValidateSenderOptions
+	relay.ReceiverOptionValidators["bsc"] = ValidateReceiverOptions
 }
@@ -0,0 +1,185 @@
+package bsc
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// signers ...
+// are the validators that may seal the block after a block, as in the
+// snapshots of parlia: each validator seals turns of turnLength consecutive
+// blocks, the validators of an epoch block take over once half of the
+// validators sealed their turn, and a validator seals at most one turn in
+// len(validators)/2+1 turns
+type signers struct {
+	number     uint64
+	validators map[ethCommon.Address]bool
+	// voteKeys are the BLS vote keys of validators since Luban
+	voteKeys map[ethCommon.Address]BLSPublicKey
+	// turnLength is 1 until the first epoch block since Bohr
+	turnLength uint64
+	// epochLength is the number of blocks of the epoch of the next block
+	epochLength uint64
+	// epochValidators, epochVoteKeys and epochTurnLength are those of the
+	// latest epoch block until they take over, nil otherwise
+	epochValidators map[ethCommon.Address]bool
+	epochVoteKeys   map[ethCommon.Address]BLSPublicKey
+	epochTurnLength uint64
+	// recents are the recent signers by height
+	recents map[uint64]ethCommon.Address
+	// known is the height of the first block whose signer is in recents
+	known uint64
+}
+
+// apply returns the signers after header, which follows s and is verified
+func (s *signers) apply(header *types.Header, forks parliaForks) (*signers, error) {
+	number := header.Number.Uint64()
+	ns := *s
+	ns.number = number
+	ns.recents = make(map[uint64]ethCommon.Address, len(s.recents)+1)
+	limit := s.historyLen() + 1
+	for seen, recent := range s.recents {
+		if seen+limit > number {
+			ns.recents[seen] = recent
+		}
+	}
+	ns.recents[number] = header.Coinbase
+
+	if number%s.epochLength == 0 {
+		validators, voteKeys, err := forks.getValidatorsFromExtra(number, header.Extra)
+		if err != nil {
+			return nil, err
+		}
+		turnLength, err := forks.getTurnLength(header)
+		if err != nil {
+			return nil, err
+		}
+		if turnLength == 0 {
+			turnLength = s.turnLength
+		}
+		ns.epochValidators, ns.epochVoteKeys, ns.epochTurnLength = validators, voteKeys, turnLength
+	}
+	if ns.epochValidators != nil && number%s.epochLength == s.historyLen() {
+		ns.validators, ns.voteKeys, ns.turnLength = ns.epochValidators, ns.epochVoteKeys, ns.epochTurnLength
+		ns.epochValidators, ns.epochVoteKeys = nil, nil
+		if forks.isBohr(header.Time) {
+			// the recent signers are cleared with the take over since Bohr
+			ns.recents = map[uint64]ethCommon.Address{}
+		} else if newLimit := ns.historyLen() + 1; newLimit < limit {
+			for seen := range ns.recents {
+				if seen+newLimit <= number {
+					delete(ns.recents, seen)
+				}
+			}
+		}
+	}
+
+	// epochs are longer since Lorentz and Maxwell, from the first block
+	// that starts an epoch of the new length
+	switch {
+	case forks.isMaxwell(header.Time) && ns.epochLength < maxwellEpochLength && (number+1)%maxwellEpochLength == 0:
+		ns.epochLength = maxwellEpochLength
+	case forks.isLorentz(header.Time) && ns.epochLength < lorentzEpochLength && (number+1)%lorentzEpochLength == 0:
+		ns.epochLength = lorentzEpochLength
+	}
+	return &ns, nil
+}
+
+// sorted returns the validators ordered by address
+func (s *signers) sorted() []ethCommon.Address {
+	validators := make([]ethCommon.Address, 0, len(s.validators))
+	for addr := range s.validators {
+		validators = append(validators, addr)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i][:], validators[j][:]) < 0
+	})
+	return validators
+}
+
+// historyLen returns the number of the latest blocks in which validators
+// seal at most a turn
+func (s *signers) historyLen() uint64 {
+	return uint64(len(s.validators)/2+1)*s.turnLength - 1
+}
+
+// inturn tells if it is the turn of signer to seal the next block
+func (s *signers) inturn(signer ethCommon.Address) bool {
+	validators := s.sorted()
+	if len(validators) == 0 {
+		return false
+	}
+	return validators[(s.number+1)/s.turnLength%uint64(len(validators))] == signer
+}
+
+// recentlySigned tells if signer sealed a whole turn too recently to seal
+// the next block
+func (s *signers) recentlySigned(signer ethCommon.Address) bool {
+	limit, count := s.historyLen(), uint64(0)
+	for seen, recent := range s.recents {
+		if recent == signer && seen+limit > s.number {
+			count++
+		}
+	}
+	return count >= s.turnLength
+}
+
+// recentsKnown tells if the signers of all the blocks that count for the
+// recency of the signer of the next block are known
+func (s *signers) recentsKnown() bool {
+	return s.number+1 >= s.known+s.historyLen()
+}
+
+// backOffTime ...
+// returns the number of milliseconds signer waits after the block interval
+// to seal the next block out of turn, as in parlia since Planck: the
+// validators other than the recent signers take turns in an order shuffled
+// by the height, after the signer in turn unless it signed recently. Since
+// Bohr, the signer in turn is left out of them and the order is shuffled by
+// the turn. It is 0 as long as the recent signers aren't known
+func (s *signers) backOffTime(signer ethCommon.Address, parent *types.Header, forks parliaForks) uint64 {
+	if s.inturn(signer) || !s.recentsKnown() || s.recentlySigned(signer) {
+		return 0
+	}
+	delay := initialBackOffTime
+	if forks.isLorentz(parent.Time) {
+		delay = lorentzInitialBackOffTime
+	}
+	bohr := forks.isBohr(parent.Time)
+	idx := -1
+	validators := make([]ethCommon.Address, 0, len(s.validators))
+	for _, v := range s.sorted() {
+		if s.recentlySigned(v) {
+			if s.inturn(v) {
+				delay = 0
+			}
+			continue
+		}
+		if bohr && s.inturn(v) {
+			continue
+		}
+		if v == signer {
+			idx = len(validators)
+		}
+		validators = append(validators, v)
+	}
+	if idx < 0 {
+		return 0
+	}
+	steps := make([]uint64, len(validators))
+	for i := range steps {
+		steps[i] = uint64(i)
+	}
+	seed := int64(s.number)
+	if bohr {
+		seed = int64((s.number + 1) / s.turnLength)
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(steps), func(i, j int) {
+		steps[i], steps[j] = steps[j], steps[i]
+	})
+	return delay + steps[idx]*wiggleTime
+}
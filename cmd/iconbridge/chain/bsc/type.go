@@ -144,6 +144,9 @@ type BlockNotification struct {
 	Header        *types.Header
 	Receipts      types.Receipts
 	HasBTPMessage *bool
+	// Orphaned tells that the blocks from Height on, notified before, were
+	// orphaned by a reorg
+	Orphaned bool
 }
 
 type RelayMessage struct {
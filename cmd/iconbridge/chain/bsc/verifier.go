@@ -5,25 +5,41 @@ import (
 	"io"
 	"math/big"
 	"sync"
+	"time"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/ethereum/go-ethereum/trie"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
 	"github.com/icon-project/icon-bridge/common"
 	"github.com/pkg/errors"
 )
 
 const (
-	extraVanity          = 32          // Fixed number of extra-data prefix bytes reserved for signer vanity
-	extraSeal            = 65          // Fixed number of extra-data suffix bytes reserved for signer seal
-	defaultEpochLength   = uint64(200) // Default number of blocks of checkpoint to update validatorSet from contract
-	validatorBytesLength = ethCommon.AddressLength
+	extraVanity        = 32           // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal          = 65           // Fixed number of extra-data suffix bytes reserved for signer seal
+	defaultEpochLength = uint64(200)  // Default number of blocks of checkpoint to update validatorSet from contract
+	lorentzEpochLength = uint64(500)  // Number of blocks of checkpoint since Lorentz
+	maxwellEpochLength = uint64(1000) // Number of blocks of checkpoint since Maxwell
+	// defaultBlockInterval, lorentzBlockInterval and maxwellBlockInterval
+	// are the minimum number of milliseconds between blocks
+	defaultBlockInterval = uint64(3000)
+	lorentzBlockInterval = uint64(1500)
+	maxwellBlockInterval = uint64(750)
+	// initialBackOffTime is the number of milliseconds out-of-turn signers
+	// wait for the signer in turn, halved since Lorentz
+	initialBackOffTime        = uint64(1000)
+	lorentzInitialBackOffTime = initialBackOffTime / 2
+	wiggleTime                = uint64(1000) // Number of milliseconds between the turns of out-of-turn signers
+	// allowedFutureBlockTime is the number of seconds headers may be ahead
+	// of the clock of the relay
+	allowedFutureBlockTime = uint64(15)
+	validatorBytesLength   = ethCommon.AddressLength
 
 	ParliaGasLimitBoundDivisor uint64 = 256                // The bound divisor of the gas limit, used in update calculations.
 	MinGasLimit                uint64 = 5000               // Minimum the gas limit may ever be.
@@ -31,8 +47,10 @@ const (
 )
 
 var (
-	big1      = big.NewInt(1)
-	uncleHash = types.CalcUncleHash(nil)
+	big1       = big.NewInt(1)
+	uncleHash  = types.CalcUncleHash(nil)
+	diffInTurn = big.NewInt(2) // Block difficulty for in-turn signatures
+	diffNoTurn = big.NewInt(1) // Block difficulty for out-of-turn signatures
 )
 
 var (
@@ -58,7 +76,12 @@ var (
 	// invalid list of validators (i.e. non divisible by 20 bytes).
 	errInvalidSpanValidators = errors.New("invalid validator list on sprint end block")
 
-	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
+	// errInvalidTurnLength is returned if an epoch block since Bohr has no
+	// valid turn length after its validators.
+	errInvalidTurnLength = errors.New("invalid turn length")
+
+	// errInvalidMixDigest is returned if a block's mix digest is non-zero
+	// before Lorentz, and not the milliseconds of its timestamp since.
 	errInvalidMixDigest = errors.New("non-zero mix digest")
 
 	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
@@ -72,22 +95,74 @@ var (
 
 	// errCoinBaseMisMatch is returned if a header's coinbase do not match with signature
 	errCoinBaseMisMatch = errors.New("coinbase do not match with signature")
+
+	// errRecentlySigned is returned if a header is signed by an authorized entity
+	// that already signed a header recently, thus is temporarily not allowed to.
+	errRecentlySigned = errors.New("recently signed")
+
+	// errWrongDifficulty is returned if the difficulty of a block doesn't match the
+	// turn of the signer.
+	errWrongDifficulty = errors.New("wrong difficulty")
+
+	// errInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the previous block's timestamp + the block interval, + the back-off of
+	// out-of-turn signers.
+	errInvalidTimestamp = errors.New("invalid timestamp")
 )
 
 type VerifierOptions struct {
 	BlockHeight   uint64          `json:"blockHeight"`
 	BlockHash     common.HexBytes `json:"parentHash"`
 	ValidatorData common.HexBytes `json:"validatorData"`
+	// LubanHeight and PlatoHeight override the heights of the parlia
+	// upgrades, known for mainnet and testnet
+	LubanHeight uint64 `json:"lubanHeight,omitempty"`
+	PlatoHeight uint64 `json:"platoHeight,omitempty"`
+	// BohrTime, LorentzTime and MaxwellTime override the times of the
+	// parlia upgrades that change turns, block intervals and epochs
+	BohrTime    uint64 `json:"bohrTime,omitempty"`
+	LorentzTime uint64 `json:"lorentzTime,omitempty"`
+	MaxwellTime uint64 `json:"maxwellTime,omitempty"`
 }
 
+// the parlia Verifier is the header verifier of the receiver
+var _ evm.RollbackVerifier = (*Verifier)(nil)
+
 // next points to height whose parentHash is expected
 // parentHash of height h is got from next-1's hash
 type Verifier struct {
 	chainID    *big.Int
+	forks      parliaForks
 	mu         sync.RWMutex
 	next       *big.Int
 	parentHash ethCommon.Hash
-	validators map[ethCommon.Address]bool
+	// signers are the signers of the next block
+	signers *signers
+
+	// justified is the vote data of the latest vote attestation, whose
+	// target is the latest justified block, if any since the verifier
+	// started
+	justified *VoteData
+	// finalized is the height of the latest finalized block, the source of
+	// the latest vote attestation
+	finalized uint64
+	// checkpoint is the state of the verifier right after the validators
+	// of the latest epoch block took over, if any
+	checkpoint *verifierSnapshot
+
+	// history are the states of the verifier right before the latest
+	// evm.ReorgDepth blocks, oldest first, to roll back to on reorgs
+	history []*verifierState
+}
+
+// verifierState is the state of Verifier right before a block
+type verifierState struct {
+	next       uint64
+	parentHash ethCommon.Hash
+	signers    *signers
+	justified  *VoteData
+	finalized  uint64
+	checkpoint *verifierSnapshot
 }
 
 func (vr *Verifier) Next() *big.Int {
@@ -108,10 +183,29 @@ func (vr *Verifier) ParentHash() ethCommon.Hash {
 	return ethCommon.BytesToHash(vr.parentHash.Bytes())
 }
 
+// Justified returns the height of the latest block justified by vote
+// attestations, 0 if unknown
+func (vr *Verifier) Justified() uint64 {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	if vr.justified == nil {
+		return 0
+	}
+	return vr.justified.TargetNumber
+}
+
+// Finalized returns the height of the latest block finalized by vote
+// attestations, 0 if unknown
+func (vr *Verifier) Finalized() uint64 {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	return vr.finalized
+}
+
 func (vr *Verifier) IsValidator(addr ethCommon.Address) bool {
 	vr.mu.RLock()
 	defer vr.mu.RUnlock()
-	_, exists := vr.validators[addr]
+	_, exists := vr.signers.validators[addr]
 	return exists
 }
 
@@ -132,15 +226,28 @@ func (vr *Verifier) Verify(header *types.Header, nextHeader *types.Header, recei
 		return fmt.Errorf("Unexpected Hash(%v): Got %v Expected %v", header.Number, header.ParentHash, vr.ParentHash())
 	}
 
-	if err := vr.verifyHeader(nextHeader); err != nil {
+	vr.mu.RLock()
+	epochLength := vr.signers.epochLength
+	signers, err := vr.signers.apply(header, vr.forks)
+	vr.mu.RUnlock()
+	if err != nil {
+		return errors.Wrapf(err, "apply %v", err)
+	}
+	if err := vr.verifyHeader(nextHeader, signers.epochLength); err != nil {
 		return errors.Wrapf(err, "verifyHeader %v", err)
 	}
 	if err := vr.verifyCascadingFields(nextHeader, header); err != nil {
 		return errors.Wrapf(err, "verifyCascadingFields %v", err)
 	}
-	if err := vr.verifySeal(nextHeader, vr.ChainID()); err != nil {
+	if err := vr.verifySeal(nextHeader, vr.ChainID(), signers); err != nil {
 		return errors.Wrapf(err, "verifySeal %v", err)
 	}
+	if err := vr.verifyBlockTime(nextHeader, header, signers); err != nil {
+		return errors.Wrapf(err, "verifyBlockTime %v", err)
+	}
+	if err := vr.verifyVoteAttestation(nextHeader, header, signers.epochLength, epochLength); err != nil {
+		return errors.Wrapf(err, "verifyVoteAttestation %v", err)
+	}
 	if len(receipts) > 0 {
 		if err := vr.validateState(nextHeader, receipts); err != nil {
 			return errors.Wrapf(err, "validateState %v", err)
@@ -149,23 +256,122 @@ func (vr *Verifier) Verify(header *types.Header, nextHeader *types.Header, recei
 	return nil
 }
 
+// Update advances the verifier past header, updating the validators on
+// epoch blocks and the justified and finalized blocks on vote attestations
 func (vr *Verifier) Update(header *types.Header) (err error) {
 	vr.mu.Lock()
 	defer vr.mu.Unlock()
-	if header.Number.Uint64()%defaultEpochLength != 0 {
-		return nil
+	number := header.Number.Uint64()
+	justified, finalized := vr.justified, vr.finalized
+	if vr.forks.isPlato(number) {
+		attestation, err := vr.forks.getVoteAttestation(header, vr.signers.epochLength)
+		if err != nil {
+			return fmt.Errorf("getVoteAttestation %v", err)
+		}
+		if attestation != nil {
+			justified = attestation.Data
+			if attestation.Data.SourceNumber > finalized {
+				finalized = attestation.Data.SourceNumber
+			}
+		}
 	}
-	// update validators if epoch block
-	validatorMap, err := getValidatorMapFromHex(header.Extra)
+	signers, err := vr.signers.apply(header, vr.forks)
 	if err != nil {
-		return fmt.Errorf("getValidatorMapFromHex %v", err)
-	}
+		return fmt.Errorf("apply %v", err)
+	}
+	if len(vr.history) == evm.ReorgDepth {
+		vr.history = vr.history[1:]
+	}
+	vr.history = append(vr.history, &verifierState{
+		next:       vr.next.Uint64(),
+		parentHash: vr.parentHash,
+		signers:    vr.signers,
+		justified:  vr.justified,
+		finalized:  vr.finalized,
+		checkpoint: vr.checkpoint,
+	})
+
+	tookOver := vr.signers.epochValidators != nil && signers.epochValidators == nil
 	vr.parentHash = header.Hash()
 	vr.next.Add(header.Number, big1)
-	vr.validators = validatorMap
+	vr.signers = signers
+	vr.justified, vr.finalized = justified, finalized
+	if tookOver {
+		vr.checkpoint = vr.state()
+	}
 	return
 }
 
+// Hash returns the hash of the verified block at height, if it is one of
+// the latest ones
+func (vr *Verifier) Hash(height uint64) (ethCommon.Hash, bool) {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	if height+1 == vr.next.Uint64() {
+		return vr.parentHash, true
+	}
+	for _, st := range vr.history {
+		if st.next == height+1 {
+			return st.parentHash, true
+		}
+	}
+	return ethCommon.Hash{}, false
+}
+
+// Rollback rolls the verifier back to right before the block at height,
+// one of the latest verified blocks
+func (vr *Verifier) Rollback(height uint64) error {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	if height == vr.next.Uint64() {
+		return nil
+	}
+	for i, st := range vr.history {
+		if st.next == height {
+			vr.next.SetUint64(st.next)
+			vr.parentHash = st.parentHash
+			vr.signers = st.signers
+			vr.justified, vr.finalized = st.justified, st.finalized
+			vr.checkpoint = st.checkpoint
+			vr.history = vr.history[:i]
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown state before %d", height)
+}
+
+// verifierSnapshot ...
+// is the trusted state of Verifier right after the validators of an epoch
+// block took over, which is persisted to resume verification from there on
+// restart
+type verifierSnapshot struct {
+	Next       uint64                       `json:"next"`
+	ParentHash ethCommon.Hash               `json:"parentHash"`
+	Validators []ethCommon.Address          `json:"validators"`
+	Recents    map[uint64]ethCommon.Address `json:"recents,omitempty"`
+	// EpochLength is the length of the epoch of the next block, the
+	// default one if zero
+	EpochLength uint64 `json:"epochLength,omitempty"`
+}
+
+// snapshot returns the state of the verifier right after the validators of
+// the latest epoch block took over, nil if they haven't yet
+func (vr *Verifier) snapshot() *verifierSnapshot {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	return vr.checkpoint
+}
+
+func (vr *Verifier) state() *verifierSnapshot {
+	return &verifierSnapshot{
+		Next:        vr.next.Uint64(),
+		ParentHash:  vr.parentHash,
+		Validators:  vr.signers.sorted(),
+		Recents:     vr.signers.recents,
+		EpochLength: vr.signers.epochLength,
+	}
+}
+
 func getValidatorMapFromHex(headerExtra common.HexBytes) (map[ethCommon.Address]bool, error) {
 	if len(headerExtra) < extraVanity+extraSeal {
 		return nil, errMissingSignature
@@ -179,16 +385,18 @@ func getValidatorMapFromHex(headerExtra common.HexBytes) (map[ethCommon.Address]
 	return newVals, nil
 }
 
-func (vr *Verifier) verifyHeader(header *types.Header) error {
+// verifyHeader checks the fields of header, in an epoch of epochLength
+// blocks, on their own
+func (vr *Verifier) verifyHeader(header *types.Header, epochLength uint64) error {
 	if header.Number == nil {
 		return errUnknownBlock
 	}
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
-	// if header.Time > uint64(time.Now().Unix()) {
-	// 	return consensus.ErrFutureBlock
-	// }
+	if header.Time > uint64(time.Now().Unix())+allowedFutureBlockTime {
+		return consensus.ErrFutureBlock
+	}
 	// Check that the extra-data contains the vanity, validators and signature.
 	if len(header.Extra) < extraVanity {
 		return errMissingVanity
@@ -198,24 +406,45 @@ func (vr *Verifier) verifyHeader(header *types.Header) error {
 	}
 
 	// check extra data
-	isEpoch := number%defaultEpochLength == 0
+	isEpoch := number%epochLength == 0
 
-	// Ensure that the extra-data contains a signer list on checkpoint, but none otherwise
+	// Ensure that the extra-data contains a signer list on checkpoint, but
+	// none otherwise, besides vote attestations since Luban, and the turn
+	// length since Bohr
 	signersBytes := len(header.Extra) - extraVanity - extraSeal
-	if !isEpoch && signersBytes != 0 {
-		return errExtraValidators
-	}
+	if vr.forks.isLuban(number) {
+		if isEpoch && (signersBytes < validatorNumberSize || header.Extra[extraVanity] == 0) {
+			return errMissingValidators
+		}
+		if isEpoch && signersBytes < validatorNumberSize+int(header.Extra[extraVanity])*validatorBytesLengthLuban {
+			return errInvalidSpanValidators
+		}
+		if isEpoch {
+			if _, err := vr.forks.getTurnLength(header); err != nil {
+				return err
+			}
+		}
+	} else {
+		if !isEpoch && signersBytes != 0 {
+			return errExtraValidators
+		}
 
-	if isEpoch && signersBytes == 0 {
-		return errMissingValidators
-	}
+		if isEpoch && signersBytes == 0 {
+			return errMissingValidators
+		}
 
-	if isEpoch && signersBytes%validatorBytesLength != 0 {
-		return errInvalidSpanValidators
+		if isEpoch && signersBytes%validatorBytesLength != 0 {
+			return errInvalidSpanValidators
+		}
 	}
 
-	// Ensure that the mix digest is zero as we don't have fork protection currently
-	if header.MixDigest != (ethCommon.Hash{}) {
+	// Ensure that the mix digest is zero before Lorentz, and the
+	// milliseconds of the timestamp since
+	if !vr.forks.isLorentz(header.Time) {
+		if header.MixDigest != (ethCommon.Hash{}) {
+			return errInvalidMixDigest
+		}
+	} else if ms := new(big.Int).SetBytes(header.MixDigest[:]); !ms.IsUint64() || ms.Uint64() >= 1000 {
 		return errInvalidMixDigest
 	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
@@ -260,7 +489,24 @@ func (vr *Verifier) verifyCascadingFields(header *types.Header, parent *types.He
 	return nil
 }
 
-func (vr *Verifier) verifySeal(header *types.Header, chainID *big.Int) error {
+// verifyBlockTime checks that header is at least the block interval after
+// parent, and the back-off of its signer, one of signers, later when out of
+// turn
+func (vr *Verifier) verifyBlockTime(header, parent *types.Header, signers *signers) error {
+	interval := vr.forks.blockInterval(parent.Time) + signers.backOffTime(header.Coinbase, parent, vr.forks)
+	if milliTimestamp(header) < milliTimestamp(parent)+interval {
+		return errInvalidTimestamp
+	}
+	return nil
+}
+
+// milliTimestamp returns the timestamp of header in milliseconds, which are
+// in the mix digest since Lorentz
+func milliTimestamp(header *types.Header) uint64 {
+	return header.Time*1000 + new(big.Int).SetBytes(header.MixDigest[:]).Uint64()
+}
+
+func (vr *Verifier) verifySeal(header *types.Header, chainID *big.Int, signers *signers) error {
 	// Resolve the authorization key and check against validators
 	signer, err := ecrecover(header, chainID)
 	if err != nil {
@@ -270,10 +516,73 @@ func (vr *Verifier) verifySeal(header *types.Header, chainID *big.Int) error {
 		return errCoinBaseMisMatch
 	}
 
-	if ok := vr.IsValidator(signer); !ok {
+	if _, ok := signers.validators[signer]; !ok {
 		return errUnauthorizedValidator
 	}
-	// TODO: check if signer is a recent Validator; avoid recent validators for spam protection
+	// avoid recent validators for spam protection
+	if signers.recentlySigned(signer) {
+		return errRecentlySigned
+	}
+	// Ensure that the difficulty corresponds to the turn-ness of the signer
+	inturn := signers.inturn(signer)
+	if inturn && header.Difficulty.Cmp(diffInTurn) != 0 {
+		return errWrongDifficulty
+	}
+	if !inturn && header.Difficulty.Cmp(diffNoTurn) != 0 {
+		return errWrongDifficulty
+	}
+	return nil
+}
+
+// verifyVoteAttestation ...
+// checks the vote attestation of header, for its parent, in epochs of
+// epochLength and parentEpochLength blocks: since Plato, at least 2/3 of the
+// validators must have voted to justify the parent, with the latest
+// justified block as source
+func (vr *Verifier) verifyVoteAttestation(header, parent *types.Header, epochLength, parentEpochLength uint64) error {
+	number := header.Number.Uint64()
+	if !vr.forks.isPlato(number) {
+		return nil
+	}
+	attestation, err := vr.forks.getVoteAttestation(header, epochLength)
+	if err != nil || attestation == nil {
+		return err
+	}
+	data := attestation.Data
+	if data.TargetNumber != parent.Number.Uint64() || data.TargetHash != parent.Hash() {
+		return fmt.Errorf("invalid attestation, target mismatch, expected block: %d, hash: %s; real block: %d, hash: %s",
+			parent.Number, parent.Hash(), data.TargetNumber, data.TargetHash)
+	}
+
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	// the source is the target of the latest attestation, the one of the
+	// parent if any; unknown until the verifier sees one
+	justified := vr.justified
+	if parentAttestation, err := vr.forks.getVoteAttestation(parent, parentEpochLength); err != nil {
+		return err
+	} else if parentAttestation != nil && vr.forks.isPlato(parent.Number.Uint64()) {
+		justified = parentAttestation.Data
+	}
+	if justified != nil && (data.SourceNumber != justified.TargetNumber || data.SourceHash != justified.TargetHash) {
+		return fmt.Errorf("invalid attestation, source mismatch, expected block: %d, hash: %s; real block: %d, hash: %s",
+			justified.TargetNumber, justified.TargetHash, data.SourceNumber, data.SourceHash)
+	}
+
+	if len(vr.signers.voteKeys) == 0 {
+		return errors.New("invalid attestation, validators without vote keys")
+	}
+	keys, err := voters(attestation, vr.signers.voteKeys)
+	if err != nil {
+		return err
+	}
+	// the valid voted validators should be no less than 2/3 validators
+	if len(keys) < (len(vr.signers.voteKeys)*2+2)/3 {
+		return errors.New("invalid attestation, not enough validators voted")
+	}
+	if err := blsFastAggregateVerify(keys, data.Hash().Bytes(), attestation.AggSignature); err != nil {
+		return errors.Wrapf(err, "invalid attestation, signature verify failed")
+	}
 	return nil
 }
 
@@ -328,13 +637,5 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
 }
 
 func (vr *Verifier) validateState(header *types.Header, receipts types.Receipts) error {
-	rbloom := types.CreateBloom(receipts)
-	if rbloom != header.Bloom {
-		return fmt.Errorf("invalid bloom (remote: %x  local: %x)", header.Bloom, rbloom)
-	}
-	receiptSha := types.DeriveSha(receipts, trie.NewStackTrie(nil))
-	if receiptSha != header.ReceiptHash {
-		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
-	}
-	return nil
+	return evm.ValidateReceipts(header, receipts)
 }
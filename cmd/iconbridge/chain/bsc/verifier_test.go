@@ -0,0 +1,578 @@
+package bsc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestBLSFastAggregateVerify(t *testing.T) {
+	// signed with an independent implementation of the ethereum 2 scheme
+	var pubKeys []BLSPublicKey
+	for _, s := range []string{
+		"ae5e38738a89ddfc8356ee816d2318a2a3d2b1293dab5907593c32035397d2d9bd00041be62ae859ca4eef2b7806f040",
+		"a4127fa8eb5b2113868cc2cb16fdc619218d8a8d1b7f7c52d5a9cd5aca1d36b089f77c4ad6b446762590d147d8ae8c01",
+		"8905a25e0bd324b3bfb8dabe5b55f6a49e04a891c1c00b96edafbf20038b900af4617bdc2b218d30131f33a91725bb3b",
+	} {
+		var pubKey BLSPublicKey
+		copy(pubKey[:], mustDecodeHex(t, s))
+		pubKeys = append(pubKeys, pubKey)
+	}
+	var sig BLSSignature
+	copy(sig[:], mustDecodeHex(t, "86f98042e3c21531045f94498a5114d5c50d308d9cc73b88ea5220ac91be870ced162b48cb9aa83dd75ce0120b66f24c0587e700c6503982ba2f98c1557ce1919955e8b9db3af9905400a6e8f9da2907d72f866ecea55fc2897dc88403c69c55"))
+	msg := []byte("hello vote")
+
+	require.NoError(t, blsFastAggregateVerify(pubKeys, msg, sig))
+	assert.Error(t, blsFastAggregateVerify(pubKeys, []byte("hello votes"), sig), "other message")
+	assert.Error(t, blsFastAggregateVerify(pubKeys[:2], msg, sig), "missing signer")
+	assert.Error(t, blsFastAggregateVerify(nil, msg, sig), "no signers")
+
+	uncompressed := sig
+	uncompressed[0] &^= 0x80
+	assert.Error(t, blsFastAggregateVerify(pubKeys, msg, uncompressed), "uncompressed")
+	infinity := pubKeys[0]
+	infinity[0] |= 0x40
+	assert.Error(t, blsFastAggregateVerify([]BLSPublicKey{infinity}, msg, sig), "infinity")
+}
+
+type testValidator struct {
+	key   *ecdsa.PrivateKey
+	blsSK *big.Int
+}
+
+func newTestValidators(t *testing.T, n int) []*testValidator {
+	vals := make([]*testValidator, n)
+	for i := range vals {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		vals[i] = &testValidator{key: key, blsSK: new(big.Int).Set(key.D)}
+	}
+	return vals
+}
+
+func (v *testValidator) address() ethCommon.Address {
+	return crypto.PubkeyToAddress(v.key.PublicKey)
+}
+
+func (v *testValidator) voteKey() (key BLSPublicKey) {
+	g1 := bls12381.NewG1()
+	b := g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), v.blsSK))
+	copy(key[:], b[:48])
+	key[0] |= 0x80
+	if new(big.Int).SetBytes(b[48:]).Cmp(blsPHalf) > 0 {
+		key[0] |= 0x20
+	}
+	return key
+}
+
+// testAttestation returns the attestation of data by voters
+func testAttestation(t *testing.T, data *VoteData, vals []*testValidator, voters ...int) []byte {
+	g2 := bls12381.NewG2()
+	h, err := hashToG2(g2, data.Hash().Bytes())
+	require.NoError(t, err)
+	sorted := map[ethCommon.Address]BLSPublicKey{}
+	for _, v := range vals {
+		sorted[v.address()] = v.voteKey()
+	}
+	attestation := &VoteAttestation{Data: data}
+	agg := g2.Zero()
+	for _, i := range voters {
+		g2.Add(agg, agg, g2.MulScalar(g2.New(), h, vals[i].blsSK))
+		bit, err := voterBit(sorted, vals[i].voteKey())
+		require.NoError(t, err)
+		attestation.VoteAddressSet |= bit
+	}
+	b := g2.ToBytes(agg)
+	copy(attestation.AggSignature[:], b[:96])
+	attestation.AggSignature[0] |= 0x80
+	y := fp2{new(big.Int).SetBytes(b[144:]), new(big.Int).SetBytes(b[96:144])}
+	if y.isLargest() {
+		attestation.AggSignature[0] |= 0x20
+	}
+	enc, err := rlp.EncodeToBytes(attestation)
+	require.NoError(t, err)
+	return enc
+}
+
+// voterBit returns the bit of the validator with voteKey among validators
+func voterBit(validators map[ethCommon.Address]BLSPublicKey, voteKey BLSPublicKey) (uint64, error) {
+	for i := 0; i < len(validators); i++ {
+		keys, err := voters(&VoteAttestation{VoteAddressSet: 1 << uint(i)}, validators)
+		if err != nil {
+			return 0, err
+		}
+		if keys[0] == voteKey {
+			return 1 << uint(i), nil
+		}
+	}
+	return 0, nil
+}
+
+// testChain seals test headers of a chain with validators, in turns of
+// turnLength blocks, 1 if zero
+type testChain struct {
+	chainID    *big.Int
+	validators []*testValidator
+	turnLength uint64
+}
+
+// sorted returns the validators ordered by address
+func (c *testChain) sorted() []*testValidator {
+	sorted := append([]*testValidator{}, c.validators...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].address().Bytes(), sorted[j].address().Bytes()) < 0
+	})
+	return sorted
+}
+
+// inturn returns the validator in turn to seal the block at number
+func (c *testChain) inturn(number uint64) *testValidator {
+	turnLength := c.turnLength
+	if turnLength == 0 {
+		turnLength = defaultTurnLength
+	}
+	sorted := c.sorted()
+	return sorted[number/turnLength%uint64(len(sorted))]
+}
+
+// header returns the header at number after parent sealed by signer a
+// block interval after parent, later than any back-off when out of turn,
+// with payload between the vanity and the seal of its extra-data
+func (c *testChain) header(t *testing.T, number uint64, parent *ethTypes.Header, signer *testValidator, payload []byte) *ethTypes.Header {
+	header := &ethTypes.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Coinbase:   signer.address(),
+		UncleHash:  uncleHash,
+		Difficulty: diffNoTurn,
+		GasLimit:   30000000,
+		Time:       1000000,
+		Extra:      append(append(make([]byte, extraVanity), payload...), make([]byte, extraSeal)...),
+	}
+	if c.inturn(number) == signer {
+		header.Difficulty = diffInTurn
+	}
+	if parent != nil {
+		header.ParentHash = parent.Hash()
+		header.Time = parent.Time + defaultBlockInterval/1000
+		if header.Difficulty == diffNoTurn {
+			header.Time += uint64(len(c.validators)) * wiggleTime / 1000
+		}
+	}
+	c.seal(t, header, signer)
+	return header
+}
+
+// seal signs header by signer
+func (c *testChain) seal(t *testing.T, header *ethTypes.Header, signer *testValidator) {
+	sig, err := crypto.Sign(SealHash(header, c.chainID).Bytes(), signer.key)
+	require.NoError(t, err)
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// newTestVerifier returns a verifier of c from next on
+func newTestVerifier(c *testChain, forks parliaForks, next uint64,
+	validators map[ethCommon.Address]bool, voteKeys map[ethCommon.Address]BLSPublicKey) *Verifier {
+	return &Verifier{
+		chainID: c.chainID,
+		forks:   forks,
+		next:    new(big.Int).SetUint64(next),
+		signers: &signers{
+			number:      next - 1,
+			validators:  validators,
+			voteKeys:    voteKeys,
+			turnLength:  defaultTurnLength,
+			epochLength: defaultEpochLength,
+			recents:     map[uint64]ethCommon.Address{},
+		},
+	}
+}
+
+// addresses returns the validator set of vals
+func addresses(vals ...*testValidator) map[ethCommon.Address]bool {
+	validators := make(map[ethCommon.Address]bool, len(vals))
+	for _, v := range vals {
+		validators[v.address()] = true
+	}
+	return validators
+}
+
+func TestVerifier_VoteAttestation(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	vals := c.validators
+	var epochPayload []byte
+	epochPayload = append(epochPayload, byte(len(vals)))
+	for _, v := range vals {
+		key := v.voteKey()
+		epochPayload = append(append(epochPayload, v.address().Bytes()...), key[:]...)
+	}
+	// the epoch block justifies its parent
+	epochPayload = append(epochPayload, testAttestation(t, &VoteData{
+		SourceNumber: 198, SourceHash: ethCommon.Hash{198},
+		TargetNumber: 199, TargetHash: ethCommon.Hash{199},
+	}, vals, 0, 1, 2)...)
+
+	h200 := c.header(t, 200, nil, c.inturn(200), epochPayload)
+	h201 := c.header(t, 201, h200, c.inturn(201), nil)
+	vote := func(source, target *ethTypes.Header, voters ...int) []byte {
+		sourceNumber, sourceHash := uint64(199), ethCommon.Hash{199}
+		if source != nil {
+			sourceNumber, sourceHash = source.Number.Uint64(), source.Hash()
+		}
+		return testAttestation(t, &VoteData{
+			SourceNumber: sourceNumber, SourceHash: sourceHash,
+			TargetNumber: target.Number.Uint64(), TargetHash: target.Hash(),
+		}, vals, voters...)
+	}
+	h202 := c.header(t, 202, h201, c.inturn(202), vote(nil, h201, 0, 2))
+	h203 := c.header(t, 203, h202, c.inturn(203), vote(h201, h202, 0, 1, 2))
+
+	forks := parliaForks{Luban: 100, Plato: 100, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}
+	validators, voteKeys, err := forks.getValidatorsFromExtra(200, h200.Extra)
+	require.NoError(t, err)
+	require.Len(t, validators, 3)
+	require.Len(t, voteKeys, 3)
+	vr := newTestVerifier(c, forks, 200, validators, voteKeys)
+
+	require.NoError(t, vr.Verify(h200, h201, nil))
+	require.NoError(t, vr.Update(h200))
+	assert.EqualValues(t, 199, vr.Justified())
+	assert.EqualValues(t, 198, vr.Finalized())
+	assert.Nil(t, vr.snapshot(), "validators of the epoch block haven't taken over")
+
+	require.NoError(t, vr.Verify(h201, h202, nil))
+	require.NoError(t, vr.Update(h201))
+	require.NotNil(t, vr.snapshot())
+	assert.EqualValues(t, 202, vr.snapshot().Next)
+	require.NoError(t, vr.Verify(h202, h203, nil))
+	require.NoError(t, vr.Update(h202))
+	assert.EqualValues(t, 201, vr.Justified())
+	assert.EqualValues(t, 199, vr.Finalized())
+	assert.EqualValues(t, 202, vr.snapshot().Next, "checkpoint of the take over")
+
+	for name, next := range map[string]*ethTypes.Header{
+		"not enough votes": c.header(t, 204, h203, c.inturn(204), vote(h202, h203, 1)),
+		"target mismatch":  c.header(t, 204, h203, c.inturn(204), vote(h202, h202, 0, 1, 2)),
+		"source mismatch":  c.header(t, 204, h203, c.inturn(204), vote(h201, h203, 0, 1, 2)),
+		"invalid":          c.header(t, 204, h203, c.inturn(204), []byte{0xc0}),
+	} {
+		assert.Error(t, vr.Verify(h203, next, nil), name)
+	}
+	// votes of others
+	others := newTestValidators(t, 3)
+	forged := testAttestation(t, &VoteData{
+		SourceNumber: 202, SourceHash: h202.Hash(),
+		TargetNumber: 203, TargetHash: h203.Hash(),
+	}, others, 0, 1, 2)
+	assert.Error(t, vr.Verify(h203, c.header(t, 204, h203, c.inturn(204), forged), nil), "forged")
+
+	h204 := c.header(t, 204, h203, c.inturn(204), vote(h202, h203, 1, 2))
+	require.NoError(t, vr.Verify(h203, h204, nil))
+	require.NoError(t, vr.Update(h203))
+	assert.EqualValues(t, 202, vr.Justified())
+	assert.EqualValues(t, 201, vr.Finalized())
+}
+
+func TestVerifier_BeforePlato(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 1)}
+	signer := c.validators[0]
+	h1 := c.header(t, 1, nil, signer, nil)
+	// attestations aren't verified before plato
+	h2 := c.header(t, 2, h1, signer, []byte{0xc0})
+	h3 := c.header(t, 3, h2, signer, []byte{0xc0})
+
+	vr := newTestVerifier(c, parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}, 1, addresses(signer), nil)
+	assert.Error(t, vr.Verify(h1, h2, nil), "extra data before luban")
+	vr.forks.Luban = 2
+
+	require.NoError(t, vr.Verify(h1, h2, nil))
+	require.NoError(t, vr.Update(h1))
+	require.NoError(t, vr.Verify(h2, h3, nil))
+	require.NoError(t, vr.Update(h2))
+	assert.EqualValues(t, 3, vr.Next().Uint64())
+	assert.Zero(t, vr.Justified())
+}
+
+func TestVerifier_Seal(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	forks := parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}
+	vr := newTestVerifier(c, forks, 1, addresses(c.validators...), nil)
+
+	h1 := c.header(t, 1, nil, c.inturn(1), nil)
+	h2 := c.header(t, 2, h1, c.inturn(2), nil)
+	require.NoError(t, vr.Verify(h1, h2, nil))
+	require.NoError(t, vr.Update(h1))
+
+	// the signer of h2 signed too recently to seal h3, that of h1 didn't
+	h3 := c.header(t, 3, h2, c.inturn(2), nil)
+	assert.Equal(t, errRecentlySigned, errors.Cause(vr.Verify(h2, h3, nil)), "recently signed")
+	h3 = c.header(t, 3, h2, c.inturn(1), nil)
+	require.Equal(t, diffNoTurn, h3.Difficulty)
+	require.NoError(t, vr.Verify(h2, h3, nil), "out of turn")
+
+	h3.Difficulty = diffInTurn
+	c.seal(t, h3, c.inturn(1))
+	assert.Equal(t, errWrongDifficulty, errors.Cause(vr.Verify(h2, h3, nil)), "out of turn difficulty")
+	h3 = c.header(t, 3, h2, c.inturn(3), nil)
+	h3.Difficulty = diffNoTurn
+	c.seal(t, h3, c.inturn(3))
+	assert.Equal(t, errWrongDifficulty, errors.Cause(vr.Verify(h2, h3, nil)), "in turn difficulty")
+
+	h3 = c.header(t, 3, h2, c.inturn(3), nil)
+	h3.Time = h2.Time + defaultBlockInterval/1000 - 1
+	c.seal(t, h3, c.inturn(3))
+	assert.Equal(t, errInvalidTimestamp, errors.Cause(vr.Verify(h2, h3, nil)), "too early")
+	h3.Time = uint64(time.Now().Unix()) + allowedFutureBlockTime + 60
+	c.seal(t, h3, c.inturn(3))
+	assert.Equal(t, consensus.ErrFutureBlock, errors.Cause(vr.Verify(h2, h3, nil)), "future")
+
+	h3 = c.header(t, 3, h2, newTestValidators(t, 1)[0], nil)
+	assert.Equal(t, errUnauthorizedValidator, errors.Cause(vr.Verify(h2, h3, nil)), "unauthorized")
+
+	h3 = c.header(t, 3, h2, c.inturn(3), nil)
+	require.NoError(t, vr.Verify(h2, h3, nil))
+	require.NoError(t, vr.Update(h2))
+	assert.Nil(t, vr.snapshot())
+	assert.Len(t, vr.state().Recents, 2, "recents of the latest len(validators)/2+1 blocks")
+}
+
+func TestVerifier_BlockTime(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 5)}
+	// lorentz at h3, maxwell right after
+	forks := parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: 1000006, Maxwell: 1000007}
+	vr := newTestVerifier(c, forks, 1, addresses(c.validators...), nil)
+	at := func(header *ethTypes.Header, signer *testValidator, ms uint64) *ethTypes.Header {
+		header.Time, header.MixDigest = ms/1000, ethCommon.BigToHash(new(big.Int).SetUint64(ms%1000))
+		c.seal(t, header, signer)
+		return header
+	}
+
+	h1 := c.header(t, 1, nil, c.inturn(1), nil)
+	h2 := c.header(t, 2, h1, c.inturn(2), nil)
+	h3 := c.header(t, 3, h2, c.inturn(3), nil)
+	require.EqualValues(t, forks.Lorentz, h3.Time)
+	ms := at(c.header(t, 2, h1, c.inturn(2), nil), c.inturn(2), h2.Time*1000+1)
+	assert.Equal(t, errInvalidMixDigest, errors.Cause(vr.Verify(h1, ms, nil)), "milliseconds before lorentz")
+	require.NoError(t, vr.Verify(h1, h2, nil))
+	require.NoError(t, vr.Update(h1))
+	require.NoError(t, vr.Verify(h2, h3, nil))
+	require.NoError(t, vr.Update(h2))
+
+	// blocks are 1.5s apart since lorentz
+	start := h3.Time * 1000
+	h4 := at(c.header(t, 4, h3, c.inturn(4), nil), c.inturn(4), start+lorentzBlockInterval-1)
+	assert.Equal(t, errInvalidTimestamp, errors.Cause(vr.Verify(h3, h4, nil)), "too early")
+	h4 = c.header(t, 4, h3, c.inturn(4), nil)
+	h4.Time, h4.MixDigest = h3.Time+1, ethCommon.BigToHash(big.NewInt(1000))
+	c.seal(t, h4, c.inturn(4))
+	assert.Equal(t, errInvalidMixDigest, errors.Cause(vr.Verify(h3, h4, nil)), "milliseconds")
+
+	// the signers of h2 and h3 signed recently, and the others back off by
+	// 0.5s and their turns shuffled by the height of h3: v0, v1 then v4
+	for _, tc := range []struct {
+		signer  *testValidator
+		backOff uint64
+	}{{c.inturn(0), 0}, {c.inturn(1), wiggleTime}} {
+		h4 = at(c.header(t, 4, h3, tc.signer, nil), tc.signer, start+lorentzBlockInterval+lorentzInitialBackOffTime+tc.backOff-1)
+		require.Equal(t, diffNoTurn, h4.Difficulty)
+		assert.Equal(t, errInvalidTimestamp, errors.Cause(vr.Verify(h3, h4, nil)), "backed off too little")
+		h4 = at(c.header(t, 4, h3, tc.signer, nil), tc.signer, start+lorentzBlockInterval+lorentzInitialBackOffTime+tc.backOff)
+		assert.NoError(t, vr.Verify(h3, h4, nil), "backed off")
+	}
+
+	// and 0.75s apart since maxwell
+	h4 = at(c.header(t, 4, h3, c.inturn(4), nil), c.inturn(4), start+lorentzBlockInterval)
+	require.NoError(t, vr.Verify(h3, h4, nil))
+	require.NoError(t, vr.Update(h3))
+	h5 := at(c.header(t, 5, h4, c.inturn(5), nil), c.inturn(5), start+lorentzBlockInterval+maxwellBlockInterval-1)
+	assert.Equal(t, errInvalidTimestamp, errors.Cause(vr.Verify(h4, h5, nil)), "too early")
+	h5 = at(c.header(t, 5, h4, c.inturn(5), nil), c.inturn(5), start+lorentzBlockInterval+maxwellBlockInterval)
+	require.NoError(t, vr.Verify(h4, h5, nil))
+}
+
+func TestVerifier_TurnLength(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	forks := parliaForks{Luban: 0, Plato: noFork, Bohr: 0, Lorentz: noFork, Maxwell: noFork}
+	vr := newTestVerifier(c, forks, 200, addresses(c.validators...), nil)
+	epochPayload := func(turnLength ...byte) []byte {
+		payload := []byte{byte(len(c.validators))}
+		for _, v := range c.validators {
+			key := v.voteKey()
+			payload = append(append(payload, v.address().Bytes()...), key[:]...)
+		}
+		return append(payload, turnLength...)
+	}
+	assert.Equal(t, errInvalidTurnLength, vr.verifyHeader(c.header(t, 200, nil, c.inturn(200), epochPayload()), defaultEpochLength), "missing")
+	assert.Equal(t, errInvalidTurnLength, vr.verifyHeader(c.header(t, 200, nil, c.inturn(200), epochPayload(0)), defaultEpochLength), "zero")
+
+	// validators seal turns of 4 blocks once those of the epoch block take
+	// over, after len(validators)/2 turns of 1 block
+	headers := []*ethTypes.Header{c.header(t, 200, nil, c.inturn(200), epochPayload(4))}
+	headers = append(headers, c.header(t, 201, headers[0], c.inturn(201), nil))
+	c.turnLength = 4
+	for number := uint64(202); number <= 207; number++ {
+		headers = append(headers, c.header(t, number, headers[len(headers)-1], c.inturn(number), nil))
+	}
+	for i := 0; i+2 < len(headers); i++ {
+		require.NoError(t, vr.Verify(headers[i], headers[i+1], nil), "height %d", headers[i+1].Number)
+		require.NoError(t, vr.Update(headers[i]))
+	}
+	require.NotNil(t, vr.snapshot())
+	assert.EqualValues(t, 202, vr.snapshot().Next)
+	assert.Empty(t, vr.snapshot().Recents, "recents cleared with the take over")
+	sorted := c.sorted()
+	require.Equal(t, sorted[0], c.inturn(205), "turn of 204 to 207")
+	require.Equal(t, diffInTurn, headers[5].Difficulty, "205 in turn")
+
+	// the validator that sealed 202 and 203 may seal out of turn, not the
+	// one of the latest turn
+	h206, h207 := headers[6], headers[7]
+	require.NoError(t, vr.Verify(h206, h207, nil))
+	require.NoError(t, vr.Update(h206))
+	out := c.header(t, 208, h207, sorted[2], nil)
+	require.Equal(t, diffNoTurn, out.Difficulty)
+	require.NoError(t, vr.Verify(h207, out, nil))
+	out = c.header(t, 208, h207, sorted[0], nil)
+	assert.Equal(t, errRecentlySigned, errors.Cause(vr.Verify(h207, out, nil)), "recently signed")
+	require.NoError(t, vr.Verify(h207, c.header(t, 208, h207, c.inturn(208), nil), nil))
+	require.NoError(t, vr.Update(h207))
+	assert.Len(t, vr.state().Recents, 6, "recents since the take over")
+}
+
+func TestVerifier_EpochLength(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	forks := parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: 0, Maxwell: noFork}
+	vr := newTestVerifier(c, forks, 401, addresses(c.validators...), nil)
+	var epochPayload []byte
+	for _, v := range c.validators {
+		epochPayload = append(epochPayload, v.address().Bytes()...)
+	}
+	headers := []*ethTypes.Header{c.header(t, 401, nil, c.inturn(401), nil)}
+	for number := uint64(402); number <= 502; number++ {
+		var payload []byte
+		if number == 500 {
+			payload = epochPayload
+		}
+		headers = append(headers, c.header(t, number, headers[len(headers)-1], c.inturn(number), payload))
+	}
+
+	// epochs are 500 blocks long from 500 on, since lorentz
+	for i := 0; i+1 < len(headers); i++ {
+		require.NoError(t, vr.Verify(headers[i], headers[i+1], nil), "height %d", headers[i+1].Number)
+		require.NoError(t, vr.Update(headers[i]))
+	}
+	assert.EqualValues(t, lorentzEpochLength, vr.signers.epochLength)
+	require.NotNil(t, vr.snapshot())
+	assert.EqualValues(t, 502, vr.snapshot().Next)
+	assert.EqualValues(t, lorentzEpochLength, vr.snapshot().EpochLength)
+}
+
+func TestVerifier_ValidatorsTakeOver(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 3)}
+	forks := parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}
+	vr := newTestVerifier(c, forks, 200, addresses(c.validators...), nil)
+
+	// the validator in turn at 201 leaves with the epoch block
+	leaving := c.inturn(201)
+	next := &testChain{chainID: c.chainID, validators: newTestValidators(t, 1)}
+	for _, v := range c.validators {
+		if v != leaving {
+			next.validators = append(next.validators, v)
+		}
+	}
+	var epochPayload []byte
+	for _, v := range next.validators {
+		epochPayload = append(epochPayload, v.address().Bytes()...)
+	}
+	h200 := c.header(t, 200, nil, c.inturn(200), epochPayload)
+	// the validators of the epoch block take over after len(validators)/2 blocks
+	h201 := c.header(t, 201, h200, leaving, nil)
+	require.NoError(t, vr.Verify(h200, h201, nil))
+	require.NoError(t, vr.Update(h200))
+	assert.True(t, vr.IsValidator(leaving.address()))
+	assert.Nil(t, vr.snapshot())
+
+	h202 := next.header(t, 202, h201, leaving, nil)
+	assert.Equal(t, errUnauthorizedValidator, errors.Cause(vr.Verify(h201, h202, nil)), "left")
+	h202 = next.header(t, 202, h201, next.inturn(202), nil)
+	require.NoError(t, vr.Verify(h201, h202, nil))
+	require.NoError(t, vr.Update(h201))
+	assert.False(t, vr.IsValidator(leaving.address()))
+	assert.True(t, vr.IsValidator(next.validators[0].address()))
+	require.NotNil(t, vr.snapshot())
+	assert.EqualValues(t, 202, vr.snapshot().Next)
+	assert.Len(t, vr.snapshot().Validators, 3)
+
+	// rolled back before the take over
+	require.NoError(t, vr.Rollback(201))
+	assert.True(t, vr.IsValidator(leaving.address()))
+	assert.Nil(t, vr.snapshot())
+}
+
+func TestVerifier_Rollback(t *testing.T) {
+	c := &testChain{chainID: big.NewInt(1337), validators: newTestValidators(t, 5)}
+	vr := newTestVerifier(c, parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}, 1, addresses(c.validators...), nil)
+	headers := []*ethTypes.Header{c.header(t, 1, nil, c.inturn(1), nil)}
+	for h := uint64(2); h <= 5; h++ {
+		headers = append(headers, c.header(t, h, headers[len(headers)-1], c.inturn(h), nil))
+	}
+	for i := 0; i < 4; i++ {
+		require.NoError(t, vr.Verify(headers[i], headers[i+1], nil))
+		require.NoError(t, vr.Update(headers[i]))
+	}
+	hash, ok := vr.Hash(2)
+	require.True(t, ok)
+	assert.Equal(t, headers[1].Hash(), hash)
+	_, ok = vr.Hash(5)
+	assert.False(t, ok, "not verified yet")
+
+	require.NoError(t, vr.Rollback(3))
+	assert.EqualValues(t, 3, vr.Next().Uint64())
+	assert.Equal(t, headers[1].Hash(), vr.ParentHash())
+	assert.Error(t, vr.Rollback(4), "rolled back")
+
+	// the recent signers are those upto the fork
+	fork := c.header(t, 3, headers[1], c.inturn(5), nil)
+	next := c.header(t, 4, fork, c.inturn(2), nil)
+	assert.Equal(t, errRecentlySigned, errors.Cause(vr.Verify(fork, next, nil)))
+	next = c.header(t, 4, fork, c.inturn(4), nil)
+	require.NoError(t, vr.Verify(fork, next, nil))
+	require.NoError(t, vr.Update(fork))
+	hash, _ = vr.Hash(3)
+	assert.Equal(t, fork.Hash(), hash)
+}
+
+func TestValidateReceiverOptions(t *testing.T) {
+	verifier, err := json.Marshal(&VerifierOptions{BlockHeight: 1})
+	require.NoError(t, err)
+	opts := func(finality string) []byte {
+		b, err := json.Marshal(map[string]interface{}{"verifier": json.RawMessage(verifier), "finality": finality})
+		require.NoError(t, err)
+		return b
+	}
+	assert.NoError(t, ValidateReceiverOptions(opts("")))
+	assert.NoError(t, ValidateReceiverOptions(opts(FinalityFinalized)))
+	assert.Error(t, ValidateReceiverOptions(opts("safe")))
+	assert.Error(t, ValidateReceiverOptions([]byte(`{"finality":"finalized"}`)), "missing verifier")
+	assert.NoError(t, ValidateReceiverOptions([]byte(`{"verifier":{},"logRange":5000}`)))
+}
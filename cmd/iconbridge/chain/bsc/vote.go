@@ -0,0 +1,253 @@
+package bsc
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+)
+
+const (
+	validatorNumberSize       = 1 // Fixed number of extra-data bytes for the number of validators since Luban
+	validatorBytesLengthLuban = ethCommon.AddressLength + blsPublicKeyLength
+	turnLengthSize            = 1 // Fixed number of extra-data bytes for the turn length since Bohr
+	defaultTurnLength         = uint64(1)
+	maxAttestationExtraLength = 256
+)
+
+// parliaForks ...
+// are the heights of the upgrades of parlia that change the verification
+// of headers
+type parliaForks struct {
+	// Luban adds the BLS vote keys of validators to epoch headers, and
+	// vote attestations to headers
+	Luban uint64
+	// Plato enables fast finality: vote attestations must be valid
+	Plato uint64
+	// Bohr is the time of the upgrade after which validators seal turns of
+	// consecutive blocks, whose length follows the validators in the
+	// extra-data of epoch headers
+	Bohr uint64
+	// Lorentz and Maxwell are the times of the upgrades that shorten the
+	// block interval and lengthen epochs; since Lorentz, the mix digest of
+	// headers holds the milliseconds of their timestamp
+	Lorentz uint64
+	Maxwell uint64
+}
+
+// noFork is the height of upgrades a chain doesn't have
+const noFork = math.MaxUint64
+
+var knownParliaForks = map[int64]parliaForks{
+	56: {Luban: 29020050, Plato: 30720096, Bohr: 1727317200, Lorentz: 1745903100, Maxwell: 1751250600}, // mainnet
+	97: {Luban: 29295050, Plato: 29861024, Bohr: 1724116996, Lorentz: 1744097580, Maxwell: 1748243100}, // testnet (chapel)
+}
+
+// newParliaForks returns the upgrades of the chain, overridden by opts
+func newParliaForks(chainID *big.Int, opts *VerifierOptions) parliaForks {
+	forks, ok := knownParliaForks[chainID.Int64()]
+	if !ok {
+		forks = parliaForks{Luban: noFork, Plato: noFork, Bohr: noFork, Lorentz: noFork, Maxwell: noFork}
+	}
+	if opts.LubanHeight > 0 {
+		forks.Luban = opts.LubanHeight
+	}
+	if opts.PlatoHeight > 0 {
+		forks.Plato = opts.PlatoHeight
+	}
+	if opts.BohrTime > 0 {
+		forks.Bohr = opts.BohrTime
+	}
+	if opts.LorentzTime > 0 {
+		forks.Lorentz = opts.LorentzTime
+	}
+	if opts.MaxwellTime > 0 {
+		forks.Maxwell = opts.MaxwellTime
+	}
+	return forks
+}
+
+func (f parliaForks) isLuban(number uint64) bool {
+	return number >= f.Luban
+}
+
+func (f parliaForks) isPlato(number uint64) bool {
+	return number >= f.Plato
+}
+
+func (f parliaForks) isBohr(time uint64) bool {
+	return time >= f.Bohr
+}
+
+func (f parliaForks) isLorentz(time uint64) bool {
+	return time >= f.Lorentz
+}
+
+func (f parliaForks) isMaxwell(time uint64) bool {
+	return time >= f.Maxwell
+}
+
+// blockInterval returns the minimum number of milliseconds between the block
+// at time and the next one
+func (f parliaForks) blockInterval(time uint64) uint64 {
+	switch {
+	case f.isMaxwell(time):
+		return maxwellBlockInterval
+	case f.isLorentz(time):
+		return lorentzBlockInterval
+	default:
+		return defaultBlockInterval
+	}
+}
+
+// epochLength returns the number of blocks of the epochs of blocks at time,
+// well after the upgrades that lengthen epochs
+func (f parliaForks) epochLength(time uint64) uint64 {
+	switch {
+	case f.isMaxwell(time):
+		return maxwellEpochLength
+	case f.isLorentz(time):
+		return lorentzEpochLength
+	default:
+		return defaultEpochLength
+	}
+}
+
+// VoteData ...
+// is what validators vote for with fast finality: to justify the target
+// block, whose source is the latest justified block
+type VoteData struct {
+	SourceNumber uint64
+	SourceHash   ethCommon.Hash
+	TargetNumber uint64
+	TargetHash   ethCommon.Hash
+}
+
+// Hash returns the hash signed by the votes
+func (d *VoteData) Hash() ethCommon.Hash {
+	b, err := rlp.EncodeToBytes(d)
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	return crypto.Keccak256Hash(b)
+}
+
+// VoteAttestation ...
+// is the aggregated votes of validators for the parent block, in the
+// extra-data of headers since Luban
+type VoteAttestation struct {
+	// VoteAddressSet is the bit set of the voters, bit i being set if the
+	// i-th of the validators ordered by address voted
+	VoteAddressSet uint64
+	AggSignature   BLSSignature
+	Data           *VoteData
+	Extra          []byte
+}
+
+// getValidatorsFromExtra ...
+// returns the validators in the extra-data of an epoch header, and their
+// BLS vote keys since Luban
+func (f parliaForks) getValidatorsFromExtra(number uint64, extra []byte) (
+	map[ethCommon.Address]bool, map[ethCommon.Address]BLSPublicKey, error) {
+	if !f.isLuban(number) {
+		validators, err := getValidatorMapFromHex(extra)
+		return validators, nil, err
+	}
+	if len(extra) < extraVanity+validatorNumberSize+extraSeal {
+		return nil, nil, errMissingSignature
+	}
+	num := int(extra[extraVanity])
+	start := extraVanity + validatorNumberSize
+	if num == 0 || len(extra) < start+num*validatorBytesLengthLuban+extraSeal {
+		return nil, nil, errInvalidSpanValidators
+	}
+	validators := make(map[ethCommon.Address]bool, num)
+	voteKeys := make(map[ethCommon.Address]BLSPublicKey, num)
+	for i := 0; i < num; i++ {
+		b := extra[start+i*validatorBytesLengthLuban : start+(i+1)*validatorBytesLengthLuban]
+		addr := ethCommon.BytesToAddress(b[:ethCommon.AddressLength])
+		validators[addr] = true
+		var voteKey BLSPublicKey
+		copy(voteKey[:], b[ethCommon.AddressLength:])
+		voteKeys[addr] = voteKey
+	}
+	return validators, voteKeys, nil
+}
+
+// getTurnLength ...
+// returns the number of consecutive blocks each validator seals, in the
+// extra-data of an epoch header since Bohr, 0 before
+func (f parliaForks) getTurnLength(header *types.Header) (uint64, error) {
+	if !f.isBohr(header.Time) {
+		return 0, nil
+	}
+	if len(header.Extra) <= extraVanity+extraSeal {
+		return 0, errMissingValidators
+	}
+	pos := extraVanity + validatorNumberSize + int(header.Extra[extraVanity])*validatorBytesLengthLuban
+	if pos >= len(header.Extra)-extraSeal || header.Extra[pos] == 0 {
+		return 0, errInvalidTurnLength
+	}
+	return uint64(header.Extra[pos]), nil
+}
+
+// getVoteAttestation returns the vote attestation of a header in an epoch
+// of epochLength blocks, if any
+func (f parliaForks) getVoteAttestation(header *types.Header, epochLength uint64) (*VoteAttestation, error) {
+	number := header.Number.Uint64()
+	if !f.isLuban(number) || len(header.Extra) <= extraVanity+extraSeal {
+		return nil, nil
+	}
+	start, end := extraVanity, len(header.Extra)-extraSeal
+	if number%epochLength == 0 {
+		num := int(header.Extra[extraVanity])
+		start += validatorNumberSize + num*validatorBytesLengthLuban
+		if f.isBohr(header.Time) {
+			start += turnLengthSize
+		}
+		if end <= start {
+			return nil, nil
+		}
+	}
+	var attestation VoteAttestation
+	if err := rlp.DecodeBytes(header.Extra[start:end], &attestation); err != nil {
+		return nil, errors.Wrapf(err, "invalid attestation")
+	}
+	if attestation.Data == nil {
+		return nil, errors.New("invalid attestation, vote data is nil")
+	}
+	if len(attestation.Extra) > maxAttestationExtraLength {
+		return nil, fmt.Errorf("invalid attestation, too large extra length: %d", len(attestation.Extra))
+	}
+	return &attestation, nil
+}
+
+// voters returns the vote keys of the voters of attestation among the
+// validators with voteKeys
+func voters(attestation *VoteAttestation, voteKeys map[ethCommon.Address]BLSPublicKey) ([]BLSPublicKey, error) {
+	validators := make([]ethCommon.Address, 0, len(voteKeys))
+	for addr := range voteKeys {
+		validators = append(validators, addr)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i][:], validators[j][:]) < 0
+	})
+	if bits.OnesCount64(attestation.VoteAddressSet) > len(validators) {
+		return nil, errors.New("invalid attestation, vote number larger than validators number")
+	}
+	keys := make([]BLSPublicKey, 0, len(validators))
+	for i, addr := range validators {
+		if i < 64 && attestation.VoteAddressSet&(1<<uint(i)) != 0 {
+			keys = append(keys, voteKeys[addr])
+		}
+	}
+	return keys, nil
+}
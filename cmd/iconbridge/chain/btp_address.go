@@ -52,6 +52,13 @@ func (a BTPAddress) ContractAddress() string {
 	return ""
 }
 
+// Equal ...
+// returns true if both addresses refer to the same BMC; contract
+// addresses may differ in letter case, e.g. with EIP-55 checksum
+func (a BTPAddress) Equal(b BTPAddress) bool {
+	return strings.EqualFold(string(a), string(b))
+}
+
 func (a BTPAddress) String() string {
 	return string(a)
 }
@@ -65,17 +72,20 @@ func (a BTPAddress) Type() string {
 	return "BtpAddress"
 }
 
+// ValidateBtpAddress ...
+// checks the format of a BTP address, btp://<network id>.<blockchain>/<bmc>;
+// whether the blockchain is supported is left to the relay
 func ValidateBtpAddress(ba BTPAddress) error {
 	switch p := ba.Protocol(); p {
 	case "btp":
 	default:
 		return fmt.Errorf("not supported protocol:%s", p)
 	}
-	switch v := ba.BlockChain(); v {
-	case "icon":
-	case "iconee":
-	default:
-		return fmt.Errorf("not supported blockchain:%s", v)
+	if len(ba.NetworkID()) < 1 {
+		return fmt.Errorf("empty network id")
+	}
+	if len(ba.BlockChain()) < 1 {
+		return fmt.Errorf("empty blockchain")
 	}
 	if len(ba.ContractAddress()) < 1 {
 		return fmt.Errorf("empty contract address")
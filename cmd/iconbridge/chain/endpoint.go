@@ -0,0 +1,445 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+const (
+	// EndpointMaxFailures is the number of consecutive failed calls after
+	// which an endpoint is taken out
+	EndpointMaxFailures = 3
+	// EndpointMaxLag is the number of blocks an endpoint may be behind the
+	// highest head height of the others before it is taken out
+	EndpointMaxLag = 20
+	// EndpointDownTime is how long an endpoint is first taken out for,
+	// doubled each time it is taken out again upto EndpointMaxDownTime
+	EndpointDownTime    = 30 * time.Second
+	EndpointMaxDownTime = 10 * time.Minute
+
+	// endpointDecay is the weight of the latest call in the moving
+	// averages of the latency and error rate of endpoints
+	endpointDecay = 0.1
+)
+
+// EndpointStatus is the health of an endpoint of a chain
+type EndpointStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	// Latency and ErrorRate are the moving averages of the latency of
+	// successful calls and of the ratio of failed ones
+	Latency   time.Duration `json:"latency"`
+	ErrorRate float64       `json:"error_rate"`
+	// Height is the latest head height reported by the endpoint
+	Height uint64 `json:"height,omitempty"`
+	Calls  uint64 `json:"calls"`
+	Errors uint64 `json:"errors"`
+	// DownUntil is when an endpoint that is taken out is back
+	DownUntil *time.Time `json:"down_until,omitempty"`
+}
+
+// EndpointReporter ...
+// is a Receiver or Sender that reports the health of its endpoints
+type EndpointReporter interface {
+	Endpoints() []EndpointStatus
+}
+
+// EndpointPool ...
+// tracks the health of the endpoints of a chain, shared by its clients:
+// the latency and error rate of their calls, and their head heights. An
+// endpoint is taken out for a while when its calls keep failing or it
+// falls behind the others, and Pick chooses among the other ones.
+type EndpointPool struct {
+	log       log.Logger
+	now       func() time.Time
+	mu        sync.Mutex
+	endpoints []*Endpoint
+}
+
+// Endpoint is an endpoint of an EndpointPool
+type Endpoint struct {
+	pool *EndpointPool
+	url  string
+
+	// guarded by pool.mu
+	latency   time.Duration
+	errorRate float64
+	height    uint64
+	calls     uint64
+	errors    uint64
+	failures  int
+	downTime  time.Duration
+	downUntil time.Time
+}
+
+// NewEndpointPool returns the EndpointPool of urls, all healthy at first,
+// logging through l, the global logger if nil
+func NewEndpointPool(urls []string, l log.Logger) *EndpointPool {
+	p := &EndpointPool{log: l, now: time.Now}
+	for _, url := range urls {
+		p.endpoints = append(p.endpoints, &Endpoint{pool: p, url: url})
+	}
+	return p
+}
+
+var sharedPools = struct {
+	sync.Mutex
+	pools map[string]*EndpointPool
+}{pools: map[string]*EndpointPool{}}
+
+// SharedEndpointPool ...
+// returns the EndpointPool of urls shared by all the clients of urls in
+// the process, such as the receiver and the senders of a chain, creating
+// it if need be. As it outlives the relay that creates it, it logs through
+// the global logger, with the url of the endpoint only.
+func SharedEndpointPool(urls []string) *EndpointPool {
+	key := strings.Join(urls, " ")
+	sharedPools.Lock()
+	defer sharedPools.Unlock()
+	p, ok := sharedPools.pools[key]
+	if !ok {
+		p = NewEndpointPool(urls, nil)
+		sharedPools.pools[key] = p
+	}
+	return p
+}
+
+// Endpoint returns the i-th endpoint, of the i-th url
+func (p *EndpointPool) Endpoint(i int) *Endpoint {
+	return p.endpoints[i]
+}
+
+// Pick ...
+// returns the index of the healthier of two healthy endpoints at random,
+// by latency and error rate, so that the calls are spread over them. It
+// returns the endpoint back the soonest if all of them are taken out.
+func (p *EndpointPool) Pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := p.pick(nil); i >= 0 {
+		return i
+	}
+	soonest := 0
+	for i, e := range p.endpoints {
+		if e.downUntil.Before(p.endpoints[soonest].downUntil) {
+			soonest = i
+		}
+	}
+	return soonest
+}
+
+// pickOther returns a healthy endpoint other than e, by Pick, nil if
+// there is none
+func (p *EndpointPool) pickOther(e *Endpoint) *Endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := p.pick(e); i >= 0 {
+		return p.endpoints[i]
+	}
+	return nil
+}
+
+// pick returns the index of the healthier of two healthy endpoints other
+// than except at random, -1 if there is none
+func (p *EndpointPool) pick(except *Endpoint) int {
+	now := p.now()
+	healthy := make([]int, 0, len(p.endpoints))
+	for i, e := range p.endpoints {
+		if e != except && !now.Before(e.downUntil) {
+			healthy = append(healthy, i)
+		}
+	}
+	if len(healthy) == 0 {
+		return -1
+	}
+	i := healthy[rand.Intn(len(healthy))]
+	if j := healthy[rand.Intn(len(healthy))]; p.endpoints[j].score() < p.endpoints[i].score() {
+		i = j
+	}
+	return i
+}
+
+// logger returns the logger of the pool, the global logger if unset
+func (p *EndpointPool) logger() log.Logger {
+	if p.log == nil {
+		return log.GlobalLogger()
+	}
+	return p.log
+}
+
+// PickEndpoint ...
+// returns the index of one of the healthiest of endpoints, which share a
+// pool, by Pick of their pool; or of one at random if they are untracked,
+// nil
+func PickEndpoint(endpoints []*Endpoint) int {
+	if endpoints[0] == nil {
+		return rand.Intn(len(endpoints))
+	}
+	return endpoints[0].pool.Pick()
+}
+
+// Status returns the health of the endpoints
+func (p *EndpointPool) Status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		st := EndpointStatus{
+			URL:       e.url,
+			Healthy:   !now.Before(e.downUntil),
+			Latency:   e.latency,
+			ErrorRate: e.errorRate,
+			Height:    e.height,
+			Calls:     e.calls,
+			Errors:    e.errors,
+		}
+		if !st.Healthy {
+			downUntil := e.downUntil
+			st.DownUntil = &downUntil
+		}
+		status = append(status, st)
+	}
+	return status
+}
+
+// URL returns the url of the endpoint
+func (e *Endpoint) URL() string {
+	return e.url
+}
+
+// Pool returns the EndpointPool of the endpoint
+func (e *Endpoint) Pool() *EndpointPool {
+	return e.pool
+}
+
+// Record records a call to the endpoint that took latency, which failed if
+// err is not nil
+func (e *Endpoint) Record(latency time.Duration, err error) {
+	p := e.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.calls++
+	if err == nil {
+		e.errorRate *= 1 - endpointDecay
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency += time.Duration(endpointDecay * float64(latency-e.latency))
+		}
+		if e.downTime != 0 {
+			p.logger().WithFields(log.Fields{"url": e.url}).Info("endpoint healthy again")
+		}
+		e.failures, e.downTime = 0, 0
+		return
+	}
+	e.errors++
+	e.errorRate += endpointDecay * (1 - e.errorRate)
+	if e.failures++; e.failures >= EndpointMaxFailures && !p.now().Before(e.downUntil) {
+		e.takeOut(fmt.Sprintf("%d consecutive failures: %v", e.failures, err))
+	}
+}
+
+// SetHeight records the head height reported by the endpoint, taking it
+// out if it is more than EndpointMaxLag blocks behind the others
+func (e *Endpoint) SetHeight(height uint64) {
+	p := e.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if height < e.height {
+		return
+	}
+	e.height = height
+	var highest uint64
+	for _, other := range p.endpoints {
+		if other.height > highest {
+			highest = other.height
+		}
+	}
+	if height+EndpointMaxLag < highest && !p.now().Before(e.downUntil) {
+		e.takeOut(fmt.Sprintf("%d blocks behind", highest-height))
+	}
+}
+
+// takeOut takes the endpoint out for twice as long as the last time, if
+// it hasn't been healthy since
+func (e *Endpoint) takeOut(reason string) {
+	if e.downTime *= 2; e.downTime == 0 {
+		e.downTime = EndpointDownTime
+	} else if e.downTime > EndpointMaxDownTime {
+		e.downTime = EndpointMaxDownTime
+	}
+	e.downUntil = e.pool.now().Add(e.downTime)
+	e.pool.logger().WithFields(log.Fields{
+		"url": e.url, "reason": reason, "for": e.downTime}).Warn("endpoint taken out")
+}
+
+// score is the expected cost of a call to the endpoint, the lower the
+// better
+func (e *Endpoint) score() float64 {
+	return float64(e.latency) * (1 + 10*e.errorRate)
+}
+
+// Transport ...
+// returns an http.RoundTripper that records the calls to the endpoint
+// through base, http.DefaultTransport if nil: requests that fail, or are
+// answered with a server error, throttled or with a json-rpc error of the
+// endpoint (see EndpointRPCErrors), are failed calls. A failed call, other
+// than one sending a transaction, is tried again once on another healthy
+// endpoint of the pool.
+func (e *Endpoint) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &endpointTransport{endpoint: e, base: base}
+}
+
+// EndpointRPCErrors ...
+// are (parts of) the messages of the json-rpc errors for which the endpoint
+// rather than the call is at fault, answered with http status 200: it is
+// behind or has pruned the state asked for, or it is overloaded
+var EndpointRPCErrors = []string{
+	"header not found",
+	"missing trie node",
+	"unknown block",
+	"limit exceeded",
+	"rate limit",
+	"too many requests",
+	"request timed out",
+	"server is busy",
+}
+
+// endpointSendMethods are the json-rpc methods that are not tried again on
+// another endpoint as they may have gone through on the first one
+var endpointSendMethods = []string{
+	"eth_sendRawTransaction",
+	"eth_sendTransaction",
+	"icx_sendTransaction",
+	"icx_sendTransactionAndWait",
+}
+
+type endpointTransport struct {
+	endpoint *Endpoint
+	base     http.RoundTripper
+}
+
+func (t *endpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp, failed, err := t.roundTrip(t.endpoint, req, req.URL, body)
+	if !failed || req.Context().Err() != nil || isSendRequest(body) {
+		return resp, err
+	}
+	other := t.endpoint.pool.pickOther(t.endpoint)
+	if other == nil || !strings.HasPrefix(req.URL.String(), t.endpoint.url) {
+		return resp, err
+	}
+	u, uerr := url.Parse(other.url + strings.TrimPrefix(req.URL.String(), t.endpoint.url))
+	if uerr != nil {
+		return resp, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	resp, _, err = t.roundTrip(other, req, u, body)
+	return resp, err
+}
+
+// roundTrip sends a copy of req with body to u, recording the call to e,
+// and returns whether it failed
+func (t *endpointTransport) roundTrip(e *Endpoint, req *http.Request, u *url.URL, body []byte) (resp *http.Response, failed bool, err error) {
+	req = req.Clone(req.Context())
+	req.URL, req.Host = u, ""
+	if body != nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	start := time.Now()
+	resp, err = t.base.RoundTrip(req)
+	var ferr error
+	switch {
+	case err != nil:
+		if req.Context().Err() != nil {
+			return resp, false, err
+		}
+		ferr = err
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		ferr = fmt.Errorf("http status: %s", resp.Status)
+	case resp.StatusCode == http.StatusOK:
+		var rb []byte
+		rb, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			ferr = err
+			resp = nil
+			break
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(rb))
+		ferr = endpointRPCError(rb)
+	}
+	e.Record(time.Since(start), ferr)
+	return resp, ferr != nil, err
+}
+
+// endpointRPCError returns the first json-rpc error of the response(s) in
+// body that is one of EndpointRPCErrors
+func endpointRPCError(body []byte) error {
+	if !bytes.Contains(body, []byte(`"error"`)) {
+		return nil
+	}
+	var resps []rpcResponse
+	if err := json.Unmarshal(body, &resps); err != nil {
+		var resp rpcResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil
+		}
+		resps = []rpcResponse{resp}
+	}
+	for _, resp := range resps {
+		if resp.Error == nil {
+			continue
+		}
+		msg := strings.ToLower(resp.Error.Message)
+		for _, s := range EndpointRPCErrors {
+			if strings.Contains(msg, s) {
+				return fmt.Errorf("json-rpc error: code=%d, message=%s", resp.Error.Code, resp.Error.Message)
+			}
+		}
+	}
+	return nil
+}
+
+type rpcResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// isSendRequest returns whether the json-rpc request(s) in body send a
+// transaction, see endpointSendMethods
+func isSendRequest(body []byte) bool {
+	for _, m := range endpointSendMethods {
+		if bytes.Contains(body, []byte(`"`+m+`"`)) {
+			return true
+		}
+	}
+	return false
+}
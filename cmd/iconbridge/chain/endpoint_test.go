@@ -0,0 +1,135 @@
+package chain
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointPool(t *testing.T) {
+	p := NewEndpointPool([]string{"a", "b", "c"}, nil)
+	now := time.Unix(1000000, 0)
+	p.now = func() time.Time { return now }
+	a, b, c := p.Endpoint(0), p.Endpoint(1), p.Endpoint(2)
+
+	// a keeps failing
+	for i := 0; i < EndpointMaxFailures; i++ {
+		assert.True(t, p.Status()[0].Healthy)
+		a.Record(0, errors.New("connection refused"))
+	}
+	assert.False(t, p.Status()[0].Healthy)
+	// c falls behind
+	b.SetHeight(100)
+	c.SetHeight(100 - EndpointMaxLag)
+	assert.True(t, p.Status()[2].Healthy)
+	c.SetHeight(100 - EndpointMaxLag)
+	b.SetHeight(101)
+	c.SetHeight(100 - EndpointMaxLag)
+	assert.False(t, p.Status()[2].Healthy)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 1, p.Pick())
+		assert.Equal(t, 1, PickEndpoint([]*Endpoint{a, b, c}))
+	}
+	// untracked endpoints are picked at random
+	assert.Less(t, PickEndpoint(make([]*Endpoint, 3)), 3)
+
+	// all of them taken out, the one back the soonest is picked
+	now = now.Add(time.Second)
+	for i := 0; i < EndpointMaxFailures; i++ {
+		b.Record(0, errors.New("timeout"))
+	}
+	assert.Equal(t, 0, p.Pick())
+
+	// a is back, until it fails again, for longer
+	now = now.Add(EndpointDownTime)
+	assert.True(t, p.Status()[0].Healthy)
+	a.Record(0, errors.New("connection refused"))
+	st := p.Status()[0]
+	require.False(t, st.Healthy)
+	assert.Equal(t, now.Add(2*EndpointDownTime), *st.DownUntil)
+	assert.EqualValues(t, EndpointMaxFailures+1, st.Errors)
+
+	// and healthy again on success
+	now = now.Add(2 * EndpointDownTime)
+	a.Record(10*time.Millisecond, nil)
+	st = p.Status()[0]
+	assert.True(t, st.Healthy)
+	assert.Equal(t, 10*time.Millisecond, st.Latency)
+	assert.Less(t, st.ErrorRate, 0.4)
+}
+
+func TestEndpoint_Transport(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	p := NewEndpointPool([]string{server.URL}, nil)
+	hc := &http.Client{Transport: p.Endpoint(0).Transport(nil)}
+	get := func() {
+		resp, err := hc.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	get()
+	status = http.StatusBadRequest
+	get()
+	status = http.StatusTooManyRequests
+	get()
+	st := p.Status()[0]
+	assert.EqualValues(t, 3, st.Calls)
+	assert.EqualValues(t, 1, st.Errors, "client errors are successful calls")
+	assert.NotZero(t, st.Latency)
+}
+
+func TestSharedEndpointPool(t *testing.T) {
+	p := SharedEndpointPool([]string{"a", "b"})
+	assert.Same(t, p, SharedEndpointPool([]string{"a", "b"}))
+	assert.NotSame(t, p, SharedEndpointPool([]string{"a"}))
+}
+
+func TestEndpoint_TransportFailover(t *testing.T) {
+	behind := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`))
+	}))
+	defer behind.Close()
+	var calls []string
+	synced := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		calls = append(calls, r.URL.Path+" "+string(body))
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer synced.Close()
+
+	p := NewEndpointPool([]string{behind.URL + "/rpc", synced.URL + "/rpc"}, nil)
+	hc := &http.Client{Transport: p.Endpoint(0).Transport(nil)}
+	post := func(body string) string {
+		resp, err := hc.Post(behind.URL+"/rpc", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		rb, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(rb)
+	}
+
+	// the call is tried again on the other endpoint
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x1",false]}`
+	assert.Contains(t, post(req), `"result":"0x1"`)
+	assert.Equal(t, []string{"/rpc " + req}, calls)
+	st := p.Status()
+	assert.EqualValues(t, 1, st[0].Errors, "json-rpc errors of the endpoint are failed calls")
+	assert.EqualValues(t, 0, st[1].Errors)
+
+	// but transactions are not sent again
+	assert.Contains(t, post(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["0x00"]}`), "header not found")
+	assert.Len(t, calls, 1)
+	assert.EqualValues(t, 2, p.Status()[0].Errors)
+}
@@ -10,6 +10,7 @@ var (
 	ErrInsufficientBalance   = errors.New("InsufficientBalance")
 	ErrGasLimitExceeded      = errors.New("GasLimitExceeded")
 	ErrBlockGasLimitExceeded = errors.New("BlockGasLimitExceeded")
+	ErrInvalidSnapshot       = errors.New("InvalidSnapshot")
 
 	// BMC errors
 	ErrBMCRevertLastOwner                 = errors.New("LastOwner")
@@ -34,32 +35,55 @@ var (
 	ErrBMCRevertUnreachable               = errors.New("Unreachable:")
 )
 
+var bmcRevertErrors = []error{
+	ErrBMCRevertLastOwner,
+	ErrBMCRevertUnauthroized,
+	ErrBMCRevertInvalidAddress,
+	ErrBMCRevertNotExistsPermission,
+	ErrBMCRevertAlreadyExistsBSH,
+	ErrBMCRevertNotExistsBSH,
+	ErrBMCRevertAlreadyExistsLink,
+	ErrBMCRevertNotExistsLink,
+	ErrBMCRevertInvalidParam,
+	ErrBMCRevertAlreadyExistRoute,
+	ErrBMCRevertNotExistRoute,
+	ErrBMCRevertInvalidSn,
+	ErrBMCRevertParseFailure,
+	ErrBMCRevertInvalidRxHeight,
+	ErrBMCRevertInvalidSeqNumber,
+	ErrBMCRevertNotExistsInternalHandler,
+	ErrBMCRevertAlreadyExistsBMCPeriphery,
+	ErrBMCRevertUnknownHandleBTPError,
+	ErrBMCRevertUnknownHandleBTPMessage,
+	ErrBMCRevertUnreachable,
+}
+
 func RevertError(msg string) error {
-	for _, err := range []error{
-		ErrBMCRevertLastOwner,
-		ErrBMCRevertUnauthroized,
-		ErrBMCRevertInvalidAddress,
-		ErrBMCRevertNotExistsPermission,
-		ErrBMCRevertAlreadyExistsBSH,
-		ErrBMCRevertNotExistsBSH,
-		ErrBMCRevertAlreadyExistsLink,
-		ErrBMCRevertNotExistsLink,
-		ErrBMCRevertInvalidParam,
-		ErrBMCRevertAlreadyExistRoute,
-		ErrBMCRevertNotExistRoute,
-		ErrBMCRevertInvalidSn,
-		ErrBMCRevertParseFailure,
-		ErrBMCRevertInvalidRxHeight,
-		ErrBMCRevertInvalidSeqNumber,
-		ErrBMCRevertNotExistsInternalHandler,
-		ErrBMCRevertAlreadyExistsBMCPeriphery,
-		ErrBMCRevertUnknownHandleBTPError,
-		ErrBMCRevertUnknownHandleBTPMessage,
-		ErrBMCRevertUnreachable,
-	} {
+	for _, err := range bmcRevertErrors {
 		if strings.HasPrefix(msg, err.Error()) {
 			return err
 		}
 	}
 	return nil
 }
+
+// ErrorName ...
+// returns the name of the common or BMC error that `err` wraps, if any,
+// otherwise returns an empty string
+func ErrorName(err error) string {
+	for _, e := range []error{
+		ErrInsufficientBalance,
+		ErrGasLimitExceeded,
+		ErrBlockGasLimitExceeded,
+	} {
+		if errors.Is(err, e) {
+			return e.Error()
+		}
+	}
+	for _, e := range bmcRevertErrors {
+		if errors.Is(err, e) {
+			return "BMCRevert" + strings.TrimSuffix(e.Error(), ":")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+const (
+	BlockHeightPollInterval    = 5 * time.Second
+	DefaultSyncConcurrency     = 10
+	MonitorBlockMaxConcurrency = 100 // number of concurrent requests to synchronize older blocks from source chain
+
+	// block tags of the final block of the node
+	BlockTagLatest    = "latest"
+	BlockTagSafe      = "safe"
+	BlockTagFinalized = "finalized"
+)
+
+func NewReceiver(
+	src, dst chain.BTPAddress, urls []string,
+	rawOpts json.RawMessage, l log.Logger) (chain.Receiver, error) {
+	r := &receiver{
+		log: l,
+		src: src,
+		dst: dst,
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("empty urls: %v", urls)
+	}
+	err := json.Unmarshal(rawOpts, &r.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.opts.validate(); err != nil {
+		return nil, err
+	}
+	if r.opts.SyncConcurrency < 1 {
+		r.opts.SyncConcurrency = DefaultSyncConcurrency
+	} else if r.opts.SyncConcurrency > MonitorBlockMaxConcurrency {
+		r.opts.SyncConcurrency = MonitorBlockMaxConcurrency
+	}
+	if r.opts.BlockTag == "" {
+		r.opts.BlockTag = BlockTagLatest
+	}
+
+	r.cls, _, err = evm.NewClients(urls, src.ContractAddress(), r.log)
+	if err != nil {
+		return nil, err
+	}
+	if r.parser, err = evm.NewMessageParser(src.ContractAddress()); err != nil {
+		return nil, err
+	}
+	r.Receiver, err = evm.NewReceiver(src, dst, r, r.log)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReceiverOptions ...
+// are the options of the receiver; blocks are final once Confirmations
+// blocks are on top of the block of BlockTag, "latest" by default
+type ReceiverOptions struct {
+	SyncConcurrency uint64 `json:"syncConcurrency"`
+	Confirmations   uint64 `json:"confirmations"`
+	BlockTag        string `json:"blockTag"`
+}
+
+// ValidateReceiverOptions checks the options of NewReceiver, rejecting
+// unknown keys
+func ValidateReceiverOptions(rawOpts json.RawMessage) error {
+	opts := &ReceiverOptions{}
+	if err := chain.UnmarshalOptionsStrict(rawOpts, opts); err != nil {
+		return err
+	}
+	return opts.validate()
+}
+
+func (opts *ReceiverOptions) validate() error {
+	switch opts.BlockTag {
+	case "", BlockTagLatest, BlockTagSafe, BlockTagFinalized:
+		return nil
+	default:
+		return fmt.Errorf("invalid blockTag: %q", opts.BlockTag)
+	}
+}
+
+type receiver struct {
+	*evm.Receiver
+
+	// latestHeight and verifiedHeight are accessed atomically
+	latestHeight   uint64
+	verifiedHeight uint64
+
+	log    log.Logger
+	src    chain.BTPAddress
+	dst    chain.BTPAddress
+	opts   ReceiverOptions
+	cls    []*evm.Client
+	parser *evm.MessageParser
+}
+
+func (r *receiver) ReceiverStatus() *chain.ReceiverStatus {
+	return &chain.ReceiverStatus{
+		Height:         atomic.LoadUint64(&r.latestHeight),
+		VerifiedHeight: atomic.LoadUint64(&r.verifiedHeight),
+	}
+}
+
+func (r *receiver) client() *evm.Client {
+	return evm.PickClient(r.cls)
+}
+
+// Endpoints implements chain.EndpointReporter
+func (r *receiver) Endpoints() []chain.EndpointStatus {
+	return evm.Endpoints(r.cls)
+}
+
+// finalHeight returns the height of the latest final block
+func (r *receiver) finalHeight(ctx context.Context) (uint64, error) {
+	header, err := r.client().GetHeaderByTag(ctx, r.opts.BlockTag)
+	if err != nil {
+		return 0, err
+	}
+	height := header.Number.Uint64()
+	if height < r.opts.Confirmations {
+		return 0, nil
+	}
+	return height - r.opts.Confirmations, nil
+}
+
+// ReceiveBlocks implements evm.BlockSource with the final blocks, their
+// receipts verified against their headers
+func (r *receiver) ReceiveBlocks(ctx context.Context, height uint64, cb func(*evm.VerifiedBlock) error) error {
+	vr := newVerifier(height)
+
+	heightPoller := time.NewTicker(BlockHeightPollInterval)
+	defer heightPoller.Stop()
+
+	for {
+		latest, err := r.finalHeight(ctx)
+		if err != nil {
+			r.log.WithFields(log.Fields{"error": err}).Error("receiveLoop: failed to get final height")
+		} else {
+			atomic.StoreUint64(&r.latestHeight, latest)
+			for vr.next <= latest && ctx.Err() == nil {
+				next := vr.next
+				if err := r.receiveBlocks(vr, latest, cb); err != nil {
+					return err
+				}
+				if vr.next == next {
+					break // retry on the next poll
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heightPoller.C:
+		}
+	}
+}
+
+// receiveBlocks ...
+// verifies and forwards the blocks from the next height of vr upto latest,
+// at most SyncConcurrency of them; it stops at blocks it fails to fetch or
+// verify, which are retried later, and only fails if cb does
+func (r *receiver) receiveBlocks(vr *verifier, latest uint64, cb func(*evm.VerifiedBlock) error) error {
+	count := latest - vr.next + 1
+	if count > r.opts.SyncConcurrency {
+		count = r.opts.SyncConcurrency
+	}
+	headers := make([]*types.Header, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := range headers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			height := new(big.Int).SetUint64(vr.next + uint64(i))
+			headers[i], errs[i] = r.client().GetHeaderByHeight(height)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, header := range headers {
+		height := vr.next
+		if errs[i] != nil {
+			r.log.WithFields(log.Fields{"height": height, "error": errs[i]}).Warn("receiveLoop: failed to get header")
+			return nil
+		}
+		var receipts types.Receipts
+		if r.parser.MayHaveMessages(header.Bloom) {
+			var err error
+			receipts, err = r.client().GetBlockReceipts(header.Hash())
+			if err != nil {
+				r.log.WithFields(log.Fields{"height": height, "error": err}).Warn("receiveLoop: failed to get receipts")
+				return nil
+			}
+			if receipts == nil {
+				receipts = types.Receipts{} // verified against the bloom all the same
+			}
+		}
+		var next *types.Header
+		if i+1 < len(headers) {
+			next = headers[i+1]
+		}
+		if err := vr.Verify(header, next, receipts); err != nil {
+			if vr.parentHash != (common.Hash{}) && header.ParentHash != vr.parentHash {
+				return r.rollback(vr, cb)
+			}
+			r.log.WithFields(log.Fields{"height": height, "hash": header.Hash(), "error": err}).Error(
+				"verification failed. refetching block")
+			return nil
+		}
+		if err := vr.Update(header); err != nil {
+			return err
+		}
+		if err := cb(&evm.VerifiedBlock{Height: height, Logs: evm.ReceiptLogs(receipts)}); err != nil {
+			return err
+		}
+		atomic.StoreUint64(&r.verifiedHeight, height)
+	}
+	return nil
+}
+
+// rollback ...
+// rolls vr back after a reorg, and withdraws the blocks it orphaned with
+// cb; it only fails if the reorg is too deep or cb fails
+func (r *receiver) rollback(vr *verifier, cb func(*evm.VerifiedBlock) error) error {
+	next := vr.next
+	height, err := evm.Rollback(vr, next, r.client().GetHeaderByHeight)
+	if err == evm.ErrReorgTooDeep {
+		return err
+	} else if err != nil {
+		r.log.WithFields(log.Fields{"height": next, "error": err}).Warn("receiveLoop: failed to roll back")
+		return nil
+	}
+	if height == next {
+		return nil // refetch the next block
+	}
+	r.log.WithFields(log.Fields{"height": height, "orphaned": next - height}).Warn("receiveLoop: reorg, rolled back")
+	if err := cb(&evm.VerifiedBlock{Height: height, Orphaned: true}); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&r.verifiedHeight, height-1)
+	return nil
+}
@@ -0,0 +1,235 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSrc = "btp://0x1.eth/0xB4fC4b3b4e3157448B7D279f06BC8e340d63e2a9"
+	testDst = "btp://0x7.icon/cx8a6606d526b96a16e6764aee5d9abecf926689df"
+)
+
+// fakeChain serves the headers and the receipts of the blocks of a test
+// chain, and the heights of its block tags
+type fakeChain struct {
+	headers  map[uint64]*types.Header
+	receipts map[common.Hash]*types.Receipt
+	tags     map[string]uint64
+}
+
+func (f *fakeChain) GetBlockByNumber(ctx context.Context, number string, full bool) (*types.Header, error) {
+	height, ok := f.tags[number]
+	if !ok {
+		var err error
+		if height, err = hexutil.DecodeUint64(number); err != nil {
+			return nil, err
+		}
+	}
+	return f.headers[height], nil
+}
+
+func (f *fakeChain) GetBlockByHash(ctx context.Context, hash common.Hash, full bool) (map[string]interface{}, error) {
+	for txh, receipt := range f.receipts {
+		if receipt.BlockHash == hash {
+			return map[string]interface{}{"transactions": []string{txh.Hex()}, "gasUsed": "0x1"}, nil
+		}
+	}
+	return nil, fmt.Errorf("no block with messages: %v", hash)
+}
+
+func (f *fakeChain) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return f.receipts[hash], nil
+}
+
+// build adds the blocks from height upto last on top of the block before
+// height, if any, replacing those of the chain; the blocks at heights have
+// a message of the bmc, and extra tells apart the blocks of forks
+func (f *fakeChain) build(t *testing.T, height, last uint64, extra string, heights ...uint64) {
+	parsed, err := abi.JSON(strings.NewReader(evm.BMCABI))
+	require.NoError(t, err)
+	parent := f.headers[height-1]
+	for number := height; number <= last; number++ {
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(1),
+			GasLimit:   30000000,
+			Time:       1000000 + number,
+			Extra:      []byte(extra),
+		}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+		}
+		for _, h := range heights {
+			if h != number {
+				continue
+			}
+			txh := common.BytesToHash([]byte(fmt.Sprintf("%s%d", extra, number)))
+			receipt := &types.Receipt{
+				Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 1, GasUsed: 1, TxHash: txh,
+				Logs: []*types.Log{{
+					Address:     common.HexToAddress(chain.BTPAddress(testSrc).ContractAddress()),
+					Topics:      []common.Hash{parsed.Events["Message"].ID},
+					Data:        []byte{1},
+					BlockNumber: number,
+					TxHash:      txh,
+				}},
+			}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			header.Bloom = receipt.Bloom
+			header.ReceiptHash = types.DeriveSha(types.Receipts{receipt}, trie.NewStackTrie(nil))
+			header.GasUsed = 1
+			receipt.BlockHash = header.Hash()
+			receipt.Logs[0].BlockHash = receipt.BlockHash
+			f.receipts[txh] = receipt
+		}
+		f.headers[number] = header
+		parent = header
+	}
+}
+
+func newFakeChain(t *testing.T, last uint64, heights ...uint64) *fakeChain {
+	f := &fakeChain{
+		headers:  map[uint64]*types.Header{},
+		receipts: map[common.Hash]*types.Receipt{},
+		tags:     map[string]uint64{BlockTagLatest: last, BlockTagSafe: last, BlockTagFinalized: last},
+	}
+	f.build(t, 0, last, "", heights...)
+	return f
+}
+
+func newTestReceiver(t *testing.T, f *fakeChain, opts ReceiverOptions) *receiver {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", f))
+	clrpc := rpc.DialInProc(server)
+	t.Cleanup(clrpc.Close)
+	parser, err := evm.NewMessageParser(chain.BTPAddress(testSrc).ContractAddress())
+	require.NoError(t, err)
+	return &receiver{
+		log:    log.New(),
+		src:    chain.BTPAddress(testSrc),
+		dst:    chain.BTPAddress(testDst),
+		opts:   opts,
+		cls:    []*evm.Client{{RPC: clrpc, Eth: ethclient.NewClient(clrpc)}},
+		parser: parser,
+	}
+}
+
+// receiveUpto runs ReceiveBlocks from height until it forwards the block at
+// last, and returns the blocks it forwarded
+func receiveUpto(t *testing.T, r *receiver, height, last uint64) []*evm.VerifiedBlock {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var vbs []*evm.VerifiedBlock
+	err := r.ReceiveBlocks(ctx, height, func(vb *evm.VerifiedBlock) error {
+		vbs = append(vbs, vb)
+		if vb.Height == last {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return vbs
+}
+
+func TestReceiver_ReceiveBlocks(t *testing.T) {
+	f := newFakeChain(t, 20, 4, 8, 14)
+	f.tags[BlockTagFinalized] = 15
+	r := newTestReceiver(t, f, ReceiverOptions{
+		SyncConcurrency: 3, Confirmations: 3, BlockTag: BlockTagFinalized})
+
+	// upto 3 blocks below the finalized block, 12
+	vbs := receiveUpto(t, r, 1, 12)
+	require.Len(t, vbs, 12)
+	for i, vb := range vbs {
+		height := uint64(i + 1)
+		assert.EqualValues(t, height, vb.Height)
+		assert.False(t, vb.Orphaned)
+		if height == 4 || height == 8 {
+			assert.Len(t, vb.Logs, 1, "block with messages %d", height)
+		} else {
+			assert.Empty(t, vb.Logs, "block without messages %d", height)
+		}
+	}
+	assert.Equal(t, &chain.ReceiverStatus{Height: 12, VerifiedHeight: 12}, r.ReceiverStatus())
+
+	// none is final yet
+	r.opts.BlockTag, r.opts.Confirmations = BlockTagLatest, 21
+	height, err := r.finalHeight(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, height)
+}
+
+func TestReceiver_ReceiveBlocksResume(t *testing.T) {
+	f := newFakeChain(t, 20, 4, 11)
+	r := newTestReceiver(t, f, ReceiverOptions{
+		SyncConcurrency: 10, Confirmations: 2, BlockTag: BlockTagLatest})
+
+	// from the stored height, whose parent is not known
+	vbs := receiveUpto(t, r, 10, 18)
+	require.Len(t, vbs, 9)
+	assert.EqualValues(t, 10, vbs[0].Height)
+	assert.EqualValues(t, 18, vbs[8].Height)
+	assert.Empty(t, vbs[0].Logs)
+	assert.Len(t, vbs[1].Logs, 1)
+	assert.Equal(t, &chain.ReceiverStatus{Height: 18, VerifiedHeight: 18}, r.ReceiverStatus())
+}
+
+func TestReceiver_ReceiveBlocksRollback(t *testing.T) {
+	f := newFakeChain(t, 10, 9)
+	r := newTestReceiver(t, f, ReceiverOptions{SyncConcurrency: 10, BlockTag: BlockTagLatest})
+	vr := newVerifier(1)
+	var vbs []*evm.VerifiedBlock
+	cb := func(vb *evm.VerifiedBlock) error {
+		vbs = append(vbs, vb)
+		return nil
+	}
+	require.NoError(t, r.receiveBlocks(vr, 10, cb))
+	require.Len(t, vbs, 10)
+	assert.EqualValues(t, 11, vr.next)
+
+	// the blocks from 8 are orphaned by a fork, whose message is at 11
+	f.build(t, 8, 12, "fork", 11)
+	vbs = nil
+	require.NoError(t, r.receiveBlocks(vr, 12, cb))
+	require.Len(t, vbs, 1)
+	assert.True(t, vbs[0].Orphaned)
+	assert.EqualValues(t, 8, vbs[0].Height, "blocks from 8 withdrawn")
+	assert.EqualValues(t, 8, vr.next)
+	assert.EqualValues(t, 7, r.ReceiverStatus().VerifiedHeight)
+
+	vbs = nil
+	require.NoError(t, r.receiveBlocks(vr, 12, cb))
+	require.Len(t, vbs, 5)
+	for i, vb := range vbs {
+		height := uint64(i + 8)
+		assert.EqualValues(t, height, vb.Height)
+		assert.False(t, vb.Orphaned)
+		if height == 11 {
+			assert.Len(t, vb.Logs, 1, "block with messages of the fork")
+		} else {
+			assert.Empty(t, vb.Logs, "block without messages %d", height)
+		}
+	}
+	assert.Equal(t, f.headers[12].Hash(), vr.parentHash)
+
+	// a reorg deeper than the verified blocks fails
+	f.build(t, 1, 13, "deep")
+	assert.Equal(t, evm.ErrReorgTooDeep, r.receiveBlocks(vr, 13, cb))
+}
@@ -0,0 +1,10 @@
+package eth
+
+import "github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+
+func init() {
+	relay.Senders["eth"] = NewSender
+	relay.Receivers["eth"] = NewReceiver
+	relay.SenderOptionValidators["eth"] = ValidateSenderOptions
+	relay.ReceiverOptionValidators["eth"] = ValidateReceiverOptions
+}
@@ -0,0 +1,36 @@
+package eth
+
+import (
+	"encoding/json"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+)
+
+const (
+	txMaxDataSize   = 64 * 1024 // 64 KB
+	defaultGasPrice = 1000000000
+	DefaultGasLimit = 10000000
+)
+
+// senderConfig prices EIP-1559 txs from the fee history by default
+var senderConfig = &evm.SenderConfig{
+	TxMaxDataSize: txMaxDataSize,
+	GasLimit:      DefaultGasLimit,
+	GasPrice:      defaultGasPrice,
+	FeeMode:       evm.FeeModeDynamic,
+}
+
+func NewSender(
+	src, dst chain.BTPAddress,
+	urls []string, w wallet.Wallet,
+	rawOpts json.RawMessage, l log.Logger) (chain.Sender, error) {
+	return evm.NewSender(senderConfig, src, dst, urls, w, rawOpts, l)
+}
+
+// ValidateSenderOptions checks the options of NewSender, rejecting unknown keys
+func ValidateSenderOptions(rawOpts json.RawMessage) error {
+	return senderConfig.ValidateOptions(rawOpts)
+}
@@ -0,0 +1,85 @@
+package eth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+)
+
+var _ evm.RollbackVerifier = (*verifier)(nil)
+
+// verifier ...
+// chains headers by their parent hashes from the first one it sees, and
+// checks the receipts of their blocks against their receipt roots. It
+// trusts the endpoints for the headers themselves, which are final by the
+// finality options of the receiver.
+type verifier struct {
+	// next is the height of the next header, and parentHash its parent
+	// hash, if known
+	next       uint64
+	parentHash common.Hash
+	// hashes are those of the latest evm.ReorgDepth verified blocks, the
+	// last one being parentHash
+	hashes []common.Hash
+}
+
+func newVerifier(height uint64) *verifier {
+	return &verifier{next: height}
+}
+
+// Verify checks that header follows the last verified header, and next
+// header if any, and that receipts are of its block unless they are nil
+func (vr *verifier) Verify(header, next *types.Header, receipts types.Receipts) error {
+	if header.Number.Uint64() != vr.next {
+		return fmt.Errorf("unexpected height: got %v expected %v", header.Number, vr.next)
+	}
+	if vr.parentHash != (common.Hash{}) && header.ParentHash != vr.parentHash {
+		return fmt.Errorf("unexpected parent hash(%v): got %v expected %v",
+			header.Number, header.ParentHash.Hex(), vr.parentHash.Hex())
+	}
+	if next != nil && next.ParentHash != header.Hash() {
+		return fmt.Errorf("unexpected hash(%v): got %v expected %v",
+			header.Number, header.Hash().Hex(), next.ParentHash.Hex())
+	}
+	if receipts != nil {
+		return evm.ValidateReceipts(header, receipts)
+	}
+	return nil
+}
+
+// Update advances the verifier past header
+func (vr *verifier) Update(header *types.Header) error {
+	vr.next = header.Number.Uint64() + 1
+	vr.parentHash = header.Hash()
+	if len(vr.hashes) == evm.ReorgDepth {
+		vr.hashes = vr.hashes[1:]
+	}
+	vr.hashes = append(vr.hashes, vr.parentHash)
+	return nil
+}
+
+// Hash returns the hash of the verified block at height, if it is one of
+// the latest ones
+func (vr *verifier) Hash(height uint64) (common.Hash, bool) {
+	if height >= vr.next || vr.next-height > uint64(len(vr.hashes)) {
+		return common.Hash{}, false
+	}
+	return vr.hashes[uint64(len(vr.hashes))-(vr.next-height)], true
+}
+
+// Rollback rolls the verifier back to right before the block at height,
+// right after one of the latest verified blocks
+func (vr *verifier) Rollback(height uint64) error {
+	if height == 0 || height > vr.next {
+		return fmt.Errorf("invalid rollback height: %d, next: %d", height, vr.next)
+	}
+	parentHash, ok := vr.Hash(height - 1)
+	if !ok {
+		return fmt.Errorf("unknown hash(%d)", height-1)
+	}
+	vr.hashes = vr.hashes[:uint64(len(vr.hashes))-(vr.next-height)]
+	vr.next, vr.parentHash = height, parentHash
+	return nil
+}
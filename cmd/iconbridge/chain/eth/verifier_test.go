@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReceipts() types.Receipts {
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{{
+			Address: common.HexToAddress("0xB4fC4b3b4e3157448B7D279f06BC8e340d63e2a9"),
+			Topics:  []common.Hash{{1}},
+		}}},
+	}
+	for _, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
+	return receipts
+}
+
+// testHeaders returns a chain of headers from height, the receipts of
+// their blocks being receipts
+func testHeaders(height uint64, n int, receipts types.Receipts) []*types.Header {
+	headers := make([]*types.Header, n)
+	parent := common.Hash{0xff}
+	for i := range headers {
+		headers[i] = &types.Header{
+			ParentHash:  parent,
+			Number:      new(big.Int).SetUint64(height + uint64(i)),
+			Bloom:       types.CreateBloom(receipts),
+			ReceiptHash: types.DeriveSha(receipts, trie.NewStackTrie(nil)),
+		}
+		parent = headers[i].Hash()
+	}
+	return headers
+}
+
+func TestVerifier(t *testing.T) {
+	receipts := testReceipts()
+	headers := testHeaders(10, 3, receipts)
+
+	vr := newVerifier(10)
+	require.NoError(t, vr.Verify(headers[0], headers[1], receipts))
+	require.NoError(t, vr.Update(headers[0]))
+	assert.EqualValues(t, 11, vr.next)
+
+	assert.Error(t, vr.Verify(headers[2], nil, nil), "unexpected height")
+	assert.Error(t, vr.Verify(headers[1], nil, types.Receipts{}), "invalid receipts")
+
+	fork := testHeaders(11, 1, receipts)[0]
+	assert.Error(t, vr.Verify(fork, nil, nil), "unexpected parent hash")
+	assert.Error(t, vr.Verify(headers[1], fork, nil), "unexpected next parent hash")
+
+	require.NoError(t, vr.Verify(headers[1], headers[2], nil))
+	require.NoError(t, vr.Update(headers[1]))
+	require.NoError(t, vr.Verify(headers[2], nil, receipts))
+}
+
+func TestVerifier_Rollback(t *testing.T) {
+	headers := testHeaders(10, 5, nil)
+	vr := newVerifier(10)
+	for _, header := range headers {
+		require.NoError(t, vr.Update(header))
+	}
+	hash, ok := vr.Hash(12)
+	require.True(t, ok)
+	assert.Equal(t, headers[2].Hash(), hash)
+	_, ok = vr.Hash(15)
+	assert.False(t, ok, "not verified yet")
+	_, ok = vr.Hash(9)
+	assert.False(t, ok, "not verified")
+
+	require.NoError(t, vr.Rollback(13))
+	assert.EqualValues(t, 13, vr.next)
+	assert.Equal(t, headers[2].Hash(), vr.parentHash)
+	_, ok = vr.Hash(13)
+	assert.False(t, ok, "rolled back")
+	assert.Error(t, vr.Rollback(10), "unknown parent hash")
+	assert.Error(t, vr.Rollback(14))
+
+	// the hashes of the latest blocks only
+	headers = testHeaders(100, evm.ReorgDepth+1, nil)
+	for _, header := range headers {
+		require.NoError(t, vr.Update(header))
+	}
+	_, ok = vr.Hash(100)
+	assert.False(t, ok)
+	require.NoError(t, vr.Rollback(102))
+	assert.Equal(t, headers[1].Hash(), vr.parentHash)
+}
+
+func TestValidateReceiverOptions(t *testing.T) {
+	assert.NoError(t, ValidateReceiverOptions([]byte(`{}`)))
+	assert.NoError(t, ValidateReceiverOptions([]byte(`{"blockTag":"finalized","confirmations":2}`)))
+	assert.Error(t, ValidateReceiverOptions([]byte(`{"blockTag":"pending"}`)))
+	assert.Error(t, ValidateReceiverOptions([]byte(`{"confirmation":2}`)))
+}
@@ -0,0 +1,324 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultReadTimeout = 50 * time.Second
+	RPCCallRetry       = 5
+	// HeaderBatchSize is the number of calls of the batches of
+	// GetHeadersByRange
+	HeaderBatchSize = 100
+)
+
+// Dial returns the Client of the rpc api at url, without its chain id
+func Dial(url string, l log.Logger) (*Client, error) {
+	clrpc, err := rpc.Dial(url)
+	if err != nil {
+		l.Errorf("failed to create evm rpc client: url=%v, %v", url, err)
+		return nil, err
+	}
+	return &Client{
+		Log: l,
+		RPC: clrpc,
+		Eth: ethclient.NewClient(clrpc),
+	}, nil
+}
+
+// DialEndpoint ...
+// returns the Client of the rpc api at endpoint, without its chain id,
+// whose calls over http are recorded by endpoint
+func DialEndpoint(endpoint *chain.Endpoint, l log.Logger) (*Client, error) {
+	url := endpoint.URL()
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		cl, err := Dial(url, l)
+		if err != nil {
+			return nil, err
+		}
+		cl.Endpoint = endpoint
+		return cl, nil
+	}
+	clrpc, err := rpc.DialHTTPWithClient(url, &http.Client{Transport: endpoint.Transport(nil)})
+	if err != nil {
+		l.Errorf("failed to create evm rpc client: url=%v, %v", url, err)
+		return nil, err
+	}
+	return &Client{
+		Log:      l,
+		RPC:      clrpc,
+		Eth:      ethclient.NewClient(clrpc),
+		Endpoint: endpoint,
+	}, nil
+}
+
+// NewClients ...
+// returns a Client for each url, with its chain id, and the bindings of the
+// BMC at address `bmc` through them; their endpoints share the
+// chain.SharedEndpointPool of urls
+func NewClients(urls []string, bmc string, l log.Logger) (cls []*Client, bmcs []*BMC, err error) {
+	pool := chain.SharedEndpointPool(urls)
+	for i, url := range urls {
+		cl, err := DialEndpoint(pool.Endpoint(i), l)
+		if err != nil {
+			return nil, nil, err
+		}
+		clbmc, err := NewBMC(common.HexToAddress(bmc), cl.Eth)
+		if err != nil {
+			l.Errorf("failed to create bmc binding to evm ethclient: url=%v, %v", url, err)
+			return nil, nil, err
+		}
+		cl.ChainID, err = cl.GetChainID()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "GetChainID %v", err)
+		}
+		cls = append(cls, cl)
+		bmcs = append(bmcs, clbmc)
+	}
+	return cls, bmcs, nil
+}
+
+// Client groups the rpc api clients of an EVM chain
+type Client struct {
+	Log     log.Logger
+	RPC     *rpc.Client
+	Eth     *ethclient.Client
+	ChainID *big.Int
+	// Endpoint tracks the health of the endpoint of the client, if set
+	Endpoint *chain.Endpoint
+}
+
+// Pick returns a client of cls and the binding of bmcs through it, one of
+// the healthiest
+func Pick(cls []*Client, bmcs []*BMC) (*Client, *BMC) {
+	i := pick(cls)
+	return cls[i], bmcs[i]
+}
+
+// PickClient returns a client of cls, one of the healthiest
+func PickClient(cls []*Client) *Client {
+	return cls[pick(cls)]
+}
+
+// pick returns the index of a client of cls by chain.PickEndpoint
+func pick(cls []*Client) int {
+	endpoints := make([]*chain.Endpoint, len(cls))
+	for i, cl := range cls {
+		endpoints[i] = cl.Endpoint
+	}
+	return chain.PickEndpoint(endpoints)
+}
+
+// Endpoints returns the health of the endpoints of cls, nil if untracked
+func Endpoints(cls []*Client) []chain.EndpointStatus {
+	if len(cls) == 0 || cls[0].Endpoint == nil {
+		return nil
+	}
+	return cls[0].Endpoint.Pool().Status()
+}
+
+func (cl *Client) GetBalance(ctx context.Context, hexAddr string) (*big.Int, error) {
+	if !common.IsHexAddress(hexAddr) {
+		return nil, fmt.Errorf("invalid hex address: %v", hexAddr)
+	}
+	return cl.Eth.BalanceAt(ctx, common.HexToAddress(hexAddr), nil)
+}
+
+func (cl *Client) GetBlockNumber() (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultReadTimeout)
+	defer cancel()
+	bn, err := cl.Eth.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if cl.Endpoint != nil {
+		cl.Endpoint.SetHeight(bn)
+	}
+	return bn, nil
+}
+
+func (cl *Client) GetChainID() (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultReadTimeout)
+	defer cancel()
+	return cl.Eth.ChainID(ctx)
+}
+
+// txBlock is a block with the hashes of its txs
+type txBlock struct {
+	Transactions []string `json:"transactions"`
+	GasUsed      string   `json:"gasUsed"`
+}
+
+func (cl *Client) getBlockByHash(hash common.Hash) (*txBlock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultReadTimeout)
+	defer cancel()
+	var hb txBlock
+	err := cl.RPC.CallContext(ctx, &hb, "eth_getBlockByHash", hash, false)
+	if err != nil {
+		return nil, err
+	}
+	return &hb, nil
+}
+
+func (cl *Client) GetHeaderByHeight(height *big.Int) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultReadTimeout)
+	defer cancel()
+	return cl.Eth.HeaderByNumber(ctx, height)
+}
+
+// GetHeaderByTag returns the header of the block of a tag of the node,
+// e.g. "latest" or "finalized"
+func (cl *Client) GetHeaderByTag(ctx context.Context, tag string) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+	defer cancel()
+	var header *types.Header
+	err := cl.RPC.CallContext(ctx, &header, "eth_getBlockByNumber", tag, false)
+	if err == nil && header == nil {
+		err = ethereum.NotFound
+	}
+	return header, err
+}
+
+// GetHeadersByRange ...
+// fetches the headers of the blocks from `from` upto `to`, in order, with
+// batches of HeaderBatchSize calls
+func (cl *Client) GetHeadersByRange(ctx context.Context, from, to uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, to-from+1)
+	for from <= to {
+		n := to - from + 1
+		if n > HeaderBatchSize {
+			n = HeaderBatchSize
+		}
+		batch := make([]rpc.BatchElem, n)
+		for i := range batch {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+				Result: new(*types.Header),
+			}
+		}
+		bctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+		err := cl.RPC.BatchCallContext(bctx, batch)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for i, elem := range batch {
+			header := *elem.Result.(**types.Header)
+			switch {
+			case elem.Error != nil:
+				return nil, errors.Wrapf(elem.Error, "eth_getBlockByNumber(%d): %v", from+uint64(i), elem.Error)
+			case header == nil:
+				return nil, errors.Wrapf(ethereum.NotFound, "eth_getBlockByNumber(%d)", from+uint64(i))
+			case header.Number.Uint64() != from+uint64(i):
+				return nil, errors.Errorf("eth_getBlockByNumber(%d): header of %d", from+uint64(i), header.Number)
+			}
+			headers = append(headers, header)
+		}
+		from += n
+	}
+	return headers, nil
+}
+
+// GetBlockReceipts fetches the receipts of the txs of a block concurrently
+func (cl *Client) GetBlockReceipts(hash common.Hash) (types.Receipts, error) {
+	hb, err := cl.getBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if hb.GasUsed == "0x0" || len(hb.Transactions) == 0 {
+		return nil, nil
+	}
+	txhs := hb.Transactions
+	// fetch all txn receipts concurrently
+	type rcq struct {
+		txh   string
+		v     *types.Receipt
+		err   error
+		retry int
+	}
+	qch := make(chan *rcq, len(txhs))
+	for _, txh := range txhs {
+		qch <- &rcq{txh, nil, nil, RPCCallRetry}
+	}
+	rmap := make(map[string]*types.Receipt)
+	for q := range qch {
+		switch {
+		case q.err != nil:
+			if q.retry == 0 {
+				return nil, q.err
+			}
+			q.retry--
+			q.err = nil
+			qch <- q
+		case q.v != nil:
+			rmap[q.txh] = q.v
+			if len(rmap) == cap(qch) {
+				close(qch)
+			}
+		default:
+			go func(q *rcq) {
+				defer func() { qch <- q }()
+				ctx, cancel := context.WithTimeout(context.Background(), DefaultReadTimeout)
+				defer cancel()
+				if q.v == nil {
+					q.v = &types.Receipt{}
+				}
+				q.v, q.err = cl.Eth.TransactionReceipt(ctx, common.HexToHash(q.txh))
+				if q.err != nil {
+					q.err = errors.Wrapf(q.err, "getTranasctionReceipt: %v", q.err)
+				}
+			}(q)
+		}
+	}
+	receipts := make(types.Receipts, 0, len(txhs))
+	for _, txh := range txhs {
+		if r, ok := rmap[txh]; ok {
+			receipts = append(receipts, r)
+		}
+	}
+	return receipts, nil
+}
+
+// HasLogs tells if the contract at address emitted any logs at height
+func (cl *Client) HasLogs(ctx context.Context, height *big.Int, address string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+	defer cancel()
+	logs, err := cl.Eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: height,
+		ToBlock:   height,
+		Addresses: []common.Address{common.HexToAddress(address)},
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "FilterLogs %v", err)
+	}
+	return len(logs) > 0, nil
+}
+
+// NewTransactOpts returns the options of txs signed by w on the chain,
+// with gasLimit
+func (cl *Client) NewTransactOpts(w wallet.Wallet, gasLimit uint64) (*bind.TransactOpts, error) {
+	txo, err := wallet.NewEvmTransactor(w, cl.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	txo.GasLimit = gasLimit
+	return txo, nil
+}
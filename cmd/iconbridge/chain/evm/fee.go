@@ -0,0 +1,258 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/icon-project/icon-bridge/common/intconv"
+)
+
+const (
+	// FeeModeLegacy prices legacy txs at the median gas price of the
+	// latest block
+	FeeModeLegacy = "legacy"
+	// FeeModeDynamic prices EIP-1559 txs from the fee history of recent
+	// blocks: the tip at a percentile of their tips, and the fee cap at
+	// twice the base fee plus the tip
+	FeeModeDynamic = "dynamic"
+	// FeeModeFixed prices txs at fixed fees of the config
+	FeeModeFixed = "fixed"
+
+	defaultFeeHistoryBlocks     = 10
+	defaultFeeHistoryPercentile = 50
+)
+
+// FeeConfig configures the fee strategy of an EVM sender, its "fee" option
+type FeeConfig struct {
+	// Mode is one of FeeModeLegacy, FeeModeDynamic and FeeModeFixed; the
+	// sender picks its default if empty
+	Mode string `json:"mode,omitempty"`
+
+	// Blocks is the number of recent blocks of the fee history in dynamic
+	// mode, 10 by default
+	Blocks uint64 `json:"blocks,omitempty"`
+	// Percentile of the tips in the blocks of the fee history, 50 by
+	// default
+	Percentile float64 `json:"percentile,omitempty"`
+
+	// GasPrice is the gas price of legacy txs in fixed mode, or GasTipCap
+	// and GasFeeCap of EIP-1559 txs
+	GasPrice  intconv.BigInt `json:"gas_price"`
+	GasTipCap intconv.BigInt `json:"gas_tip_cap"`
+	GasFeeCap intconv.BigInt `json:"gas_fee_cap"`
+
+	// MaxGasPrice caps the gas price of legacy txs and the fee cap of
+	// EIP-1559 txs, and MaxGasTipCap their tip; no caps if zero
+	MaxGasPrice  intconv.BigInt `json:"max_gas_price"`
+	MaxGasTipCap intconv.BigInt `json:"max_gas_tip_cap"`
+}
+
+// Fee is the price of a tx: GasPrice of a legacy tx, or GasTipCap and
+// GasFeeCap of an EIP-1559 tx
+type Fee struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// Apply sets the fee of the txs of opts
+func (f *Fee) Apply(opts *bind.TransactOpts) {
+	opts.GasPrice, opts.GasTipCap, opts.GasFeeCap = f.GasPrice, f.GasTipCap, f.GasFeeCap
+}
+
+func (f *Fee) String() string {
+	if f.GasPrice != nil {
+		return fmt.Sprintf("gasPrice=%v", f.GasPrice)
+	}
+	return fmt.Sprintf("gasTipCap=%v gasFeeCap=%v", f.GasTipCap, f.GasFeeCap)
+}
+
+// FeeHistory is the result of eth_feeHistory
+type FeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeClient is the api of a chain that fee strategies use
+type FeeClient interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error)
+	TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error)
+	// FeeHistory returns the fee history of the latest blocks, with the
+	// tips at the percentiles in each block
+	FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*FeeHistory, error)
+}
+
+type feeClient struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// NewFeeClient returns the FeeClient of an rpc client
+func NewFeeClient(c *rpc.Client) FeeClient {
+	return &feeClient{Client: ethclient.NewClient(c), rpc: c}
+}
+
+func (c *feeClient) FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*FeeHistory, error) {
+	var fh FeeHistory
+	if err := c.rpc.CallContext(ctx, &fh, "eth_feeHistory",
+		hexutil.Uint64(blocks), "latest", percentiles); err != nil {
+		return nil, err
+	}
+	return &fh, nil
+}
+
+// FeeStrategy prices relay txs
+type FeeStrategy interface {
+	Fee(ctx context.Context, c FeeClient) (*Fee, error)
+}
+
+// NewFeeStrategy returns the fee strategy of cfg, which multiplies the
+// market gas price (or tip) by boost
+func NewFeeStrategy(cfg *FeeConfig, boost float64) (FeeStrategy, error) {
+	if boost < 1 {
+		boost = 1
+	}
+	switch cfg.Mode {
+	case FeeModeLegacy:
+		return &legacyFee{boost: boost, max: &cfg.MaxGasPrice.Int}, nil
+	case FeeModeDynamic:
+		fs := &dynamicFee{
+			blocks:     cfg.Blocks,
+			percentile: cfg.Percentile,
+			boost:      boost,
+			maxFeeCap:  &cfg.MaxGasPrice.Int,
+			maxTip:     &cfg.MaxGasTipCap.Int,
+		}
+		if fs.blocks == 0 {
+			fs.blocks = defaultFeeHistoryBlocks
+		}
+		if fs.percentile == 0 {
+			fs.percentile = defaultFeeHistoryPercentile
+		}
+		if fs.percentile < 0 || fs.percentile > 100 {
+			return nil, fmt.Errorf("fee: invalid percentile: %v", cfg.Percentile)
+		}
+		return fs, nil
+	case FeeModeFixed:
+		var fee Fee
+		switch {
+		case cfg.GasPrice.Sign() > 0:
+			fee.GasPrice = capped(&cfg.GasPrice.Int, &cfg.MaxGasPrice.Int)
+		case cfg.GasTipCap.Sign() > 0 && cfg.GasFeeCap.Sign() > 0:
+			fee.GasFeeCap = capped(&cfg.GasFeeCap.Int, &cfg.MaxGasPrice.Int)
+			fee.GasTipCap = capped(capped(&cfg.GasTipCap.Int, &cfg.MaxGasTipCap.Int), fee.GasFeeCap)
+		default:
+			return nil, fmt.Errorf("fee: fixed mode requires gas_price, or gas_tip_cap and gas_fee_cap")
+		}
+		return fixedFee(fee), nil
+	default:
+		return nil, fmt.Errorf("fee: invalid mode: %q", cfg.Mode)
+	}
+}
+
+type legacyFee struct {
+	boost float64
+	max   *big.Int
+}
+
+func (fs *legacyFee) Fee(ctx context.Context, c FeeClient) (*Fee, error) {
+	gasPrice, _, err := MedianGasPrice(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice.Sign() == 0 {
+		return nil, fmt.Errorf("zero median gas price")
+	}
+	return &Fee{GasPrice: capped(boosted(gasPrice, fs.boost), fs.max)}, nil
+}
+
+type dynamicFee struct {
+	blocks     uint64
+	percentile float64
+	boost      float64
+	maxFeeCap  *big.Int
+	maxTip     *big.Int
+}
+
+func (fs *dynamicFee) Fee(ctx context.Context, c FeeClient) (*Fee, error) {
+	fh, err := c.FeeHistory(ctx, fs.blocks, []float64{fs.percentile})
+	if err != nil {
+		return nil, err
+	}
+	// the last base fee is of the next block
+	if len(fh.BaseFee) == 0 || fh.BaseFee[len(fh.BaseFee)-1] == nil {
+		return nil, fmt.Errorf("no base fee, EIP-1559 is not supported")
+	}
+	baseFee := fh.BaseFee[len(fh.BaseFee)-1].ToInt()
+	var tips []*big.Int
+	for _, reward := range fh.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			tips = append(tips, reward[0].ToInt())
+		}
+	}
+	tip := new(big.Int)
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+		tip = boosted(tips[len(tips)/2], fs.boost)
+	}
+	tip = capped(tip, fs.maxTip)
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	feeCap = capped(feeCap, fs.maxFeeCap)
+	return &Fee{GasTipCap: capped(tip, feeCap), GasFeeCap: feeCap}, nil
+}
+
+type fixedFee Fee
+
+func (fs fixedFee) Fee(ctx context.Context, c FeeClient) (*Fee, error) {
+	fee := Fee(fs)
+	return &fee, nil
+}
+
+// MedianGasPrice returns the median gas price of the txs of the latest
+// block, and its height
+func MedianGasPrice(ctx context.Context, c FeeClient) (gasPrice, height *big.Int, err error) {
+	header, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("HeaderByNumber(latest): %v", err)
+	}
+	height = header.Number
+	count, err := c.TransactionCount(ctx, header.Hash())
+	if err != nil {
+		return nil, height, fmt.Errorf("TransactionCount(height: %v, hash: %v): %v", height, header.Hash(), err)
+	} else if count == 0 {
+		return nil, height, fmt.Errorf("TransactionCount is zero for height(%v, hash: %v)", height, header.Hash())
+	}
+	tx, err := c.TransactionInBlock(ctx, header.Hash(), count/2)
+	if err != nil {
+		return nil, height, fmt.Errorf("TransactionInBlock(height: %v, hash: %v, index: %v): %v", height, header.Hash(), count/2, err)
+	}
+	return tx.GasPrice(), height, nil
+}
+
+// boosted returns v multiplied by boost, if more than 1
+func boosted(v *big.Int, boost float64) *big.Int {
+	if boost <= 1 {
+		return new(big.Int).Set(v)
+	}
+	b, _ := new(big.Float).Mul(new(big.Float).SetInt(v), big.NewFloat(boost)).Int(nil)
+	return b
+}
+
+// capped returns v, or max if v exceeds a non-zero max
+func capped(v, max *big.Int) *big.Int {
+	if max != nil && max.Sign() > 0 && v.Cmp(max) > 0 {
+		return new(big.Int).Set(max)
+	}
+	return v
+}
@@ -0,0 +1,104 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFeeClient struct {
+	gasPrices []int64
+	tips      []int64
+	baseFee   int64
+}
+
+func (c *fakeFeeClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100)}, nil
+}
+
+func (c *fakeFeeClient) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return uint(len(c.gasPrices)), nil
+}
+
+func (c *fakeFeeClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	return types.NewTransaction(0, common.Address{}, nil, 21000, big.NewInt(c.gasPrices[index]), nil), nil
+}
+
+func (c *fakeFeeClient) FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*FeeHistory, error) {
+	fh := &FeeHistory{}
+	for _, tip := range c.tips {
+		fh.Reward = append(fh.Reward, []*hexutil.Big{(*hexutil.Big)(big.NewInt(tip))})
+	}
+	if c.baseFee > 0 {
+		fh.BaseFee = []*hexutil.Big{(*hexutil.Big)(big.NewInt(c.baseFee))}
+	}
+	return fh, nil
+}
+
+func TestFeeStrategy(t *testing.T) {
+	const gwei = 1e9
+	c := &fakeFeeClient{
+		gasPrices: []int64{5 * gwei, 6 * gwei, 7 * gwei},
+		tips:      []int64{1 * gwei, 3 * gwei, 2 * gwei},
+		baseFee:   10 * gwei,
+	}
+	for _, tc := range []struct {
+		name    string
+		cfg     string
+		boost   float64
+		fee     Fee
+		err     bool
+		feeErr  bool
+		noBase  bool
+		noPrice bool
+	}{
+		{name: "legacy", cfg: `{"mode":"legacy"}`, fee: Fee{GasPrice: big.NewInt(6 * gwei)}},
+		{name: "legacy boost", cfg: `{"mode":"legacy"}`, boost: 1.5, fee: Fee{GasPrice: big.NewInt(9 * gwei)}},
+		{name: "legacy cap", cfg: `{"mode":"legacy","max_gas_price":5000000000}`, fee: Fee{GasPrice: big.NewInt(5 * gwei)}},
+		{name: "legacy no txs", cfg: `{"mode":"legacy"}`, feeErr: true, noPrice: true},
+		{name: "dynamic", cfg: `{"mode":"dynamic"}`,
+			fee: Fee{GasTipCap: big.NewInt(2 * gwei), GasFeeCap: big.NewInt(22 * gwei)}},
+		{name: "dynamic boost", cfg: `{"mode":"dynamic","percentile":90}`, boost: 2,
+			fee: Fee{GasTipCap: big.NewInt(4 * gwei), GasFeeCap: big.NewInt(24 * gwei)}},
+		{name: "dynamic caps", cfg: `{"mode":"dynamic","max_gas_price":"15000000000","max_gas_tip_cap":1000000000}`,
+			fee: Fee{GasTipCap: big.NewInt(1 * gwei), GasFeeCap: big.NewInt(15 * gwei)}},
+		{name: "dynamic unsupported", cfg: `{"mode":"dynamic"}`, feeErr: true, noBase: true},
+		{name: "dynamic percentile", cfg: `{"mode":"dynamic","percentile":101}`, err: true},
+		{name: "fixed", cfg: `{"mode":"fixed","gas_price":1000}`, boost: 2, fee: Fee{GasPrice: big.NewInt(1000)}},
+		{name: "fixed dynamic", cfg: `{"mode":"fixed","gas_tip_cap":10,"gas_fee_cap":100,"max_gas_price":50}`,
+			fee: Fee{GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(50)}},
+		{name: "fixed none", cfg: `{"mode":"fixed"}`, err: true},
+		{name: "unknown", cfg: `{"mode":"auto"}`, err: true},
+	} {
+		var cfg FeeConfig
+		require.NoError(t, json.Unmarshal([]byte(tc.cfg), &cfg), tc.name)
+		fs, err := NewFeeStrategy(&cfg, tc.boost)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+
+		fc := *c
+		if tc.noBase {
+			fc.baseFee = 0
+		}
+		if tc.noPrice {
+			fc.gasPrices = nil
+		}
+		fee, err := fs.Fee(context.Background(), &fc)
+		if tc.feeErr {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.fee.String(), fee.String(), tc.name)
+	}
+}
@@ -0,0 +1,251 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// VerifiedBlock is a verified block of an EVM chain: its height and the
+// logs of the receipts of its txs, in order
+type VerifiedBlock struct {
+	Height uint64
+	Logs   [][]*types.Log
+	// Orphaned ...
+	// tells that the blocks from Height on, passed before, were orphaned
+	// by a reorg, rather than being a block itself; the blocks of the new
+	// chain follow from Height
+	Orphaned bool
+}
+
+// BlockSource ...
+// is the hook of a Receiver for the blocks of a chain: it fetches them and
+// verifies their headers in its own way, e.g. with parlia for BSC or the
+// signatures of the committee for Harmony
+type BlockSource interface {
+	// ReceiveBlocks ...
+	// calls cb with the verified blocks from height, in order, until ctx
+	// is done or it fails; blocks orphaned by a reorg, within the latest
+	// ReorgDepth blocks, are withdrawn with an Orphaned VerifiedBlock
+	ReceiveBlocks(ctx context.Context, height uint64, cb func(*VerifiedBlock) error) error
+}
+
+// HeaderVerifier ...
+// is the hook of a BlockSource of a chain with ethereum headers to verify
+// them, given the next header, and the receipts of their blocks
+type HeaderVerifier interface {
+	Verify(header, next *types.Header, receipts types.Receipts) error
+	// Update advances the verifier past a verified header
+	Update(header *types.Header) error
+}
+
+// ValidateReceipts ...
+// checks receipts against the logs bloom and the receipt root of the
+// header of their block
+func ValidateReceipts(header *types.Header, receipts types.Receipts) error {
+	rbloom := types.CreateBloom(receipts)
+	if rbloom != header.Bloom {
+		return fmt.Errorf("invalid bloom (remote: %x  local: %x)", header.Bloom, rbloom)
+	}
+	receiptSha := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	if receiptSha != header.ReceiptHash {
+		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
+	}
+	return nil
+}
+
+// ReceiptLogs returns the logs of each of receipts
+func ReceiptLogs(receipts types.Receipts) [][]*types.Log {
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs
+}
+
+// MessageParser extracts the BTP messages of a BMC from the logs of receipts
+type MessageParser struct {
+	address common.Address
+	topic   common.Hash
+	bmc     *BMCFilterer
+}
+
+// NewMessageParser returns the MessageParser of the BMC at address
+func NewMessageParser(address string) (*MessageParser, error) {
+	addr := common.HexToAddress(address)
+	bmc, err := NewBMCFilterer(addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(BMCABI))
+	if err != nil {
+		return nil, err
+	}
+	return &MessageParser{
+		address: addr,
+		topic:   parsed.Events["Message"].ID,
+		bmc:     bmc,
+	}, nil
+}
+
+// MayHaveMessages tells if a block with the logs bloom may have messages
+// of the BMC; it has none otherwise
+func (p *MessageParser) MayHaveMessages(bloom types.Bloom) bool {
+	return types.BloomLookup(bloom, p.address) && types.BloomLookup(bloom, p.topic)
+}
+
+// Receipts returns the receipts of the block at height with messages of
+// the BMC, logs[i] being the logs of receipt i
+func (p *MessageParser) Receipts(height uint64, logs [][]*types.Log) []*chain.Receipt {
+	var receipts []*chain.Receipt
+	for i, rlogs := range logs {
+		var events []*chain.Event
+		for _, log := range rlogs {
+			// anonymous events have no topics, unlike Message
+			if !bytes.Equal(log.Address.Bytes(), p.address.Bytes()) || len(log.Topics) == 0 {
+				continue
+			}
+			msg, err := p.bmc.ParseMessage(types.Log{
+				Data: log.Data, Topics: log.Topics,
+			})
+			if err == nil {
+				events = append(events, &chain.Event{
+					Next:     chain.BTPAddress(msg.Next),
+					Sequence: msg.Seq.Uint64(),
+					Message:  msg.Msg,
+				})
+			}
+		}
+		if len(events) > 0 {
+			receipts = append(receipts, &chain.Receipt{
+				Index:  uint64(i),
+				Height: height,
+				Events: events,
+			})
+		}
+	}
+	return receipts
+}
+
+// Receiver ...
+// subscribes to the BTP messages from the BMC of an EVM chain, in the
+// blocks of its BlockSource
+type Receiver struct {
+	log    log.Logger
+	dst    chain.BTPAddress
+	source BlockSource
+	parser *MessageParser
+}
+
+// NewReceiver returns the Receiver of the messages of the BMC at src to
+// dst, in the blocks of source
+func NewReceiver(src, dst chain.BTPAddress, source BlockSource, l log.Logger) (*Receiver, error) {
+	parser, err := NewMessageParser(src.ContractAddress())
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{log: l, dst: dst, source: source, parser: parser}, nil
+}
+
+func (r *Receiver) Subscribe(
+	ctx context.Context, msgCh chan<- *chain.Message,
+	opts chain.SubscribeOptions) (errCh <-chan error, err error) {
+
+	opts.Seq++
+
+	_errCh := make(chan error)
+
+	// sequence is the sequence expected at the first height with
+	// receipts, to rewind to on reorgs
+	type sequence struct {
+		height, seq uint64
+	}
+
+	go func() {
+		defer close(_errCh)
+		lastHeight := opts.Height - 1
+		// sequences of the latest ReorgDepth heights
+		var seqs []sequence
+		if err := r.source.ReceiveBlocks(ctx, opts.Height,
+			func(v *VerifiedBlock) error {
+				if v.Orphaned {
+					if v.Height > lastHeight || v.Height < opts.Height ||
+						v.Height+ReorgDepth <= lastHeight {
+						r.log.Errorf("unexpected orphaned height: %d, last height: %d", v.Height, lastHeight)
+						return fmt.Errorf(
+							"block notification: orphaned=%d, last=%d",
+							v.Height, lastHeight)
+					}
+					for i, s := range seqs {
+						if s.height >= v.Height {
+							opts.Seq, seqs = s.seq, seqs[:i]
+							break
+						}
+					}
+					r.log.WithFields(log.Fields{
+						"height": v.Height, "seq": opts.Seq}).Warn("blocks orphaned by reorg")
+					lastHeight = v.Height - 1
+					msgCh <- &chain.Message{Height: lastHeight, Rollback: true}
+					return nil
+				}
+				r.log.WithFields(log.Fields{"height": v.Height}).Debug("block notification")
+
+				if v.Height != lastHeight+1 {
+					r.log.Errorf("expected v.Height == %d, got %d", lastHeight+1, v.Height)
+					return fmt.Errorf(
+						"block notification: expected=%d, got=%d",
+						lastHeight+1, v.Height)
+				}
+
+				receipts := r.parser.Receipts(v.Height, v.Logs)
+				if len(receipts) > 0 {
+					for len(seqs) > 0 && seqs[0].height+ReorgDepth <= v.Height {
+						seqs = seqs[1:]
+					}
+					seqs = append(seqs, sequence{v.Height, opts.Seq})
+				}
+				for _, receipt := range receipts {
+					events := receipt.Events[:0]
+					for _, event := range receipt.Events {
+						switch {
+						case !event.Next.Equal(r.dst):
+							// sequence of another link, routed by the relay
+							events = append(events, event)
+						case event.Sequence == opts.Seq:
+							events = append(events, event)
+							opts.Seq++
+						case event.Sequence > opts.Seq:
+							r.log.WithFields(log.Fields{
+								"seq": log.Fields{"got": event.Sequence, "expected": opts.Seq},
+							}).Error("invalid event seq")
+							return fmt.Errorf("invalid event seq")
+						}
+					}
+					receipt.Events = events
+				}
+				if len(receipts) > 0 {
+					msgCh <- &chain.Message{Receipts: receipts, Height: v.Height}
+				} else {
+					select { // report progress, but don't block on it
+					case msgCh <- &chain.Message{Height: v.Height}:
+					default:
+					}
+				}
+				lastHeight++
+				return nil
+			}); err != nil {
+			r.log.Errorf("receiveLoop terminated: %v", err)
+			_errCh <- err
+		}
+	}()
+
+	return _errCh, nil
+}
@@ -0,0 +1,204 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSrc = "btp://0x61.bsc/0xB4fC4b3b4e3157448B7D279f06BC8e340d63e2a9"
+	testDst = "btp://0x7.icon/cx8a6606d526b96a16e6764aee5d9abecf926689df"
+)
+
+func messageLog(t *testing.T, address, next string, seq int64) *types.Log {
+	parsed, err := abi.JSON(strings.NewReader(BMCABI))
+	require.NoError(t, err)
+	event := parsed.Events["Message"]
+	data, err := event.Inputs.Pack(next, big.NewInt(seq), []byte(fmt.Sprint(seq)))
+	require.NoError(t, err)
+	return &types.Log{
+		Address: common.HexToAddress(address),
+		Topics:  []common.Hash{event.ID},
+		Data:    data,
+	}
+}
+
+func TestMessageParser_Receipts(t *testing.T) {
+	src := chain.BTPAddress(testSrc)
+	p, err := NewMessageParser(src.ContractAddress())
+	require.NoError(t, err)
+
+	other := "0x0000000000000000000000000000000000000001"
+	receipts := p.Receipts(10, [][]*types.Log{
+		{messageLog(t, other, testDst, 1)},
+		{
+			messageLog(t, src.ContractAddress(), testDst, 1),
+			{Address: common.HexToAddress(src.ContractAddress()), Topics: []common.Hash{{1}}},
+			{Address: common.HexToAddress(src.ContractAddress())},
+		},
+		nil,
+		{messageLog(t, src.ContractAddress(), testDst, 2), messageLog(t, src.ContractAddress(), testDst, 3)},
+	})
+	require.Len(t, receipts, 2, "only receipts with messages of the bmc")
+	assert.EqualValues(t, 1, receipts[0].Index)
+	assert.EqualValues(t, 10, receipts[0].Height)
+	require.Len(t, receipts[0].Events, 1)
+	assert.Equal(t, chain.BTPAddress(testDst), receipts[0].Events[0].Next)
+	assert.EqualValues(t, 1, receipts[0].Events[0].Sequence)
+	assert.Equal(t, []byte("1"), receipts[0].Events[0].Message)
+	assert.EqualValues(t, 3, receipts[1].Index)
+	assert.Len(t, receipts[1].Events, 2)
+}
+
+type fakeBlockSource []*VerifiedBlock
+
+func (s fakeBlockSource) ReceiveBlocks(ctx context.Context, height uint64, cb func(*VerifiedBlock) error) error {
+	for _, b := range s {
+		if b.Height < height && !b.Orphaned {
+			continue
+		}
+		if err := cb(b); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestReceiver_Subscribe(t *testing.T) {
+	src, dst := chain.BTPAddress(testSrc), chain.BTPAddress(testDst)
+	bmc := src.ContractAddress()
+	source := fakeBlockSource{
+		{Height: 10, Logs: [][]*types.Log{{messageLog(t, bmc, testDst, 1)}}},
+		{Height: 11},
+		{Height: 12, Logs: [][]*types.Log{{
+			messageLog(t, bmc, testDst, 2),
+			messageLog(t, bmc, "btp://0x1.other/cx0", 7),
+			messageLog(t, bmc, testDst, 3),
+		}}},
+	}
+	r, err := NewReceiver(src, dst, source, log.New())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgCh := make(chan *chain.Message, 10)
+	errCh, err := r.Subscribe(ctx, msgCh, chain.SubscribeOptions{Seq: 1, Height: 11})
+	require.NoError(t, err)
+
+	msg := <-msgCh
+	assert.EqualValues(t, 11, msg.Height)
+	assert.Empty(t, msg.Receipts)
+	msg = <-msgCh
+	assert.EqualValues(t, 12, msg.Height)
+	require.Len(t, msg.Receipts, 1)
+	var seqs []uint64
+	for _, ev := range msg.Receipts[0].Events {
+		seqs = append(seqs, ev.Sequence)
+	}
+	assert.Equal(t, []uint64{2, 7, 3}, seqs, "delivered seq 1 skipped, other links kept")
+
+	cancel()
+	_, ok := <-errCh
+	assert.False(t, ok)
+
+	// a gap in the sequence fails the subscription
+	r, err = NewReceiver(src, dst, source, log.New())
+	require.NoError(t, err)
+	errCh, err = r.Subscribe(context.Background(), msgCh, chain.SubscribeOptions{Seq: 0, Height: 12})
+	require.NoError(t, err)
+	assert.Error(t, <-errCh)
+}
+
+func TestReceiver_SubscribeOrphaned(t *testing.T) {
+	src, dst := chain.BTPAddress(testSrc), chain.BTPAddress(testDst)
+	bmc := src.ContractAddress()
+	source := fakeBlockSource{
+		{Height: 10, Logs: [][]*types.Log{{messageLog(t, bmc, testDst, 1)}}},
+		{Height: 11, Logs: [][]*types.Log{{messageLog(t, bmc, testDst, 2)}}},
+		{Height: 12, Logs: [][]*types.Log{{messageLog(t, bmc, testDst, 3)}}},
+		{Height: 11, Orphaned: true},
+		{Height: 11},
+		{Height: 12, Logs: [][]*types.Log{{messageLog(t, bmc, testDst, 2)}}},
+	}
+	r, err := NewReceiver(src, dst, source, log.New())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgCh := make(chan *chain.Message, 10)
+	_, err = r.Subscribe(ctx, msgCh, chain.SubscribeOptions{Seq: 0, Height: 10})
+	require.NoError(t, err)
+
+	for _, height := range []uint64{10, 11, 12} {
+		msg := <-msgCh
+		assert.EqualValues(t, height, msg.Height)
+		assert.False(t, msg.Rollback)
+	}
+	msg := <-msgCh
+	assert.True(t, msg.Rollback)
+	assert.EqualValues(t, 10, msg.Height, "receipts above 10 withdrawn")
+	msg = <-msgCh
+	assert.EqualValues(t, 11, msg.Height)
+	assert.Empty(t, msg.Receipts)
+	msg = <-msgCh
+	assert.EqualValues(t, 12, msg.Height)
+	require.Len(t, msg.Receipts, 1)
+	assert.EqualValues(t, 2, msg.Receipts[0].Events[0].Sequence, "sequence rewound")
+
+	// orphaned blocks before the start height
+	r, err = NewReceiver(src, dst, source, log.New())
+	require.NoError(t, err)
+	errCh, err := r.Subscribe(context.Background(), msgCh, chain.SubscribeOptions{Seq: 2, Height: 12})
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, (<-msgCh).Height)
+	assert.Error(t, <-errCh)
+}
+
+func TestMessageParser_MayHaveMessages(t *testing.T) {
+	src := chain.BTPAddress(testSrc)
+	p, err := NewMessageParser(src.ContractAddress())
+	require.NoError(t, err)
+
+	bloom := func(logs ...*types.Log) types.Bloom {
+		return types.CreateBloom(types.Receipts{{Logs: logs}})
+	}
+	other := "0x0000000000000000000000000000000000000001"
+	assert.True(t, p.MayHaveMessages(bloom(messageLog(t, src.ContractAddress(), testDst, 1))))
+	assert.False(t, p.MayHaveMessages(bloom()))
+	assert.False(t, p.MayHaveMessages(bloom(messageLog(t, other, testDst, 1))))
+	assert.False(t, p.MayHaveMessages(bloom(&types.Log{
+		Address: common.HexToAddress(src.ContractAddress()), Topics: []common.Hash{{1}}})))
+}
+
+func TestValidateReceipts(t *testing.T) {
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000,
+			Logs: []*types.Log{messageLog(t, chain.BTPAddress(testSrc).ContractAddress(), testDst, 1)}},
+		{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 42000},
+	}
+	for _, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
+	header := &types.Header{
+		Bloom:       types.CreateBloom(receipts),
+		ReceiptHash: types.DeriveSha(receipts, trie.NewStackTrie(nil)),
+	}
+	require.NoError(t, ValidateReceipts(header, receipts))
+
+	assert.Error(t, ValidateReceipts(header, receipts[:1]), "missing receipt")
+	assert.Error(t, ValidateReceipts(header, types.Receipts{receipts[1], receipts[0]}), "reordered receipts")
+	assert.Error(t, ValidateReceipts(header, nil), "no receipts")
+}
@@ -0,0 +1,51 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReorgDepth is the number of the latest verified blocks that receivers
+// can roll back on reorgs
+const ReorgDepth = 64
+
+// ErrReorgTooDeep is returned by Rollback if a reorg orphaned more blocks
+// than the verifier can roll back
+var ErrReorgTooDeep = fmt.Errorf("reorg deeper than the latest %d verified blocks", ReorgDepth)
+
+// RollbackVerifier ...
+// is a HeaderVerifier that keeps the hashes of the latest blocks it
+// verified, so that it can be rolled back to one of them on reorgs
+type RollbackVerifier interface {
+	HeaderVerifier
+	// Hash returns the hash of the verified block at height, if the
+	// verifier can be rolled back to right after it
+	Hash(height uint64) (common.Hash, bool)
+	// Rollback rolls the verifier back to right before the block at height
+	Rollback(height uint64) error
+}
+
+// Rollback ...
+// rolls vr back to the latest block below next it verified that is still
+// in the chain of the node after a reorg, as told by headerByHeight, and
+// returns the height of the block after it, the first orphaned one
+func Rollback(vr RollbackVerifier, next uint64,
+	headerByHeight func(height *big.Int) (*types.Header, error)) (uint64, error) {
+	for height := next; height > 0; height-- {
+		hash, ok := vr.Hash(height - 1)
+		if !ok {
+			break
+		}
+		header, err := headerByHeight(new(big.Int).SetUint64(height - 1))
+		if err != nil {
+			return 0, err
+		}
+		if header.Hash() == hash {
+			return height, vr.Rollback(height)
+		}
+	}
+	return 0, ErrReorgTooDeep
+}
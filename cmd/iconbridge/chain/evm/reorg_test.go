@@ -0,0 +1,95 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRollbackVerifier knows the hashes of verified blocks from first
+type fakeRollbackVerifier struct {
+	first  uint64
+	hashes []common.Hash
+}
+
+func (vr *fakeRollbackVerifier) Verify(header, next *types.Header, receipts types.Receipts) error {
+	return nil
+}
+
+func (vr *fakeRollbackVerifier) Update(header *types.Header) error {
+	vr.hashes = append(vr.hashes, header.Hash())
+	return nil
+}
+
+func (vr *fakeRollbackVerifier) Hash(height uint64) (common.Hash, bool) {
+	if height < vr.first || height >= vr.first+uint64(len(vr.hashes)) {
+		return common.Hash{}, false
+	}
+	return vr.hashes[height-vr.first], true
+}
+
+func (vr *fakeRollbackVerifier) Rollback(height uint64) error {
+	vr.hashes = vr.hashes[:height-vr.first]
+	return nil
+}
+
+// testChain returns the headers of a chain upto height, forked from
+// parent at the height of the first header
+func testChain(parent *types.Header, height uint64, extra byte) map[uint64]*types.Header {
+	headers := map[uint64]*types.Header{}
+	for h := parent.Number.Uint64() + 1; h <= height; h++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).SetUint64(h),
+			Extra:      []byte{extra},
+		}
+		headers[h] = header
+		parent = header
+	}
+	return headers
+}
+
+func TestRollback(t *testing.T) {
+	genesis := &types.Header{Number: big.NewInt(9)}
+	old := testChain(genesis, 20, 0)
+	vr := &fakeRollbackVerifier{first: 10}
+	for h := uint64(10); h <= 20; h++ {
+		require.NoError(t, vr.Update(old[h]))
+	}
+
+	// blocks from 16 are orphaned
+	node := testChain(old[15], 22, 1)
+	for h := uint64(10); h <= 15; h++ {
+		node[h] = old[h]
+	}
+	headerByHeight := func(height *big.Int) (*types.Header, error) {
+		if header, ok := node[height.Uint64()]; ok {
+			return header, nil
+		}
+		return nil, fmt.Errorf("not found: %v", height)
+	}
+	height, err := Rollback(vr, 21, headerByHeight)
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, height)
+	assert.Len(t, vr.hashes, 6)
+
+	// nothing orphaned
+	height, err = Rollback(vr, 16, headerByHeight)
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, height)
+
+	// all the verified blocks orphaned
+	node = testChain(genesis, 22, 2)
+	_, err = Rollback(vr, 16, headerByHeight)
+	assert.Equal(t, ErrReorgTooDeep, err)
+
+	delete(node, 15)
+	_, err = Rollback(vr, 16, headerByHeight)
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrReorgTooDeep, err, "failed to get header")
+}
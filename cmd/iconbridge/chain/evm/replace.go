@@ -0,0 +1,164 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/common/intconv"
+)
+
+const (
+	defaultReplaceWait = time.Minute
+	defaultReplaceBump = 1.2
+	// minReplaceBump is the least raise of the fee of a replacement that
+	// nodes accept, 10%
+	minReplaceBump = 1.1
+)
+
+// ReplaceConfig configures the replacement of stuck txs of an EVM sender,
+// its "replace" option. A tx pending for Wait and Blocks is sent again
+// with the same nonce and the gas price raised by Bump, upto MaxGasPrice.
+type ReplaceConfig struct {
+	// Wait is the time to wait for a tx to be mined before replacing it,
+	// e.g. "30s"; 1m by default
+	Wait string `json:"wait,omitempty"`
+	// Blocks is the number of blocks to wait for a tx to be mined before
+	// replacing it, besides Wait
+	Blocks uint64 `json:"blocks,omitempty"`
+	// Bump is the ratio to raise the gas price (or tip and fee cap) of a
+	// replacement by, 1.2 by default and at least 1.1
+	Bump float64 `json:"bump,omitempty"`
+	// MaxGasPrice is the ceiling of the gas price (or fee cap) of
+	// replacements; no ceiling if zero
+	MaxGasPrice intconv.BigInt `json:"max_gas_price"`
+}
+
+// Replacer decides when and at which fee to replace stuck txs
+type Replacer struct {
+	wait   time.Duration
+	blocks uint64
+	bump   float64
+	max    *big.Int
+}
+
+// NewReplacer returns the Replacer of cfg, nil if cfg is nil
+func NewReplacer(cfg *ReplaceConfig) (*Replacer, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	r := &Replacer{
+		wait:   defaultReplaceWait,
+		blocks: cfg.Blocks,
+		bump:   cfg.Bump,
+		max:    &cfg.MaxGasPrice.Int,
+	}
+	if cfg.Wait != "" {
+		wait, err := time.ParseDuration(cfg.Wait)
+		if err != nil {
+			return nil, fmt.Errorf("replace: invalid wait: %v", err)
+		}
+		r.wait = wait
+	}
+	if r.bump == 0 {
+		r.bump = defaultReplaceBump
+	}
+	if r.bump < minReplaceBump {
+		return nil, fmt.Errorf("replace: bump must be at least %v: %v", minReplaceBump, cfg.Bump)
+	}
+	return r, nil
+}
+
+// Due tells if the txs sent by sent are to be replaced at height
+func (r *Replacer) Due(sent *SentTxs, height uint64) bool {
+	return time.Since(sent.At) >= r.wait && height >= sent.Height+r.blocks
+}
+
+// Bump returns the fee of a replacement of tx, and false if the fee
+// cannot be raised enough below the ceiling
+func (r *Replacer) Bump(tx *types.Transaction) (*Fee, bool) {
+	if tx.Type() == types.DynamicFeeTxType {
+		feeCap := capped(r.bumped(tx.GasFeeCap()), r.max)
+		tip := capped(r.bumped(tx.GasTipCap()), feeCap)
+		if !raised(tx.GasFeeCap(), feeCap) || !raised(tx.GasTipCap(), tip) {
+			return nil, false
+		}
+		return &Fee{GasTipCap: tip, GasFeeCap: feeCap}, true
+	}
+	gasPrice := capped(r.bumped(tx.GasPrice()), r.max)
+	if !raised(tx.GasPrice(), gasPrice) {
+		return nil, false
+	}
+	return &Fee{GasPrice: gasPrice}, true
+}
+
+// bumped returns v raised by the bump, and at least by minReplaceBump
+func (r *Replacer) bumped(v *big.Int) *big.Int {
+	b := boosted(v, r.bump)
+	if min := minRaised(v); b.Cmp(min) < 0 {
+		return min
+	}
+	return b
+}
+
+// minRaised returns v raised by minReplaceBump, rounded up
+func minRaised(v *big.Int) *big.Int {
+	min := new(big.Int).Mul(v, big.NewInt(int64(minReplaceBump*100)))
+	min.Add(min, big.NewInt(99))
+	return min.Div(min, big.NewInt(100))
+}
+
+// raised tells if v is raised from old enough for a replacement
+func raised(old, v *big.Int) bool {
+	return v.Cmp(minRaised(old)) >= 0
+}
+
+// TxClient is the api of a chain to follow the txs sent to it
+type TxClient interface {
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
+// SentTxs ...
+// are the txs sent with the same nonce: a tx and its replacements, the
+// latest last
+type SentTxs struct {
+	Txs []*types.Transaction
+	// At is the time the latest tx was sent, and Height the latest block
+	// then
+	At     time.Time
+	Height uint64
+}
+
+// Add records tx as sent at height
+func (s *SentTxs) Add(tx *types.Transaction, height uint64) {
+	s.Txs = append(s.Txs, tx)
+	s.At, s.Height = time.Now(), height
+}
+
+// Hashes returns the hashes of the txs
+func (s *SentTxs) Hashes() []common.Hash {
+	hashes := make([]common.Hash, len(s.Txs))
+	for i, tx := range s.Txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
+
+// Mined returns whichever of the txs is mined, and its receipt; it
+// returns ethereum.NotFound if none is
+func (s *SentTxs) Mined(ctx context.Context, c TxClient) (*types.Transaction, *types.Receipt, error) {
+	for i := len(s.Txs) - 1; i >= 0; i-- {
+		txr, err := c.TransactionReceipt(ctx, s.Txs[i].Hash())
+		switch {
+		case err == nil:
+			return s.Txs[i], txr, nil
+		case err != ethereum.NotFound:
+			return nil, nil, err
+		}
+	}
+	return nil, nil, ethereum.NotFound
+}
@@ -0,0 +1,93 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReplacer(t *testing.T, cfg string) *Replacer {
+	var rc ReplaceConfig
+	require.NoError(t, json.Unmarshal([]byte(cfg), &rc))
+	r, err := NewReplacer(&rc)
+	require.NoError(t, err)
+	return r
+}
+
+func TestReplacer(t *testing.T) {
+	r, err := NewReplacer(nil)
+	require.NoError(t, err)
+	assert.Nil(t, r, "disabled")
+	for _, cfg := range []string{`{"wait":"1"}`, `{"bump":1.05}`} {
+		var rc ReplaceConfig
+		require.NoError(t, json.Unmarshal([]byte(cfg), &rc))
+		_, err := NewReplacer(&rc)
+		assert.Error(t, err, cfg)
+	}
+
+	r = newTestReplacer(t, `{"wait":"1h","blocks":3}`)
+	sent := &SentTxs{}
+	sent.Add(types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(100), nil), 10)
+	assert.False(t, r.Due(sent, 20), "too soon")
+	sent.At = time.Now().Add(-time.Hour)
+	assert.False(t, r.Due(sent, 12), "too few blocks")
+	assert.True(t, r.Due(sent, 13))
+
+	r = newTestReplacer(t, `{"bump":1.5,"max_gas_price":200}`)
+	legacy := types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(100), nil)
+	fee, ok := r.Bump(legacy)
+	require.True(t, ok)
+	assert.Equal(t, "gasPrice=150", fee.String())
+	fee, ok = r.Bump(types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(150), nil))
+	require.True(t, ok)
+	assert.Equal(t, "gasPrice=200", fee.String(), "ceiling")
+	_, ok = r.Bump(types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(190), nil))
+	assert.False(t, ok, "less than 10% below the ceiling")
+
+	dynamic := types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(100)})
+	fee, ok = r.Bump(dynamic)
+	require.True(t, ok)
+	assert.Equal(t, "gasTipCap=15 gasFeeCap=150", fee.String())
+
+	// at least 10% more, rounded up
+	r = newTestReplacer(t, `{"bump":1.1}`)
+	fee, ok = r.Bump(types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(15), nil))
+	require.True(t, ok)
+	assert.Equal(t, "gasPrice=17", fee.String())
+}
+
+type fakeTxClient map[common.Hash]*types.Receipt
+
+func (c fakeTxClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if txr, ok := c[hash]; ok {
+		return txr, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func TestSentTxs_Mined(t *testing.T) {
+	sent := &SentTxs{}
+	for price := int64(1); price <= 3; price++ {
+		sent.Add(types.NewTransaction(0, common.Address{}, nil, 0, big.NewInt(price), nil), 1)
+	}
+	assert.Len(t, sent.Hashes(), 3)
+
+	c := fakeTxClient{}
+	_, _, err := sent.Mined(context.Background(), c)
+	assert.Equal(t, ethereum.NotFound, err)
+
+	// the original tx is mined, not its replacements
+	c[sent.Txs[0].Hash()] = &types.Receipt{Status: 1}
+	mined, txr, err := sent.Mined(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, sent.Txs[0], mined)
+	assert.EqualValues(t, 1, txr.Status)
+}
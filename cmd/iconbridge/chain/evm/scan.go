@@ -0,0 +1,86 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultLogRange is the default number of blocks of the ranges of a
+// LogScanner
+const DefaultLogRange = 5000
+
+// LogScanner ...
+// finds the blocks with messages of a BMC with eth_getLogs over ranges of
+// blocks, instead of looking into every block. The ranges adapt to the
+// limits of the endpoints on the number of blocks or logs of a query: they
+// are halved when a query fails, and grow back gradually upto their
+// initial size.
+type LogScanner struct {
+	parser *MessageParser
+	// size is the number of blocks of the next range, upto maxSize
+	size, maxSize uint64
+}
+
+// NewLogScanner returns the LogScanner of the messages of parser, over
+// ranges of upto size blocks, DefaultLogRange if zero
+func NewLogScanner(parser *MessageParser, size uint64) *LogScanner {
+	if size == 0 {
+		size = DefaultLogRange
+	}
+	return &LogScanner{parser: parser, size: size, maxSize: size}
+}
+
+// Size returns the number of blocks of the next range
+func (s *LogScanner) Size() uint64 {
+	return s.size
+}
+
+// MayHaveMessages tells if a block with the logs bloom may have messages,
+// see MessageParser.MayHaveMessages; to check that a block without
+// messages by eth_getLogs has none
+func (s *LogScanner) MayHaveMessages(bloom types.Bloom) bool {
+	return s.parser.MayHaveMessages(bloom)
+}
+
+// Scan ...
+// returns the heights of the blocks with messages from `from` upto `last`,
+// in order, where `last` is `to` or lower if the range is limited
+func (s *LogScanner) Scan(ctx context.Context, cl *Client, from, to uint64) (heights []uint64, last uint64, err error) {
+	last = to
+	if to-from >= s.size {
+		last = from + s.size - 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+	defer cancel()
+	logs, err := cl.Eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(last),
+		Addresses: []common.Address{s.parser.address},
+		Topics:    [][]common.Hash{{s.parser.topic}},
+	})
+	if err != nil {
+		if s.size > 1 {
+			s.size /= 2
+		}
+		return nil, 0, errors.Wrapf(err, "FilterLogs %v", err)
+	}
+	if last-from+1 == s.size && s.size < s.maxSize {
+		if s.size += s.size/8 + 1; s.size > s.maxSize {
+			s.size = s.maxSize
+		}
+	}
+	for _, log := range logs {
+		if log.Removed || log.BlockNumber < from || log.BlockNumber > last {
+			continue
+		}
+		if n := len(heights); n == 0 || heights[n-1] < log.BlockNumber {
+			heights = append(heights, log.BlockNumber)
+		}
+	}
+	return heights, last, nil
+}
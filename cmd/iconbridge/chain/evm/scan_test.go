@@ -0,0 +1,125 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogs serves eth_getLogs of the logs at heights, over ranges of upto
+// limit blocks
+type fakeLogs struct {
+	heights []uint64
+	limit   uint64
+	queries int
+}
+
+type fakeFilter struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+func (f *fakeLogs) GetLogs(ctx context.Context, filter fakeFilter) ([]types.Log, error) {
+	f.queries++
+	if uint64(filter.ToBlock-filter.FromBlock) >= f.limit {
+		return nil, fmt.Errorf("exceed maximum block range: %d", f.limit)
+	}
+	logs := []types.Log{}
+	for _, height := range f.heights {
+		if height >= uint64(filter.FromBlock) && height <= uint64(filter.ToBlock) {
+			// twice, as of two messages of a block
+			for i := 0; i < 2; i++ {
+				logs = append(logs, types.Log{BlockNumber: height, Topics: []common.Hash{{}}})
+			}
+		}
+	}
+	return logs, nil
+}
+
+func TestLogScanner_Scan(t *testing.T) {
+	fake := &fakeLogs{heights: []uint64{5, 150, 151, 420}, limit: 100}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", fake))
+	clrpc := rpc.DialInProc(server)
+	defer clrpc.Close()
+	cl := &Client{RPC: clrpc, Eth: ethclient.NewClient(clrpc)}
+
+	parser, err := NewMessageParser("0xB4fC4b3b4e3157448B7D279f06BC8e340d63e2a9")
+	require.NoError(t, err)
+	s := NewLogScanner(parser, 400)
+
+	ctx := context.Background()
+	_, _, err = s.Scan(ctx, cl, 0, 1000)
+	assert.Error(t, err)
+	assert.EqualValues(t, 200, s.Size(), "halved")
+	_, _, err = s.Scan(ctx, cl, 0, 1000)
+	assert.Error(t, err)
+
+	heights, last, err := s.Scan(ctx, cl, 0, 1000)
+	require.NoError(t, err)
+	assert.EqualValues(t, 99, last)
+	assert.Equal(t, []uint64{5}, heights)
+	assert.EqualValues(t, 113, s.Size(), "grows back")
+
+	var all []uint64
+	for from := uint64(0); from <= 1000; {
+		heights, last, err := s.Scan(ctx, cl, from, 1000)
+		if err != nil {
+			assert.Less(t, s.Size(), fake.limit+1)
+			continue
+		}
+		all = append(all, heights...)
+		from = last + 1
+	}
+	assert.Equal(t, fake.heights, all)
+
+	heights, last, err = s.Scan(ctx, cl, 420, 430)
+	require.NoError(t, err)
+	assert.EqualValues(t, 430, last, "upto to")
+	assert.Equal(t, []uint64{420}, heights)
+}
+
+// fakeHeaders serves eth_getBlockByNumber of the headers of the blocks
+// upto last
+type fakeHeaders struct {
+	last  uint64
+	calls int
+}
+
+func (f *fakeHeaders) GetBlockByNumber(ctx context.Context, number hexutil.Uint64, full bool) (*types.Header, error) {
+	f.calls++
+	if uint64(number) > f.last {
+		return nil, nil
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(uint64(number)), Difficulty: big.NewInt(2)}, nil
+}
+
+func TestClient_GetHeadersByRange(t *testing.T) {
+	fake := &fakeHeaders{last: 300}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", fake))
+	clrpc := rpc.DialInProc(server)
+	defer clrpc.Close()
+	cl := &Client{RPC: clrpc, Eth: ethclient.NewClient(clrpc)}
+
+	ctx := context.Background()
+	headers, err := cl.GetHeadersByRange(ctx, 10, 10+HeaderBatchSize+20)
+	require.NoError(t, err)
+	require.Len(t, headers, HeaderBatchSize+21)
+	for i, header := range headers {
+		assert.EqualValues(t, 10+i, header.Number.Uint64())
+	}
+	assert.Equal(t, HeaderBatchSize+21, fake.calls)
+
+	_, err = cl.GetHeadersByRange(ctx, 290, 310)
+	assert.Error(t, err, "blocks not found")
+}
@@ -0,0 +1,282 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/codec"
+	"github.com/icon-project/icon-bridge/common/intconv"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+)
+
+const (
+	txOverheadScale      = 0.01 // base64 encoding overhead 0.36, rlp and other fields 0.01
+	defaultSendTxTimeout = 15 * time.Second
+	maxGasPriceBoost     = 10.0
+)
+
+// SenderConfig ...
+// configures the sender of an EVM chain with its defaults, which the
+// sender options override
+type SenderConfig struct {
+	// TxMaxDataSize is the max size of the data of relay txs
+	TxMaxDataSize uint64
+	// GasLimit is the gas limit of relay txs unless the gas_limit option
+	// is set, raised upto 4 times the greater of both on
+	// ErrGasLimitExceeded
+	GasLimit uint64
+	// GasPrice is the gas price of the fixed fee mode, and of txs until a
+	// fee is known, both boosted by the boost_gas_price option
+	GasPrice int64
+	// FeeMode is the fee mode unless the fee option has one
+	FeeMode string
+}
+
+func (cfg *SenderConfig) txSizeLimit() uint64 {
+	return uint64(float64(cfg.TxMaxDataSize) / (1 + txOverheadScale))
+}
+
+// maxGasLimit returns the ceiling of the gas limit of relay txs of the
+// sender with opts
+func (cfg *SenderConfig) maxGasLimit(opts *SenderOptions) uint64 {
+	if opts.GasLimit > cfg.GasLimit {
+		return 4 * opts.GasLimit
+	}
+	return 4 * cfg.GasLimit
+}
+
+// SenderOptions are the options of the sender of an EVM chain
+type SenderOptions struct {
+	GasLimit         uint64         `json:"gas_limit"`
+	TxDataSizeLimit  uint64         `json:"tx_data_size_limit"`
+	BoostGasPrice    float64        `json:"boost_gas_price"`
+	BalanceThreshold intconv.BigInt `json:"balance_threshold"`
+	// Fee is the fee strategy of txs, the FeeMode of the chain by default
+	Fee *FeeConfig `json:"fee,omitempty"`
+	// Replace enables the replacement of stuck txs with higher fees
+	Replace *ReplaceConfig `json:"replace,omitempty"`
+}
+
+// ValidateOptions checks the options of NewSender, rejecting unknown keys
+func (cfg *SenderConfig) ValidateOptions(rawOpts json.RawMessage) error {
+	var opts SenderOptions
+	if err := chain.UnmarshalOptionsStrict(rawOpts, &opts); err != nil {
+		return err
+	}
+	if _, err := cfg.feeStrategy(&opts); err != nil {
+		return err
+	}
+	_, err := NewReplacer(opts.Replace)
+	return err
+}
+
+func (cfg *SenderConfig) feeStrategy(opts *SenderOptions) (FeeStrategy, error) {
+	var fc FeeConfig
+	if opts.Fee != nil {
+		fc = *opts.Fee
+	}
+	if fc.Mode == "" {
+		fc.Mode = cfg.FeeMode
+	}
+	if fc.Mode == FeeModeFixed && fc.GasPrice.Sign() == 0 && fc.GasFeeCap.Sign() == 0 {
+		fc.GasPrice.Set(cfg.gasPrice(opts))
+	}
+	return NewFeeStrategy(&fc, opts.BoostGasPrice)
+}
+
+// gasPrice returns the default gas price boosted by BoostGasPrice
+func (cfg *SenderConfig) gasPrice(opts *SenderOptions) *big.Int {
+	return boosted(big.NewInt(cfg.GasPrice), opts.BoostGasPrice)
+}
+
+type sender struct {
+	log      log.Logger
+	cfg      *SenderConfig
+	w        wallet.Wallet
+	src      chain.BTPAddress
+	dst      chain.BTPAddress
+	opts     SenderOptions
+	cls      []*Client
+	bmcs     []*BMC
+	fee      FeeStrategy
+	replacer *Replacer
+	// prevFee is the last fee of txs, used when the fee is unknown
+	prevFee *Fee
+	// nonces tracks the txs of the wallet pending on dst
+	nonces chain.NonceTracker
+}
+
+// NewSender returns the sender of relay txs to the BMC at dst, an EVM chain
+// configured by cfg
+func NewSender(
+	cfg *SenderConfig, src, dst chain.BTPAddress,
+	urls []string, w wallet.Wallet,
+	rawOpts json.RawMessage, l log.Logger) (chain.Sender, error) {
+	s := &sender{
+		log: l,
+		cfg: cfg,
+		w:   w,
+		src: src,
+		dst: dst,
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("empty urls: %v", urls)
+	}
+	if !common.IsHexAddress(w.Address()) {
+		return nil, fmt.Errorf("invalid wallet address: %s", w.Address())
+	}
+	err := json.Unmarshal(rawOpts, &s.opts)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unmarshal opt:%v err:%+v", rawOpts, err)
+	}
+	if s.opts.BoostGasPrice < 1.0 {
+		s.opts.BoostGasPrice = 1.0
+	}
+	if s.opts.BoostGasPrice > maxGasPriceBoost {
+		s.opts.BoostGasPrice = maxGasPriceBoost
+	}
+	if s.opts.TxDataSizeLimit == 0 {
+		s.opts.TxDataSizeLimit = cfg.txSizeLimit()
+	}
+	if s.opts.GasLimit == 0 {
+		s.opts.GasLimit = cfg.GasLimit
+	}
+	if s.fee, err = cfg.feeStrategy(&s.opts); err != nil {
+		return nil, err
+	}
+	if s.replacer, err = NewReplacer(s.opts.Replace); err != nil {
+		return nil, err
+	}
+	s.prevFee = &Fee{GasPrice: cfg.gasPrice(&s.opts)}
+	s.cls, s.bmcs, err = NewClients(urls, dst.ContractAddress(), s.log)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Endpoints implements chain.EndpointReporter
+func (s *sender) Endpoints() []chain.EndpointStatus {
+	return Endpoints(s.cls)
+}
+
+func (s *sender) jointClient() (*Client, *BMC) {
+	return Pick(s.cls, s.bmcs)
+}
+
+// Status ...
+// returns the BMCLinkStatus for "src" link
+func (s *sender) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	_, bmcCl := s.jointClient()
+	status, err := bmcCl.GetStatus(&bind.CallOpts{Context: ctx}, s.src.String())
+	if err != nil {
+		s.log.Error("GetStatus", "err", err)
+		return nil, err
+	}
+	ls := &chain.BMCLinkStatus{}
+	ls.TxSeq = status.TxSeq.Uint64()
+	ls.RxSeq = status.RxSeq.Uint64()
+	ls.RxHeight = status.RxHeight.Uint64()
+	ls.CurrentHeight = status.CurrentHeight.Uint64()
+	return ls, nil
+}
+
+// Segment ...
+// returns a relay tx of the receipts of msg upto the tx size limit, and
+// the rest of msg
+func (s *sender) Segment(
+	ctx context.Context, msg *chain.Message,
+) (tx chain.RelayTx, newMsg *chain.Message, err error) {
+	if ctx.Err() != nil {
+		return nil, msg, ctx.Err()
+	}
+	if len(msg.Receipts) == 0 {
+		return nil, msg, nil
+	}
+
+	rm := &chain.RelayMessage{
+		Receipts: make([][]byte, 0),
+	}
+
+	var msgSize uint64
+
+	newMsg = &chain.Message{
+		From:     msg.From,
+		Receipts: msg.Receipts,
+	}
+	for i, receipt := range msg.Receipts {
+		rlpEvents, err := codec.RLP.MarshalToBytes(receipt.Events)
+		if err != nil {
+			return nil, nil, err
+		}
+		rlpReceipt, err := codec.RLP.MarshalToBytes(&chain.RelayReceipt{
+			Index:  receipt.Index,
+			Height: receipt.Height,
+			Events: rlpEvents,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		newMsgSize := msgSize + uint64(len(rlpReceipt))
+		if newMsgSize > s.opts.TxDataSizeLimit {
+			newMsg.Receipts = msg.Receipts[i:]
+			break
+		}
+		msgSize = newMsgSize
+		rm.Receipts = append(rm.Receipts, rlpReceipt)
+	}
+	message, err := codec.RLP.MarshalToBytes(rm)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err = s.newRelayTx(ctx, msg.From.String(), message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, newMsg, nil
+}
+
+func (s *sender) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
+	cl, _ := s.jointClient()
+	bal, err := cl.GetBalance(ctx, s.w.Address())
+	return bal, &s.opts.BalanceThreshold.Int, err
+}
+
+func (s *sender) newRelayTx(ctx context.Context, prev string, message []byte) (*relayTx, error) {
+	client, bmcClient := s.jointClient()
+	txOpts, err := client.NewTransactOpts(s.w, s.opts.GasLimit)
+	if err != nil {
+		return nil, err
+	}
+	txOpts.Context = ctx
+	fee, err := s.fee.Fee(ctx, NewFeeClient(client.RPC))
+	if err != nil {
+		s.log.Infof("Fee Msg: %v. Using previous fee: %v", err, s.prevFee)
+		fee = s.prevFee
+	} else {
+		s.prevFee = fee
+		s.log.Infof("Fee: %v", fee)
+	}
+	fee.Apply(txOpts)
+	return &relayTx{
+		Prev:        prev,
+		Message:     message,
+		opts:        txOpts,
+		maxGasLimit: s.cfg.maxGasLimit(&s.opts),
+		cl:          client,
+		bmcCl:       bmcClient,
+		nonces:      &s.nonces,
+		replacer:    s.replacer,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderConfig_ValidateOptions(t *testing.T) {
+	cfg := &SenderConfig{TxMaxDataSize: 1024, GasLimit: 100, GasPrice: 1000, FeeMode: FeeModeFixed}
+	for _, tc := range []struct {
+		opts string
+		err  bool
+	}{
+		{opts: `{}`},
+		{opts: `{"gas_limit":10,"boost_gas_price":1.5,"fee":{"mode":"dynamic"},"replace":{"wait":"30s"}}`},
+		{opts: `{"gas_price":10}`, err: true},
+		{opts: `{"fee":{"mode":"auto"}}`, err: true},
+		{opts: `{"replace":{"bump":1.01}}`, err: true},
+	} {
+		err := cfg.ValidateOptions(json.RawMessage(tc.opts))
+		if tc.err {
+			assert.Error(t, err, tc.opts)
+		} else {
+			assert.NoError(t, err, tc.opts)
+		}
+	}
+}
+
+func TestSenderConfig_maxGasLimit(t *testing.T) {
+	cfg := &SenderConfig{GasLimit: 100}
+	assert.EqualValues(t, 400, cfg.maxGasLimit(&SenderOptions{GasLimit: 100}))
+	assert.EqualValues(t, 400, cfg.maxGasLimit(&SenderOptions{GasLimit: 50}), "below the default")
+	assert.EqualValues(t, 2000, cfg.maxGasLimit(&SenderOptions{GasLimit: 500}), "above 4 times the default")
+}
+
+func TestSenderConfig_feeStrategy(t *testing.T) {
+	cfg := &SenderConfig{GasPrice: 1000, FeeMode: FeeModeFixed}
+	for _, tc := range []struct {
+		opts string
+		fee  string
+	}{
+		{opts: `{}`, fee: "gasPrice=1000"},
+		{opts: `{"boost_gas_price":1.5}`, fee: "gasPrice=1500"},
+		{opts: `{"fee":{"gas_price":10}}`, fee: "gasPrice=10"},
+		{opts: `{"fee":{"mode":"fixed","gas_tip_cap":1,"gas_fee_cap":20}}`, fee: "gasTipCap=1 gasFeeCap=20"},
+	} {
+		var opts SenderOptions
+		require.NoError(t, json.Unmarshal([]byte(tc.opts), &opts), tc.opts)
+		fs, err := cfg.feeStrategy(&opts)
+		require.NoError(t, err, tc.opts)
+		fee, err := fs.Fee(context.Background(), nil)
+		require.NoError(t, err, tc.opts)
+		assert.Equal(t, tc.fee, fee.String(), tc.opts)
+	}
+
+	cfg.FeeMode = FeeModeLegacy
+	fs, err := cfg.feeStrategy(&SenderOptions{})
+	require.NoError(t, err)
+	assert.IsType(t, &legacyFee{}, fs)
+}
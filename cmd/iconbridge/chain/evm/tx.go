@@ -0,0 +1,241 @@
+package evm
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+type relayTx struct {
+	Prev    string `json:"_prev"`
+	Message []byte `json:"_msg"`
+
+	opts        *bind.TransactOpts
+	maxGasLimit uint64
+	pendingTx   *types.Transaction
+	gasUsed     uint64
+	cl          *Client
+	bmcCl       *BMC
+	nonces      *chain.NonceTracker
+	// sent are the txs sent with the nonce of the tx, replaced by
+	// replacer while they are stuck
+	sent     SentTxs
+	replacer *Replacer
+}
+
+func (tx *relayTx) ID() interface{} {
+	if tx.pendingTx != nil {
+		return tx.pendingTx.Hash()
+	}
+	return nil
+}
+
+func (tx *relayTx) GasUsed() uint64 {
+	return tx.gasUsed
+}
+
+// IncreaseGasLimit raises the gas limit by half, upto maxGasLimit
+func (tx *relayTx) IncreaseGasLimit() bool {
+	if tx.opts.GasLimit >= tx.maxGasLimit {
+		return false
+	}
+	tx.opts.GasLimit += tx.opts.GasLimit / 2
+	if tx.opts.GasLimit > tx.maxGasLimit {
+		tx.opts.GasLimit = tx.maxGasLimit
+	}
+	tx.pendingTx = nil
+	return true
+}
+
+func (tx *relayTx) Send(ctx context.Context) (err error) {
+	tx.cl.Log.WithFields(log.Fields{
+		"prev": tx.Prev}).Debug("handleRelayMessage: send tx")
+
+	_ctx, cancel := context.WithTimeout(ctx, defaultSendTxTimeout)
+	defer cancel()
+	txOpts := *tx.opts
+	txOpts.Context = _ctx
+	latest, err := tx.cl.Eth.NonceAt(ctx, txOpts.From, nil)
+	if err != nil {
+		return err
+	}
+	nonce := tx.nonces.Next(latest)
+	txOpts.Nonce = (&big.Int{}).SetUint64(nonce)
+	defer func() {
+		if tx.pendingTx != nil {
+			txBytes, _ := tx.pendingTx.MarshalJSON()
+			tx.cl.Log.WithFields(log.Fields{
+				"tx": string(txBytes)}).Debug("handleRelayMessage: tx sent")
+		}
+	}()
+	tx.pendingTx, err = tx.bmcCl.HandleRelayMessage(&txOpts, tx.Prev, tx.Message)
+	if err != nil {
+		tx.cl.Log.WithFields(log.Fields{
+			"error": err}).Debug("handleRelayMessage: send tx")
+		if err.Error() == "insufficient funds for gas * price + value" {
+			return chain.ErrInsufficientBalance
+		}
+		return err
+	}
+	tx.nonces.Sent(nonce)
+	tx.sent = SentTxs{}
+	tx.sent.Add(tx.pendingTx, tx.height(ctx))
+	return nil
+}
+
+func (tx *relayTx) Receipt(ctx context.Context) (blockHeight uint64, err error) {
+	if tx.pendingTx == nil {
+		return 0, fmt.Errorf("no pending tx")
+	}
+
+	var txr *types.Receipt
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Second)
+		var mined *types.Transaction
+		func() {
+			_ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+			defer cancel()
+			mined, txr, err = tx.sent.Mined(_ctx, tx.cl.Eth)
+		}()
+		if err != ethereum.NotFound {
+			if mined != nil {
+				tx.pendingTx = mined
+			}
+			break
+		}
+	}
+	if err == ethereum.NotFound {
+		err = tx.pending(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	tx.gasUsed = txr.GasUsed
+
+	if txr.Status == 0 {
+		if txr.GasUsed >= tx.pendingTx.Gas()*63/64 { // gas limit exceeded
+			if txr.GasUsed == txr.CumulativeGasUsed { // block gas limit exceeded
+				return 0, chain.ErrBlockGasLimitExceeded
+			}
+			return 0, chain.ErrGasLimitExceeded
+		}
+
+		callMsg := ethereum.CallMsg{
+			From:       tx.opts.From,
+			To:         tx.pendingTx.To(),
+			Gas:        tx.pendingTx.Gas(),
+			GasPrice:   tx.pendingTx.GasPrice(),
+			Value:      tx.pendingTx.Value(),
+			AccessList: tx.pendingTx.AccessList(),
+			Data:       tx.pendingTx.Data(),
+		}
+
+		_ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+		defer cancel()
+		data, err := tx.cl.Eth.CallContract(_ctx, callMsg, txr.BlockNumber)
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, chain.RevertError(RevertReason(data))
+	}
+
+	l := tx.cl.Log.WithFields(log.Fields{"txh": tx.pendingTx.Hash()})
+	if len(tx.sent.Txs) > 1 {
+		l = l.WithFields(log.Fields{"sent": tx.sent.Hashes()})
+	}
+	l.Debug("handleRelayMessage: success")
+
+	return txr.BlockNumber.Uint64(), nil
+}
+
+// pending checks the latest tx, which is not mined yet: it is replaced if
+// stuck, or its nonce is reused if dropped. It returns ethereum.NotFound
+// unless it fails.
+func (tx *relayTx) pending(ctx context.Context) error {
+	_ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+	defer cancel()
+	_, isPending, err := tx.cl.Eth.TransactionByHash(_ctx, tx.pendingTx.Hash())
+	switch {
+	case err == ethereum.NotFound:
+		// dropped, its nonce is to be reused
+		tx.nonces.Reset()
+		return err
+	case err != nil:
+		return err
+	case isPending && tx.replacer != nil:
+		if err := tx.replace(ctx); err != nil {
+			tx.cl.Log.WithFields(log.Fields{
+				"txh": tx.pendingTx.Hash(), "error": err}).Warn("handleRelayMessage: failed to replace tx")
+		}
+	}
+	return ethereum.NotFound
+}
+
+// replace sends the tx again with the same nonce and a higher fee, once it
+// has been pending for long enough
+func (tx *relayTx) replace(ctx context.Context) error {
+	_ctx, cancel := context.WithTimeout(ctx, defaultSendTxTimeout)
+	defer cancel()
+	height, err := tx.cl.Eth.BlockNumber(_ctx)
+	if err != nil {
+		return err
+	}
+	if !tx.replacer.Due(&tx.sent, height) {
+		return nil
+	}
+	fee, ok := tx.replacer.Bump(tx.pendingTx)
+	if !ok {
+		tx.cl.Log.WithFields(log.Fields{
+			"txh": tx.pendingTx.Hash()}).Debug("handleRelayMessage: replacement fee ceiling reached")
+		return nil
+	}
+	txOpts := *tx.opts
+	txOpts.Context = _ctx
+	txOpts.Nonce = new(big.Int).SetUint64(tx.pendingTx.Nonce())
+	txOpts.GasLimit = tx.pendingTx.Gas()
+	fee.Apply(&txOpts)
+	ntx, err := tx.bmcCl.HandleRelayMessage(&txOpts, tx.Prev, tx.Message)
+	if err != nil {
+		return err
+	}
+	tx.cl.Log.WithFields(log.Fields{
+		"txh": tx.pendingTx.Hash(), "replacement": ntx.Hash(), "fee": fee.String(),
+	}).Info("handleRelayMessage: replaced stuck tx")
+	tx.pendingTx = ntx
+	tx.sent.Add(ntx, height)
+	return nil
+}
+
+// height returns the latest block height to replace txs by, if they are
+// to be replaced
+func (tx *relayTx) height(ctx context.Context) uint64 {
+	if tx.replacer == nil {
+		return 0
+	}
+	_ctx, cancel := context.WithTimeout(ctx, DefaultReadTimeout)
+	defer cancel()
+	height, err := tx.cl.Eth.BlockNumber(_ctx)
+	if err != nil {
+		tx.cl.Log.WithFields(log.Fields{"error": err}).Debug("handleRelayMessage: failed to get block number")
+	}
+	return height
+}
+
+// RevertReason returns the reason of a revert from the data of the error
+func RevertReason(data []byte) string {
+	if len(data) < 4+32+32 {
+		return ""
+	}
+	data = data[4+32:] // ignore method and index
+	length := binary.BigEndian.Uint64(data[24:32])
+	return string(data[32 : 32+length])
+}
@@ -0,0 +1,17 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertReason(t *testing.T) {
+	str := "08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000002b" +
+		"526576657274496e76616c696452785365713a2065762e736571203e206578706563746564207278536571000000000000000000000000000000000000000000"
+	reason := RevertReason(common.Hex2Bytes(str))
+	require.Equal(t, "RevertInvalidRxSeq: ev.seq > expected rxSeq", reason, "revert reason should match")
+}
@@ -0,0 +1,116 @@
+//go:build hmny
+// +build hmny
+
+package hmny
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/icon-project/icon-bridge/common/errors"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// catchUp ...
+// forwards the blocks from next upto `to` to callback, finding those with
+// messages of the BMC by eth_getLogs over ranges of blocks instead of
+// fetching every block. Only the blocks with messages are fetched with
+// their receipts and verified, syncing vr, if any, to their epochs; the
+// others are forwarded without header nor receipts. It returns the height
+// of the next block to forward, short of `to` if it keeps failing to scan
+// logs
+func (r *receiver) catchUp(ctx context.Context, vr Verifier, next, to uint64, callback func(*BlockNotification) error) (uint64, error) {
+	r.log.WithFields(log.Fields{"height": next, "target": to}).Info("catchUp: start")
+	for retry := 0; next <= to && retry < RPCCallRetry; {
+		if ctx.Err() != nil {
+			return next, nil
+		}
+		heights, last, err := r.scanner.Scan(ctx, r.client().Client, next, to)
+		if err != nil {
+			retry++
+			r.log.WithFields(log.Fields{"height": next, "range": r.scanner.Size(), "error": err}).Warn(
+				"catchUp: failed to scan logs")
+			continue
+		}
+		retry = 0
+		hits := make(map[uint64]bool, len(heights))
+		for _, height := range heights {
+			hits[height] = true
+		}
+		for ; next <= last; next++ {
+			bn := &BlockNotification{Height: new(big.Int).SetUint64(next)}
+			if hits[next] {
+				if bn, err = r.fetchVerified(vr, next); err != nil {
+					return next, err
+				}
+			}
+			if err := callback(bn); err != nil {
+				return next, errors.Wrapf(err, "callback: %v", err)
+			}
+			atomic.StoreUint64(&r.verifiedHeight, next)
+		}
+		r.log.WithFields(log.Fields{"height": next, "target": to, "messages": len(heights)}).Debug("catchUp: catching up")
+	}
+	if vr != nil {
+		// the blocks from next on are verified with the committee of their
+		// epoch, past the epoch last blocks skipped
+		if err := r.client().syncVerifier(vr, next); err != nil {
+			return next, errors.Wrapf(err, "syncVerifier: %v", err)
+		}
+		r.saveVerifier(vr)
+	}
+	r.log.WithFields(log.Fields{"height": next}).Info("catchUp: complete")
+	return next, nil
+}
+
+// fetchVerified returns the notification of the block at height with its
+// receipts, verified by vr, if any, synced to its epoch
+func (r *receiver) fetchVerified(vr Verifier, height uint64) (bn *BlockNotification, err error) {
+	for retry := 0; ; retry++ {
+		if bn, err = r.fetchBlock(vr, height); err == nil || retry == RPCCallRetry {
+			return bn, err
+		}
+		r.log.WithFields(log.Fields{"height": height, "error": err}).Warn("fetchVerified: failed, retrying")
+	}
+}
+
+func (r *receiver) fetchBlock(vr Verifier, height uint64) (*BlockNotification, error) {
+	cl := r.client()
+	bn := &BlockNotification{Height: new(big.Int).SetUint64(height)}
+	if vr != nil {
+		if err := cl.syncVerifier(vr, height); err != nil {
+			return nil, errors.Wrapf(err, "syncVerifier: %v", err)
+		}
+		r.saveVerifier(vr)
+	}
+	header, err := cl.GetHmyV2HeaderByHeight(bn.Height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHmyHeaderByHeight: %v", err)
+	}
+	bn.Header, bn.Hash = header, header.Hash()
+	if bn.Receipts, err = cl.GetBlockReceipts(bn.Hash); err != nil {
+		return nil, errors.Wrapf(err, "GetBlockReceipts: %v", err)
+	}
+	if len(bn.Receipts) == 0 {
+		return nil, errors.Errorf("GetBlockReceipts: no receipts of block with messages: %d", height)
+	}
+	if err := verifyReceipts(header, bn.Receipts); err != nil {
+		return nil, errors.Wrapf(err, "GetBlockReceipts: %v", err)
+	}
+	if vr == nil {
+		return bn, nil
+	}
+	nextHeader, err := cl.GetHmyV2HeaderByHeight(new(big.Int).SetUint64(height + 1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetHmyHeaderByHeight: %v", err)
+	}
+	ok, err := vr.Verify(header, nextHeader.LastCommitBitmap, nextHeader.LastCommitSignature)
+	if err != nil {
+		return nil, errors.Wrapf(err, "vr.Verify: %v", err)
+	}
+	if !ok {
+		return nil, errors.Errorf("invalid header: signature validation failed: h=%d", height)
+	}
+	return bn, nil
+}
@@ -5,66 +5,52 @@ package hmny
 
 import (
 	"context"
-	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
 	"github.com/icon-project/icon-bridge/common/errors"
 	"github.com/icon-project/icon-bridge/common/log"
 )
 
 func NewClients(urls []string, l log.Logger) (cls []*Client, err error) {
-	for _, url := range urls {
-		clrpc, err := rpc.Dial(url)
+	pool := chain.SharedEndpointPool(urls)
+	for i := range urls {
+		cl, err := evm.DialEndpoint(pool.Endpoint(i), l)
 		if err != nil {
-			l.Errorf("failed to create hmny rpc client: url=%v, %v", url, err)
 			return nil, err
 		}
-		cleth := ethclient.NewClient(clrpc)
-		cls = append(cls, &Client{
-			log: l,
-			rpc: clrpc,
-			eth: cleth,
-			//bmc: clbmc,
-		})
+		cls = append(cls, &Client{Client: cl})
 	}
 	return cls, nil
 }
 
-func newClients(urls []string, bmc string, l log.Logger) (cls []*Client, bmcs []*BMC, err error) {
-	for _, url := range urls {
-		clrpc, err := rpc.Dial(url)
-		if err != nil {
-			l.Errorf("failed to create hmny rpc client: url=%v, %v", url, err)
-			return nil, nil, err
-		}
-		cleth := ethclient.NewClient(clrpc)
-		clbmc, err := NewBMC(common.HexToAddress(bmc), cleth)
-		if err != nil {
-			l.Errorf("failed to create bmc binding to hmny ethclient: url=%v, %v", url, err)
-			return nil, nil, err
-		}
-		bmcs = append(bmcs, clbmc)
-		cls = append(cls, &Client{
-			log: l,
-			rpc: clrpc,
-			eth: cleth,
-			//bmc: clbmc,
-		})
+func newClients(urls []string, bmc string, l log.Logger) (cls []*Client, bmcs []*evm.BMC, err error) {
+	ecls, bmcs, err := evm.NewClients(urls, bmc, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, cl := range ecls {
+		cls = append(cls, &Client{Client: cl})
 	}
 	return cls, bmcs, nil
 }
 
-// grouped rpc api clients
+// pickClient returns a client of cls by chain.PickEndpoint
+func pickClient(cls []*Client) *Client {
+	endpoints := make([]*chain.Endpoint, len(cls))
+	for i, cl := range cls {
+		endpoints[i] = cl.Endpoint
+	}
+	return cls[chain.PickEndpoint(endpoints)]
+}
+
+// grouped rpc api clients, with the harmony api besides the ethereum one
 type Client struct {
-	log log.Logger
-	rpc *rpc.Client
-	eth *ethclient.Client
-	//bmc *BMC
+	*evm.Client
 }
 
 func (cl *Client) newVerifier(opts *VerifierOptions) (Verifier, error) {
@@ -107,7 +93,7 @@ func (cl *Client) syncVerifier(vr Verifier, height uint64) (err error) {
 	if err != nil {
 		return err
 	}
-	cl.log.WithFields(log.Fields{"epoch": vr.Epoch()}).Debugf("syncVerifier: start")
+	cl.Log.WithFields(log.Fields{"epoch": vr.Epoch()}).Debugf("syncVerifier: start")
 	for epoch := vr.Epoch(); epoch < h.Epoch.Uint64(); epoch++ {
 		elb, err := cl.GetEpochLastBlock((&big.Int{}).SetUint64(epoch))
 		if err != nil {
@@ -128,40 +114,17 @@ func (cl *Client) syncVerifier(vr Verifier, height uint64) (err error) {
 		if err = vr.Update(elh); err != nil {
 			return errors.Wrapf(err, "vr.Update: %v", err)
 		}
-		cl.log.WithFields(log.Fields{
+		cl.Log.WithFields(log.Fields{
 			"epoch": vr.Epoch(), "height": elb.Uint64()}).Debugf("syncVerifier: syncing")
 	}
-	cl.log.WithFields(log.Fields{"epoch": vr.Epoch()}).Debugf("syncVerifier: complete")
+	cl.Log.WithFields(log.Fields{"epoch": vr.Epoch()}).Debugf("syncVerifier: complete")
 	return nil
 }
 
-func (cl *Client) GetChainID() (*big.Int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
-	defer cancel()
-	return cl.eth.ChainID(ctx)
-}
-
-func (cl *Client) GetBalance(ctx context.Context, hexAddr string) (*big.Int, error) {
-	if !common.IsHexAddress(hexAddr) {
-		return nil, fmt.Errorf("invalid hex address: %v", hexAddr)
-	}
-	return cl.eth.BalanceAt(ctx, common.HexToAddress(hexAddr), nil)
-}
-
-func (cl *Client) GetBlockNumber() (uint64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
-	defer cancel()
-	bn, err := cl.eth.BlockNumber(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return bn, nil
-}
-
 func (cl *Client) GetTransaction(hash common.Hash) (*ethtypes.Transaction, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
-	tx, pending, err := cl.eth.TransactionByHash(ctx, hash)
+	tx, pending, err := cl.Eth.TransactionByHash(ctx, hash)
 	if err != nil {
 		return nil, pending, err
 	}
@@ -172,7 +135,7 @@ func (cl *Client) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	tr := new(types.Receipt)
-	err := cl.rpc.CallContext(ctx, tr, "hmy_getTransactionReceipt", hash)
+	err := cl.RPC.CallContext(ctx, tr, "hmy_getTransactionReceipt", hash)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +146,7 @@ func (cl *Client) GetEpochLastBlock(epoch *big.Int) (*big.Int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	lbn := big.NewInt(0)
-	err := cl.rpc.CallContext(ctx, lbn, "hmyv2_epochLastBlock", epoch)
+	err := cl.RPC.CallContext(ctx, lbn, "hmyv2_epochLastBlock", epoch)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +157,7 @@ func (cl *Client) GetHmyBlockByHeight(height *big.Int) (*BlockWithTxHash, error)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	hb := new(BlockWithTxHash)
-	err := cl.rpc.CallContext(ctx, hb, "hmy_getBlockByNumber", height, false)
+	err := cl.RPC.CallContext(ctx, hb, "hmy_getBlockByNumber", height, false)
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +168,7 @@ func (cl *Client) GetHmyV2BlockByHeight(height *big.Int) (*BlockV2WithTxHash, er
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	hb := new(BlockV2WithTxHash)
-	err := cl.rpc.CallContext(ctx, hb, "hmyv2_getBlockByNumber", height, map[string]interface{}{})
+	err := cl.RPC.CallContext(ctx, hb, "hmyv2_getBlockByNumber", height, map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +179,7 @@ func (cl *Client) GetHmyBlockByHash(hash common.Hash) (*BlockWithTxHash, error)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	hb := new(BlockWithTxHash)
-	err := cl.rpc.CallContext(ctx, hb, "hmy_getBlockByHash", hash, false)
+	err := cl.RPC.CallContext(ctx, hb, "hmy_getBlockByHash", hash, false)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +190,7 @@ func (cl *Client) GetHmyV2BlockByHash(hash common.Hash) (*BlockV2WithTxHash, err
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	hb := new(BlockV2WithTxHash)
-	err := cl.rpc.CallContext(ctx, hb, "hmyv2_getBlockByHash", hash, map[string]interface{}{"inclStaking": true})
+	err := cl.RPC.CallContext(ctx, hb, "hmyv2_getBlockByHash", hash, map[string]interface{}{"inclStaking": true})
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +201,7 @@ func (cl *Client) GetHmyV2HeaderByHeight(height *big.Int) (*Header, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	hb := new(Header)
-	err := cl.rpc.CallContext(ctx, hb, "hmyv2_getFullHeader", height)
+	err := cl.RPC.CallContext(ctx, hb, "hmyv2_getFullHeader", height)
 	if err != nil {
 		return nil, err
 	}
@@ -257,7 +220,7 @@ func (cl *Client) getHmyBlockReceipts(hash common.Hash) (types.Receipts, error)
 	ctx, cancel := context.WithTimeout(context.Background(), defaultReadTimeout)
 	defer cancel()
 	receipts := make([]*types.Receipt, 0)
-	err := cl.rpc.CallContext(ctx, &receipts, "hmy_getBlockReceipts", hash)
+	err := cl.RPC.CallContext(ctx, &receipts, "hmy_getBlockReceipts", hash)
 	if err != nil {
 		return nil, err
 	}
@@ -307,7 +270,7 @@ func (cl *Client) getHmyTxnReceiptsByBlockHash(hash common.Hash) (types.Receipts
 				if q.v == nil {
 					q.v = &types.Receipt{}
 				}
-				q.err = cl.rpc.CallContext(ctx, q.v, "hmy_getTransactionReceipt", q.txh)
+				q.err = cl.RPC.CallContext(ctx, q.v, "hmy_getTransactionReceipt", q.txh)
 				if q.err != nil {
 					q.err = errors.Wrapf(q.err, "hmy_getTransactionReceipt: %v", q.err)
 				}
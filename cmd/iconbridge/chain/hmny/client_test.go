@@ -17,13 +17,14 @@ import (
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
 	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/stretchr/testify/require"
 )
 
 const URL = "https://rpc.s0.b.hmny.io"
 
-func newTestClient(t *testing.T, url string) (*Client, *BMC) {
+func newTestClient(t *testing.T, url string) (*Client, *evm.BMC) {
 	cls, bmcs, err := newClients([]string{url}, "", log.New())
 	require.NoError(t, err)
 	return cls[0], bmcs[0]
@@ -39,12 +40,12 @@ func TestGetTransactionRevertReason(t *testing.T) {
 
 	ctx, cancel := getDefaultContext()
 	defer cancel()
-	tx, _, err := cl.eth.TransactionByHash(ctx, txh)
+	tx, _, err := cl.Eth.TransactionByHash(ctx, txh)
 	require.NoError(t, err)
 
 	ctx, cancel = getDefaultContext()
 	defer cancel()
-	txr, err := cl.eth.TransactionReceipt(ctx, txh)
+	txr, err := cl.Eth.TransactionReceipt(ctx, txh)
 	require.NoError(t, err)
 
 	if txr.Status == 0 {
@@ -60,22 +61,13 @@ func TestGetTransactionRevertReason(t *testing.T) {
 
 		ctx, cancel = getDefaultContext()
 		defer cancel()
-		data, _ := cl.eth.CallContract(ctx, callMsg, txr.BlockNumber)
+		data, _ := cl.Eth.CallContract(ctx, callMsg, txr.BlockNumber)
 		//require.NoError(t, err)
 
-		t.Logf("revert reason: %v", revertReason(data))
+		t.Logf("revert reason: %v", evm.RevertReason(data))
 	}
 }
 
-func TestRevertReason(t *testing.T) {
-	str := "08c379a0" +
-		"0000000000000000000000000000000000000000000000000000000000000020" +
-		"000000000000000000000000000000000000000000000000000000000000002b" +
-		"526576657274496e76616c696452785365713a2065762e736571203e206578706563746564207278536571000000000000000000000000000000000000000000"
-	reason := revertReason(common.Hex2Bytes(str))
-	require.Equal(t, "RevertInvalidRxSeq: ev.seq > expected rxSeq", reason, "revert reason should match")
-}
-
 func TestBlockAndHeaderHashMatch(t *testing.T) {
 	n := int64(1000000) // block number
 	cl, _ := newTestClient(t, URL)
@@ -155,7 +147,7 @@ func TestBMCMessageDecode(t *testing.T) {
 		require.Equal(t, "btp://0x7.icon/cx9e5c0a749ee94c01febe04702184002a76a84f84", msg.Next)
 
 		fmt.Println(common.Bytes2Hex(msg.Msg))
-		var bmcMsg TypesBMCMessage
+		var bmcMsg evm.TypesBMCMessage
 		err = rlp.DecodeBytes(msg.Msg, &bmcMsg)
 		require.NoError(t, err, "failed to decode rlp into underlying bmc message")
 
@@ -164,7 +156,7 @@ func TestBMCMessageDecode(t *testing.T) {
 		require.Equal(t, "WonderlandTokenSaleService", bmcMsg.Svc)
 		require.Equal(t, uint64(2), bmcMsg.Sn.Uint64())
 
-		var svcMsg TypesBMCService
+		var svcMsg evm.TypesBMCService
 		err = rlp.DecodeBytes(bmcMsg.Message, &svcMsg)
 		require.NoError(t, err, "failed to decode rlp into underlying bmc service")
 
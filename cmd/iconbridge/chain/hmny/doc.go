@@ -0,0 +1,4 @@
+// Package hmny is the Harmony chain of the relay, with the sender and
+// client pool of package evm. Its files are built with the "hmny" tag
+// only, so that the package is empty without it.
+package hmny
@@ -9,16 +9,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain/evm"
+	"github.com/icon-project/icon-bridge/common/db"
 	"github.com/icon-project/icon-bridge/common/errors"
 	"github.com/icon-project/icon-bridge/common/log"
 )
@@ -29,6 +30,9 @@ const (
 	defaultReadTimeout         = 15 * time.Second
 	monitorBlockMaxConcurrency = 1000 // number of concurrent requests to synchronize older blocks from source chain
 	RPCCallRetry               = 3
+	// CatchUpMargin is the number of the latest blocks the receiver
+	// fetches one by one, rather than catching up with by log ranges
+	CatchUpMargin = 100
 )
 
 func NewReceiver(
@@ -46,7 +50,18 @@ func NewReceiver(
 	if err != nil {
 		return nil, err
 	}
-	r.cls, r.bmcs, err = newClients(urls, src.ContractAddress(), r.log)
+	r.cls, _, err = newClients(urls, src.ContractAddress(), r.log)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.LogRange > 0 {
+		parser, err := evm.NewMessageParser(src.ContractAddress())
+		if err != nil {
+			return nil, err
+		}
+		r.scanner = evm.NewLogScanner(parser, r.opts.LogRange)
+	}
+	r.Receiver, err = evm.NewReceiver(src, dst, r, r.log)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +71,25 @@ func NewReceiver(
 type ReceiverOptions struct {
 	Verifier        *VerifierOptions `json:"verifier"`
 	SyncConcurrency uint64           `json:"syncConcurrency"`
+	// LogRange, if set, is the number of blocks of the eth_getLogs ranges
+	// the receiver catches up with when it is far behind, instead of
+	// fetching every block. The blocks with messages are verified, but the
+	// endpoints are trusted not to leave any out of eth_getLogs.
+	LogRange uint64 `json:"logRange,omitempty"`
+}
+
+// ValidateReceiverOptions ...
+// checks the options of NewReceiver, rejecting unknown keys; the receiver
+// doesn't verify blocks without verifier options
+func ValidateReceiverOptions(rawOpts json.RawMessage) error {
+	opts := &ReceiverOptions{}
+	if err := chain.UnmarshalOptionsStrict(rawOpts, opts); err != nil {
+		return err
+	}
+	if opts.Verifier == nil {
+		return fmt.Errorf("missing verifier")
+	}
+	return nil
 }
 
 func (opts *ReceiverOptions) Unmarshal(v map[string]interface{}) error {
@@ -67,22 +101,119 @@ func (opts *ReceiverOptions) Unmarshal(v map[string]interface{}) error {
 }
 
 type receiver struct {
+	*evm.Receiver
+
+	// latestHeight and verifiedHeight are accessed atomically
+	latestHeight   uint64
+	verifiedHeight uint64
+
 	log  log.Logger
 	src  chain.BTPAddress
 	dst  chain.BTPAddress
 	opts ReceiverOptions
 	cls  []*Client
-	bmcs []*BMC
+	// scanner finds the blocks with messages when catching up, if set
+	scanner *evm.LogScanner
+
+	// store persists verifier snapshots, if set
+	store     db.Bucket
+	snapEpoch uint64
+}
+
+func (r *receiver) SetStateStore(store db.Bucket) {
+	r.store = store
+}
+
+func (r *receiver) ReceiverStatus() *chain.ReceiverStatus {
+	return &chain.ReceiverStatus{
+		Height:         atomic.LoadUint64(&r.latestHeight),
+		VerifiedHeight: atomic.LoadUint64(&r.verifiedHeight),
+	}
+}
+
+// loadVerifier ...
+// restores the verifier from its latest snapshot if it is usable to verify
+// blocks from `height`, otherwise creates it from the config anchor `opts`
+func (r *receiver) loadVerifier(opts *VerifierOptions, height uint64) (Verifier, error) {
+	if vr, err := r.restoreVerifier(opts, height); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn(
+			"verifier snapshot: discarded, falling back to config")
+	} else if vr != nil {
+		return vr, nil
+	}
+	vr, err := r.client().newVerifier(opts)
+	if err != nil {
+		return nil, err
+	}
+	r.snapEpoch = vr.Epoch() // nothing to save until it advances
+	return vr, nil
+}
+
+func (r *receiver) restoreVerifier(opts *VerifierOptions, height uint64) (Verifier, error) {
+	if r.store == nil {
+		return nil, nil
+	}
+	var ss VerifierSnapshot
+	if ok, err := chain.LoadSnapshot(r.store, chain.VerifierSnapshotKey, &ss); err != nil || !ok {
+		return nil, err
+	}
+	if ss.Height <= opts.BlockHeight || ss.Height >= height {
+		r.log.WithFields(log.Fields{"epoch": ss.Epoch, "height": ss.Height}).Debug(
+			"verifier snapshot: not usable")
+		return nil, nil
+	}
+	vr, err := NewVerifierFromSnapshot(&ss)
+	if err != nil {
+		return nil, err
+	}
+
+	// cross check with the first block of the epoch and its commit signature
+	cl := r.client()
+	h, err := cl.GetHmyV2HeaderByHeight(new(big.Int).SetUint64(ss.Height + 1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cl.GetHeaderByHeight(%d): %v", ss.Height+1, err)
+	}
+	if h.Epoch.Uint64() != ss.Epoch {
+		return nil, fmt.Errorf("unexpected epoch(%d): got %d, expected %d", ss.Height+1, h.Epoch.Uint64(), ss.Epoch)
+	}
+	x, err := cl.GetHmyV2HeaderByHeight(new(big.Int).SetUint64(ss.Height + 2))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cl.GetHeaderByHeight(%d): %v", ss.Height+2, err)
+	}
+	ok, err := vr.Verify(h, x.LastCommitBitmap, x.LastCommitSignature)
+	if !ok || err != nil {
+		return nil, errors.Wrapf(err, "invalid signature: %v", err)
+	}
+
+	r.log.WithFields(log.Fields{"epoch": ss.Epoch, "height": ss.Height}).Info("verifier snapshot: restored")
+	r.snapEpoch = ss.Epoch
+	return vr, nil
+}
+
+// saveVerifier persists the state of the verifier if it has advanced
+func (r *receiver) saveVerifier(vr Verifier) {
+	if r.store == nil || vr.Epoch() == r.snapEpoch {
+		return
+	}
+	ss := vr.Snapshot()
+	if err := chain.SaveSnapshot(r.store, chain.VerifierSnapshotKey, ss); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("verifier snapshot: failed to save")
+		return
+	}
+	r.snapEpoch = ss.Epoch
 }
 
 func (r *receiver) client() *Client {
-	randInt := rand.Intn(len(r.cls))
-	return r.cls[randInt]
+	return pickClient(r.cls)
 }
 
-func (r *receiver) bmcClient() *BMC {
-	randInt := rand.Intn(len(r.cls))
-	return r.bmcs[randInt]
+// Endpoints implements chain.EndpointReporter
+func (r *receiver) Endpoints() []chain.EndpointStatus {
+	ecls := make([]*evm.Client, 0, len(r.cls))
+	for _, cl := range r.cls {
+		ecls = append(ecls, cl.Client)
+	}
+	return evm.Endpoints(ecls)
 }
 
 func (r *receiver) rpcConsensusCall(
@@ -97,7 +228,7 @@ func (r *receiver) rpcConsensusCall(
 
 	if threshold == 0 {
 		val := valfn()
-		err := r.client().rpc.CallContext(ctx, val, method, args...)
+		err := r.client().RPC.CallContext(ctx, val, method, args...)
 		if err != nil {
 			return nil, err
 		}
@@ -117,7 +248,7 @@ func (r *receiver) rpcConsensusCall(
 			}
 			ech <- err
 			vch <- val
-		}(caller.rpc)
+		}(caller.RPC)
 	}
 	counts := make(map[interface{}]int, total)
 	lookup := make(map[interface{}]interface{}, total)
@@ -178,7 +309,7 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 	var vr Verifier
 	if opts.VerifierOptions != nil {
 		var err error
-		vr, err = r.client().newVerifier(opts.VerifierOptions)
+		vr, err = r.loadVerifier(opts.VerifierOptions, opts.StartHeight)
 		if err != nil {
 			return errors.Wrapf(err, "receiveLoop: NewVerifier: %v", err)
 		}
@@ -186,6 +317,7 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 		if err != nil {
 			return errors.Wrapf(err, "receiveLoop: cl.syncVerifier: %v", err)
 		}
+		r.saveVerifier(vr)
 	}
 
 	// block notification channel
@@ -209,6 +341,14 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 	}
 
 	next, latest := opts.StartHeight, latestHeight()
+	atomic.StoreUint64(&r.latestHeight, latest)
+
+	if r.scanner != nil && next+CatchUpMargin < latest {
+		var err error
+		if next, err = r.catchUp(ctx, vr, next, latest-CatchUpMargin, callback); err != nil {
+			return errors.Wrapf(err, "receiveLoop: catchUp: %v", err)
+		}
+	}
 
 	// last unverified block notification
 	var lbn *BlockNotification
@@ -225,6 +365,7 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 		case <-heightPoller.C:
 			if height := latestHeight(); height > 0 {
 				latest = height
+				atomic.StoreUint64(&r.latestHeight, latest)
 				r.log.WithFields(log.Fields{"latest": latest, "next": next}).Debug("poll height")
 			}
 
@@ -246,10 +387,12 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 						if err := vr.Update(lbn.Header); err != nil {
 							return errors.Wrapf(err, "receiveLoop: update verifier: %v", err)
 						}
+						r.saveVerifier(vr)
 					}
 					if err := callback(lbn); err != nil {
 						return errors.Wrapf(err, "receiveLoop: callback: %v", err)
 					}
+					atomic.StoreUint64(&r.verifiedHeight, lbn.Height.Uint64())
 				}
 				if lbn, bn = bn, nil; len(bnch) > 0 {
 					bn = <-bnch
@@ -323,12 +466,7 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 						if q.v.Header.GasUsed > 0 {
 							q.v.Receipts, q.err = r.client().GetBlockReceipts(q.v.Hash)
 							if q.err == nil {
-								receiptsRoot := types.DeriveSha(q.v.Receipts)
-								if !bytes.Equal(receiptsRoot.Bytes(), q.v.Header.ReceiptsRoot.Bytes()) {
-									q.err = fmt.Errorf(
-										"invalid receipts: remote=%v, local=%v",
-										q.v.Header.ReceiptsRoot, receiptsRoot)
-								}
+								q.err = verifyReceipts(q.v.Header, q.v.Receipts)
 							}
 							if q.err != nil {
 								q.err = errors.Wrapf(q.err, "GetBlockReceipts: %v", q.err)
@@ -360,91 +498,43 @@ func (r *receiver) receiveLoop(ctx context.Context, opts *BnOptions, callback fu
 	}
 }
 
-func (r *receiver) getRelayReceipts(v *BlockNotification) []*chain.Receipt {
-	sc := common.HexToAddress(r.src.ContractAddress())
-	var receipts []*chain.Receipt
-	var events []*chain.Event
-	for i, receipt := range v.Receipts {
-		events := events[:0]
-		for _, log := range receipt.Logs {
-			if !bytes.Equal(log.Address.Bytes(), sc.Bytes()) {
-				continue
-			}
-			msg, err := r.bmcClient().ParseMessage(ethtypes.Log{
-				Data: log.Data, Topics: log.Topics,
+// ReceiveBlocks implements evm.BlockSource with the blocks verified by
+// receiveLoop
+func (r *receiver) ReceiveBlocks(ctx context.Context, height uint64, cb func(*evm.VerifiedBlock) error) error {
+	return r.receiveLoop(ctx,
+		&BnOptions{
+			StartHeight:     height,
+			VerifierOptions: r.opts.Verifier,
+			Concurrency:     r.opts.SyncConcurrency,
+		},
+		func(v *BlockNotification) error {
+			return cb(&evm.VerifiedBlock{
+				Height: v.Height.Uint64(),
+				Logs:   receiptLogs(v.Receipts),
 			})
-			if err == nil {
-				events = append(events, &chain.Event{
-					Next:     chain.BTPAddress(msg.Next),
-					Sequence: msg.Seq.Uint64(),
-					Message:  msg.Msg,
-				})
-			}
-		}
-		if len(events) > 0 {
-			rp := &chain.Receipt{}
-			rp.Index, rp.Height = uint64(i), v.Height.Uint64()
-			rp.Events = append(rp.Events, events...)
-			receipts = append(receipts, rp)
-		}
-	}
-	return receipts
+		})
 }
 
-func (r *receiver) Subscribe(
-	ctx context.Context, msgCh chan<- *chain.Message,
-	opts chain.SubscribeOptions) (errCh <-chan error, err error) {
-
-	opts.Seq++
-
-	_errCh := make(chan error)
-
-	go func() {
-		defer close(_errCh)
-		lastHeight := opts.Height - 1
-		if err := r.receiveLoop(ctx,
-			&BnOptions{
-				StartHeight:     opts.Height,
-				VerifierOptions: r.opts.Verifier,
-				Concurrency:     r.opts.SyncConcurrency,
-			},
-			func(v *BlockNotification) error {
-				r.log.WithFields(log.Fields{"height": v.Height}).Debug("block notification")
-
-				if v.Height.Uint64() != lastHeight+1 {
-					r.log.Errorf("expected v.Height == %d, got %d", lastHeight+1, v.Height.Uint64())
-					return fmt.Errorf(
-						"block notification: expected=%d, got=%d",
-						lastHeight+1, v.Height.Uint64())
-				}
+// verifyReceipts checks receipts against the receipts root of header
+func verifyReceipts(header *Header, receipts types.Receipts) error {
+	receiptsRoot := types.DeriveSha(receipts)
+	if !bytes.Equal(receiptsRoot.Bytes(), header.ReceiptsRoot.Bytes()) {
+		return fmt.Errorf(
+			"invalid receipts: remote=%v, local=%v",
+			header.ReceiptsRoot, receiptsRoot)
+	}
+	return nil
+}
 
-				receipts := r.getRelayReceipts(v)
-				for _, receipt := range receipts {
-					events := receipt.Events[:0]
-					for _, event := range receipt.Events {
-						switch {
-						case event.Sequence == opts.Seq:
-							events = append(events, event)
-							opts.Seq++
-						case event.Sequence > opts.Seq:
-							r.log.WithFields(log.Fields{
-								"seq": log.Fields{"got": event.Sequence, "expected": opts.Seq},
-							}).Error("invalid event seq")
-							return fmt.Errorf("invalid event seq")
-						}
-					}
-					receipt.Events = events
-				}
-				if len(receipts) > 0 {
-					msgCh <- &chain.Message{Receipts: receipts}
-				}
-				lastHeight++
-				return nil
-			}); err != nil {
-			r.log.Errorf("receiveLoop terminated: %v", err)
-			_errCh <- err
+// receiptLogs returns the logs of each of receipts as ethereum logs
+func receiptLogs(receipts types.Receipts) [][]*ethtypes.Log {
+	logs := make([][]*ethtypes.Log, len(receipts))
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			logs[i] = append(logs[i], &ethtypes.Log{
+				Address: log.Address, Topics: log.Topics, Data: log.Data,
+			})
 		}
-	}()
-
-	return _errCh, nil
+	}
+	return logs
 }
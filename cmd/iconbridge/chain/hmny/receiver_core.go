@@ -8,7 +8,6 @@ import (
 	"context"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"sort"
 	"strings"
 	"time"
@@ -25,8 +24,7 @@ type ReceiverCore struct {
 }
 
 func (r *ReceiverCore) client() *Client {
-	randInt := rand.Intn(len(r.Cls))
-	return r.Cls[randInt]
+	return pickClient(r.Cls)
 }
 
 func (r *ReceiverCore) ReceiveLoop(ctx context.Context, opts *BnOptions, callback func(v *BlockNotification) error) error {
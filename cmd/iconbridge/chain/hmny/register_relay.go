@@ -8,4 +8,6 @@ import "github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
 func init() {
 	relay.Senders["hmny"] = NewSender
 	relay.Receivers["hmny"] = NewReceiver
+	relay.SenderOptionValidators["hmny"] = ValidateSenderOptions
+	relay.ReceiverOptionValidators["hmny"] = ValidateReceiverOptions
 }
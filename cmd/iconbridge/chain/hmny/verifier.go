@@ -32,20 +32,69 @@ type Verifier interface {
 	Epoch() uint64
 	Verify(h *Header, bitmap, signature []byte) (ok bool, err error)
 	Update(h *Header) (err error)
+	Snapshot() *VerifierSnapshot
+}
+
+// VerifierSnapshot ...
+// is the trusted state of a Verifier: the committee of each shard for
+// the epoch that starts after the block at Height
+type VerifierSnapshot struct {
+	Height uint64                     `json:"height"`
+	Epoch  uint64                     `json:"epoch"`
+	Shards map[uint32][]hexutil.Bytes `json:"shards"`
 }
 
 func NewVerifier() Verifier {
 	return &verifier{}
 }
 
+// NewVerifierFromSnapshot restores a Verifier from its snapshot
+func NewVerifierFromSnapshot(ss *VerifierSnapshot) (Verifier, error) {
+	spks := make(map[uint32][]bls.SerializedPublicKey, len(ss.Shards))
+	for sid, keys := range ss.Shards {
+		pks := make([]bls.SerializedPublicKey, len(keys))
+		for i, key := range keys {
+			if len(key) != len(pks[i]) {
+				return nil, fmt.Errorf("invalid bls public key: shard=%d, index=%d", sid, i)
+			}
+			copy(pks[i][:], key)
+		}
+		spks[sid] = pks
+	}
+	smsk, err := newShardMasks(spks)
+	if err != nil {
+		return nil, err
+	}
+	return &verifier{height: ss.Height, epoch: ss.Epoch, smsk: smsk}, nil
+}
+
 type verifier struct {
-	epoch uint64
-	mu    sync.RWMutex
-	smsk  map[uint32]*bls.Mask
+	height uint64 // of the last header whose shard state was applied
+	epoch  uint64
+	mu     sync.RWMutex
+	smsk   map[uint32]*bls.Mask
 }
 
 func (vr *verifier) Epoch() uint64 { return vr.epoch }
 
+func (vr *verifier) Snapshot() *VerifierSnapshot {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	ss := &VerifierSnapshot{
+		Height: vr.height,
+		Epoch:  vr.epoch,
+		Shards: make(map[uint32][]hexutil.Bytes, len(vr.smsk)),
+	}
+	for sid, mask := range vr.smsk {
+		keys := make([]hexutil.Bytes, len(mask.Publics))
+		for i, pub := range mask.Publics {
+			keys[i] = append(hexutil.Bytes{}, pub.Bytes[:]...)
+		}
+		ss.Shards[sid] = keys
+	}
+	return ss
+}
+
 func (vr *verifier) Verify(h *Header, bitmap, signature []byte) (bool, error) {
 	vr.mu.RLock()
 	msk, ok := vr.smsk[h.ShardID]
@@ -102,26 +151,34 @@ func (vr *verifier) Update(h *Header) (err error) {
 		epoch = h.Epoch.Uint64() + 1
 	}
 
-	smsk := make(map[uint32]*bls.Mask)
+	smsk, err := newShardMasks(spks)
+	if err != nil {
+		return err
+	}
 
+	vr.height, vr.epoch, vr.smsk = h.Number.Uint64(), epoch, smsk
+	return nil
+}
+
+func newShardMasks(spks map[uint32][]bls.SerializedPublicKey) (map[uint32]*bls.Mask, error) {
+	var err error
+	smsk := make(map[uint32]*bls.Mask)
 	for sid, pks := range spks {
 		pubs := make([]bls.PublicKeyWrapper, len(pks))
 		for i, pk := range pks {
 			pubs[i].Bytes = pk
 			pubs[i].Object, err = bls.BytesToBLSPublicKey(pubs[i].Bytes[:])
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		mask, err := bls.NewMask(pubs, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		smsk[sid] = mask
 	}
-
-	vr.epoch, vr.smsk = epoch, smsk
-	return nil
+	return smsk, nil
 }
 
 func (vl *verifier) payload(h *Header) []byte {
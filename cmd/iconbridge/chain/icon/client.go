@@ -36,9 +36,11 @@ import (
 
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
 	"github.com/icon-project/icon-bridge/common/crypto"
 	"github.com/icon-project/icon-bridge/common/jsonrpc"
 	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
 )
 
 const (
@@ -56,6 +58,8 @@ type Client struct {
 	conns map[string]*websocket.Conn
 	log   log.Logger
 	mtx   sync.Mutex
+	// endpoint tracks the health of the endpoint of the client, if set
+	endpoint *chain.Endpoint
 }
 
 var txSerializeExcludes = map[string]bool{"signature": true}
@@ -74,7 +78,21 @@ func (c *Client) SignTransaction(w Wallet, p *TransactionParam) error {
 	bs = append([]byte("icx_sendTransaction."), bs...)
 	txHash := crypto.SHA3Sum256(bs)
 	p.TxHash = NewHexBytes(txHash)
-	sig, err := w.Sign(txHash)
+	var sig []byte
+	if ts, ok := w.(wallet.IconTxSigner); ok {
+		// the params but the signature, for the signer to check them
+		var params map[string]json.RawMessage
+		if err = json.Unmarshal(js, &params); err != nil {
+			return err
+		}
+		delete(params, "signature")
+		if js, err = json.Marshal(params); err != nil {
+			return err
+		}
+		sig, err = ts.SignIconTransaction(js, txHash)
+	} else {
+		sig, err = w.Sign(txHash)
+	}
 	if err != nil {
 		return err
 	}
@@ -214,6 +232,7 @@ func (c *Client) GetLastBlock() (*Block, error) {
 	if _, err := c.Do("icx_getLastBlock", struct{}{}, &result); err != nil {
 		return nil, err
 	}
+	c.setHeight(result.Height)
 	return result, nil
 }
 
@@ -596,15 +615,68 @@ func NewIconOptionsByHeader(h http.Header) IconOptions {
 }
 
 func NewClient(uri string, l log.Logger) *Client {
+	return newClient(uri, nil, l)
+}
+
+// newClients returns a Client for each url, their endpoints sharing a
+// chain.EndpointPool
+func newClients(urls []string, l log.Logger) []*Client {
+	pool := chain.SharedEndpointPool(urls)
+	cls := make([]*Client, 0, len(urls))
+	for i, url := range urls {
+		cls = append(cls, newClient(url, pool.Endpoint(i), l))
+	}
+	return cls
+}
+
+// newClient returns the Client of uri, whose calls are recorded by
+// endpoint if not nil
+func newClient(uri string, endpoint *chain.Endpoint, l log.Logger) *Client {
 	//TODO options {MaxRetrySendTx, MaxRetryGetResult, MaxIdleConnsPerHost, Debug, Dump}
-	tr := &http.Transport{MaxIdleConnsPerHost: 1000}
+	var tr http.RoundTripper = &http.Transport{MaxIdleConnsPerHost: 1000}
+	if endpoint != nil {
+		tr = endpoint.Transport(tr)
+	}
 	c := &Client{
-		Client: jsonrpc.NewJsonRpcClient(&http.Client{Transport: tr}, uri),
-		conns:  make(map[string]*websocket.Conn),
-		log:    l,
+		Client:   jsonrpc.NewJsonRpcClient(&http.Client{Transport: tr}, uri),
+		conns:    make(map[string]*websocket.Conn),
+		log:      l,
+		endpoint: endpoint,
 	}
 	opts := IconOptions{}
 	opts.SetBool(IconOptionsDebug, true)
 	c.CustomHeader[HeaderKeyIconOptions] = opts.ToHeaderValue()
 	return c
 }
+
+// pickClient returns a client of cls by chain.PickEndpoint
+func pickClient(cls []*Client) *Client {
+	endpoints := make([]*chain.Endpoint, len(cls))
+	for i, cl := range cls {
+		endpoints[i] = cl.endpoint
+	}
+	return cls[chain.PickEndpoint(endpoints)]
+}
+
+// endpoints returns the health of the endpoints of cls, nil if untracked
+func endpoints(cls []*Client) []chain.EndpointStatus {
+	if len(cls) == 0 || cls[0].endpoint == nil {
+		return nil
+	}
+	return cls[0].endpoint.Pool().Status()
+}
+
+// failed records a failed call to the endpoint of the client outside of
+// the http api, over websocket
+func (c *Client) failed(err error) {
+	if c.endpoint != nil {
+		c.endpoint.Record(0, err)
+	}
+}
+
+// setHeight records the head height seen from the endpoint of the client
+func (c *Client) setHeight(height int64) {
+	if c.endpoint != nil && height > 0 {
+		c.endpoint.SetHeight(uint64(height))
+	}
+}
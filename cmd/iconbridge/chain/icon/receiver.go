@@ -22,12 +22,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/db"
 	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/pkg/errors"
 )
@@ -43,6 +45,9 @@ const (
 const RECONNECT_ON_UNEXPECTED_HEIGHT = "Unexpected Block Height. Should Reconnect"
 const (
 	MonitorBlockMaxConcurrency = 300
+	// VerifierSnapshotInterval is the number of blocks after which the
+	// verifier state is persisted, if a state store is set
+	VerifierSnapshotInterval = 1000
 )
 
 type ReceiverOptions struct {
@@ -50,6 +55,20 @@ type ReceiverOptions struct {
 	Verifier        *VerifierOptions `json:"verifier"`
 }
 
+// ValidateReceiverOptions ...
+// checks the options of NewReceiver, rejecting unknown keys; the receiver
+// doesn't verify blocks without verifier options
+func ValidateReceiverOptions(rawOpts json.RawMessage) error {
+	opts := &ReceiverOptions{}
+	if err := chain.UnmarshalOptionsStrict(rawOpts, opts); err != nil {
+		return err
+	}
+	if opts.Verifier == nil {
+		return fmt.Errorf("missing verifier")
+	}
+	return nil
+}
+
 func (opts *ReceiverOptions) Unmarshal(v map[string]interface{}) error {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -66,20 +85,48 @@ type eventLogRawFilter struct {
 }
 
 type receiver struct {
+	// latestHeight and verifiedHeight are accessed atomically
+	latestHeight   uint64
+	verifiedHeight uint64
+
 	log       log.Logger
 	src       chain.BTPAddress
 	dst       chain.BTPAddress
-	cl        *Client
+	cls       []*Client
 	opts      ReceiverOptions
 	blockReq  BlockRequest
 	logFilter eventLogRawFilter
+
+	// store persists verifier snapshots, if set
+	store    db.Bucket
+	snapshot *verifierSnapshot
+}
+
+func (r *receiver) SetStateStore(store db.Bucket) {
+	r.store = store
+}
+
+func (r *receiver) client() *Client {
+	return pickClient(r.cls)
+}
+
+// Endpoints implements chain.EndpointReporter
+func (r *receiver) Endpoints() []chain.EndpointStatus {
+	return endpoints(r.cls)
+}
+
+func (r *receiver) ReceiverStatus() *chain.ReceiverStatus {
+	return &chain.ReceiverStatus{
+		Height:         atomic.LoadUint64(&r.latestHeight),
+		VerifiedHeight: atomic.LoadUint64(&r.verifiedHeight),
+	}
 }
 
 func NewReceiver(src, dst chain.BTPAddress, urls []string, rawOpts json.RawMessage, l log.Logger) (chain.Receiver, error) {
 	if len(urls) == 0 {
 		return nil, errors.New("List of Urls is empty")
 	}
-	client := NewClient(urls[0], l)
+	cls := newClients(urls, l)
 
 	var recvOpts ReceiverOptions
 	if err := json.Unmarshal(rawOpts, &recvOpts); err != nil {
@@ -111,7 +158,7 @@ func NewReceiver(src, dst chain.BTPAddress, urls []string, rawOpts json.RawMessa
 		log:      l,
 		src:      src,
 		dst:      dst,
-		cl:       client,
+		cls:      cls,
 		opts:     recvOpts,
 		blockReq: evtReq,
 		logFilter: eventLogRawFilter{
@@ -125,7 +172,7 @@ func NewReceiver(src, dst chain.BTPAddress, urls []string, rawOpts json.RawMessa
 }
 
 func (r *receiver) newVerifer(opts *VerifierOptions) (*Verifier, error) {
-	validators, err := r.cl.getValidatorsByHash(opts.ValidatorsHash)
+	validators, err := r.client().getValidatorsByHash(opts.ValidatorsHash)
 	if err != nil {
 		return nil, err
 	}
@@ -136,23 +183,97 @@ func (r *receiver) newVerifer(opts *VerifierOptions) (*Verifier, error) {
 			opts.ValidatorsHash.String(): validators,
 		},
 	}
-	header, err := r.cl.getBlockHeaderByHeight(int64(vr.next))
-	if err != nil {
+	if err = r.crossCheckVerifier(&vr); err != nil {
 		return nil, err
 	}
-	votes, err := r.cl.GetVotesByHeight(
-		&BlockHeightParam{Height: NewHexInt(vr.next)})
+	return &vr, nil
+}
+
+// crossCheckVerifier verifies the header at vr.Next() with its votes
+func (r *receiver) crossCheckVerifier(vr *Verifier) error {
+	header, err := r.client().getBlockHeaderByHeight(vr.Next())
 	if err != nil {
-		return nil, err
+		return err
+	}
+	votes, err := r.client().GetVotesByHeight(
+		&BlockHeightParam{Height: NewHexInt(vr.Next())})
+	if err != nil {
+		return err
 	}
 	ok, err := vr.Verify(header, votes)
-	if !ok {
+	if !ok && err == nil {
 		err = errors.New("verification failed")
 	}
+	return err
+}
+
+// loadVerifier ...
+// restores the verifier from its latest snapshot if it is usable to verify
+// blocks from `height`, otherwise creates it from the config anchor `opts`
+func (r *receiver) loadVerifier(opts *VerifierOptions, height uint64) (*Verifier, error) {
+	if vr, err := r.restoreVerifier(opts, height); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn(
+			"verifier snapshot: discarded, falling back to config")
+	} else if vr != nil {
+		return vr, nil
+	}
+	vr, err := r.newVerifer(opts)
 	if err != nil {
 		return nil, err
 	}
-	return &vr, nil
+	r.snapshot = vr.snapshot() // nothing to save until it advances
+	return vr, nil
+}
+
+func (r *receiver) restoreVerifier(opts *VerifierOptions, height uint64) (*Verifier, error) {
+	if r.store == nil {
+		return nil, nil
+	}
+	ss := &verifierSnapshot{}
+	if ok, err := chain.LoadSnapshot(r.store, chain.VerifierSnapshotKey, ss); err != nil || !ok {
+		return nil, err
+	}
+	if ss.Next <= int64(opts.BlockHeight) || ss.Next > int64(height) {
+		r.log.WithFields(log.Fields{"next": ss.Next, "height": height}).Debug(
+			"verifier snapshot: not usable")
+		return nil, nil
+	}
+	if len(ss.Validators) == 0 {
+		return nil, fmt.Errorf("no validators for hash=%v", ss.NextValidatorsHash)
+	}
+	vr := &Verifier{
+		next:               ss.Next,
+		nextValidatorsHash: ss.NextValidatorsHash,
+		validators: map[string][]common.Address{
+			ss.NextValidatorsHash.String(): ss.Validators,
+		},
+	}
+	if err := r.crossCheckVerifier(vr); err != nil {
+		return nil, errors.Wrapf(err, "crossCheckVerifier: %v", err)
+	}
+	r.log.WithFields(log.Fields{"next": ss.Next}).Info("verifier snapshot: restored")
+	r.snapshot = ss
+	return vr, nil
+}
+
+// saveVerifier ...
+// persists the state of the verifier when validators change or every
+// VerifierSnapshotInterval blocks
+func (r *receiver) saveVerifier(vr *Verifier) {
+	if r.store == nil {
+		return
+	}
+	ss := vr.snapshot()
+	if last := r.snapshot; last != nil &&
+		bytes.Equal(last.NextValidatorsHash, ss.NextValidatorsHash) &&
+		ss.Next-last.Next < VerifierSnapshotInterval {
+		return
+	}
+	if err := chain.SaveSnapshot(r.store, chain.VerifierSnapshotKey, ss); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("verifier snapshot: failed to save")
+		return
+	}
+	r.snapshot = ss
 }
 
 func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
@@ -217,19 +338,19 @@ func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
 						q.res = &res{}
 					}
 					q.res.Height = q.height
-					q.res.Header, q.err = r.cl.getBlockHeaderByHeight(q.height)
+					q.res.Header, q.err = r.client().getBlockHeaderByHeight(q.height)
 					if q.err != nil {
 						q.err = errors.Wrapf(q.err, "syncVerifier: getBlockHeader: %v", q.err)
 						return
 					}
-					q.res.Votes, q.err = r.cl.GetVotesByHeight(
+					q.res.Votes, q.err = r.client().GetVotesByHeight(
 						&BlockHeightParam{Height: NewHexInt(int64(q.height))})
 					if q.err != nil {
 						q.err = errors.Wrapf(q.err, "syncVerifier: GetVotesByHeight: %v", q.err)
 						return
 					}
 					if len(vr.Validators(q.res.Header.NextValidatorsHash)) == 0 {
-						q.res.NextValidators, q.err = r.cl.getValidatorsByHash(q.res.Header.NextValidatorsHash)
+						q.res.NextValidators, q.err = r.client().getValidatorsByHash(q.res.Header.NextValidatorsHash)
 						if q.err != nil {
 							q.err = errors.Wrapf(q.err, "syncVerifier: getValidatorsByHash: %v", q.err)
 							return
@@ -265,6 +386,7 @@ func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
 					}
 				}
 			}
+			r.saveVerifier(vr)
 			r.log.WithFields(log.Fields{"height": vr.Next(), "target": height}).Debug("syncVerifier: syncing")
 		}
 	}
@@ -273,7 +395,7 @@ func (r *receiver) syncVerifier(vr *Verifier, height int64) error {
 	return nil
 }
 
-func (r *receiver) receiveLoop(ctx context.Context, startHeight, startSeq uint64, callback func(rs []*chain.Receipt) error) (err error) {
+func (r *receiver) receiveLoop(ctx context.Context, startHeight, startSeq uint64, callback func(height uint64, rs []*chain.Receipt) error) (err error) {
 
 	blockReq, logFilter := r.blockReq, r.logFilter // copy
 
@@ -281,7 +403,7 @@ func (r *receiver) receiveLoop(ctx context.Context, startHeight, startSeq uint64
 
 	var vr *Verifier
 	if r.opts.Verifier != nil {
-		vr, err = r.newVerifer(r.opts.Verifier)
+		vr, err = r.loadVerifier(r.opts.Verifier, startHeight)
 		if err != nil {
 			return err
 		}
@@ -337,8 +459,12 @@ loop:
 			go func(ctx context.Context, cancel context.CancelFunc) {
 				defer cancel()
 				blockReq.Height = NewHexInt(next)
-				err := r.cl.MonitorBlock(ctx, &blockReq,
+				cl := r.client()
+				err := cl.MonitorBlock(ctx, &blockReq,
 					func(conn *websocket.Conn, v *BlockNotification) error {
+						if height, err := v.Height.Value(); err == nil {
+							cl.setHeight(height)
+						}
 						if !errors.Is(ctx.Err(), context.Canceled) {
 							bnch <- v
 						}
@@ -350,6 +476,7 @@ loop:
 					if errors.Is(err, context.Canceled) {
 						return
 					}
+					cl.failed(err)
 					time.Sleep(time.Second * 5)
 					reconnect()
 					r.log.WithFields(log.Fields{"error": err}).Error("reconnect: monitor block error")
@@ -388,10 +515,12 @@ loop:
 					if err := vr.Update(br.Header, br.NextValidators); err != nil {
 						return errors.Wrapf(err, "receiveLoop: update verifier: %v", err)
 					}
+					r.saveVerifier(vr)
 				}
-				if err := callback(br.Receipts); err != nil {
+				if err := callback(uint64(br.Height), br.Receipts); err != nil {
 					return errors.Wrapf(err, "receiveLoop: callback: %v", err)
 				}
+				atomic.StoreUint64(&r.verifiedHeight, uint64(br.Height))
 				if br = nil; len(brch) > 0 {
 					br = <-brch
 				}
@@ -425,6 +554,7 @@ loop:
 						reconnect()
 						continue loop
 					}
+					atomic.StoreUint64(&r.latestHeight, uint64(height))
 					qch <- &req{
 						height:  height,
 						hash:    bn.Hash,
@@ -476,21 +606,21 @@ loop:
 								return
 							}
 
-							q.res.Header, q.err = r.cl.getBlockHeaderByHeight(q.height)
+							q.res.Header, q.err = r.client().getBlockHeaderByHeight(q.height)
 							if q.err != nil {
 								q.err = errors.Wrapf(q.err, "getBlockHeader: %v", q.err)
 								return
 							}
 							// fetch votes, next validators only if verifier exists
 							if vr != nil {
-								q.res.Votes, q.err = r.cl.GetVotesByHeight(
+								q.res.Votes, q.err = r.client().GetVotesByHeight(
 									&BlockHeightParam{Height: NewHexInt(int64(q.height))})
 								if q.err != nil {
 									q.err = errors.Wrapf(q.err, "GetVotesByHeight: %v", q.err)
 									return
 								}
 								if len(vr.Validators(q.res.Header.NextValidatorsHash)) == 0 {
-									q.res.NextValidators, q.err = r.cl.getValidatorsByHash(q.res.Header.NextValidatorsHash)
+									q.res.NextValidators, q.err = r.client().getValidatorsByHash(q.res.Header.NextValidatorsHash)
 									if q.err != nil {
 										q.err = errors.Wrapf(q.err, "getValidatorsByHash: %v", q.err)
 										return
@@ -511,7 +641,7 @@ loop:
 										BlockHash: q.hash,
 										Events:    q.events[0][i],
 									}
-									proofs, err := r.cl.GetProofForEvents(p)
+									proofs, err := r.client().GetProofForEvents(p)
 									if err != nil {
 										q.err = errors.Wrapf(err, "GetProofForEvents: %v", err)
 										return
@@ -646,7 +776,7 @@ func (r *receiver) Subscribe(
 	_errCh := make(chan error)
 	go func() {
 		defer close(_errCh)
-		err := r.receiveLoop(ctx, opts.Height, opts.Seq, func(receipts []*chain.Receipt) error {
+		err := r.receiveLoop(ctx, opts.Height, opts.Seq, func(height uint64, receipts []*chain.Receipt) error {
 			for _, receipt := range receipts {
 				events := receipt.Events[:0]
 				for _, event := range receipt.Events {
@@ -664,7 +794,12 @@ func (r *receiver) Subscribe(
 				receipt.Events = events
 			}
 			if len(receipts) > 0 {
-				msgCh <- &chain.Message{Receipts: receipts}
+				msgCh <- &chain.Message{Receipts: receipts, Height: height}
+			} else {
+				select { // report progress, but don't block on it
+				case msgCh <- &chain.Message{Height: height}:
+				default:
+				}
 			}
 			return nil
 		})
@@ -5,4 +5,6 @@ import "github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
 func init() {
 	relay.Senders["icon"] = NewSender
 	relay.Receivers["icon"] = NewReceiver
+	relay.SenderOptionValidators["icon"] = ValidateSenderOptions
+	relay.ReceiverOptionValidators["icon"] = ValidateReceiverOptions
 }
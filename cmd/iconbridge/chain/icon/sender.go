@@ -29,6 +29,7 @@ import (
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
 	"github.com/icon-project/icon-bridge/common"
 	"github.com/icon-project/icon-bridge/common/codec"
+	"github.com/icon-project/icon-bridge/common/errors"
 	"github.com/icon-project/icon-bridge/common/intconv"
 	"github.com/icon-project/icon-bridge/common/jsonrpc"
 	"github.com/icon-project/icon-bridge/common/log"
@@ -42,6 +43,7 @@ const (
 	defaultGetRelayResultInterval = time.Second
 	defaultRelayReSendInterval    = time.Second
 	defaultStepLimit              = 13610920010
+	maxStepLimit                  = 4 * defaultStepLimit
 )
 
 // NewSender ...
@@ -62,7 +64,7 @@ func NewSender(
 	if err := json.Unmarshal(rawOpts, &s.opts); err != nil {
 		return nil, err
 	}
-	s.cl = NewClient(urls[0], l)
+	s.cls = newClients(urls, l)
 	return s, nil
 }
 
@@ -72,6 +74,11 @@ type senderOptions struct {
 	BalanceThreshold intconv.BigInt `json:"balance_threshold"`
 }
 
+// ValidateSenderOptions checks the options of NewSender, rejecting unknown keys
+func ValidateSenderOptions(rawOpts json.RawMessage) error {
+	return chain.UnmarshalOptionsStrict(rawOpts, &senderOptions{})
+}
+
 func (opts *senderOptions) Unmarshal(v map[string]interface{}) error {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -86,7 +93,16 @@ type sender struct {
 	src  chain.BTPAddress
 	dst  chain.BTPAddress
 	opts senderOptions
-	cl   *Client
+	cls  []*Client
+}
+
+func (s *sender) client() *Client {
+	return pickClient(s.cls)
+}
+
+// Endpoints implements chain.EndpointReporter
+func (s *sender) Endpoints() []chain.EndpointStatus {
+	return endpoints(s.cls)
 }
 
 func hexInt2Uint64(hi HexInt) uint64 {
@@ -112,7 +128,7 @@ func (s *sender) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
 		},
 	}
 	bs := &BMCStatus{}
-	err := mapError(s.cl.Call(p, bs))
+	err := mapError(s.client().Call(p, bs))
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +209,7 @@ func (s *sender) Segment(
 }
 
 func (s *sender) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
-	bal, err := s.cl.GetBalance(&AddressParam{Address: Address(s.w.Address())})
+	bal, err := s.client().GetBalance(&AddressParam{Address: Address(s.w.Address())})
 	return bal, &s.opts.BalanceThreshold.Int, err
 }
 
@@ -220,7 +236,7 @@ func (s *sender) newRelayTx(ctx context.Context, prev string, message []byte) (*
 		Prev:    prev,
 		Message: message,
 		txParam: txParam,
-		cl:      s.cl,
+		cl:      s.client(),
 		w:       s.w,
 	}, nil
 }
@@ -231,6 +247,7 @@ type relayTx struct {
 
 	txParam     *TransactionParam
 	txHashParam *TransactionHashParam
+	stepUsed    uint64
 	cl          *Client
 	w           wallet.Wallet
 }
@@ -242,6 +259,25 @@ func (tx *relayTx) ID() interface{} {
 	return nil
 }
 
+func (tx *relayTx) GasUsed() uint64 {
+	return tx.stepUsed
+}
+
+// IncreaseGasLimit raises the step limit by half, upto maxStepLimit
+func (tx *relayTx) IncreaseGasLimit() bool {
+	stepLimit, err := tx.txParam.StepLimit.Value()
+	if err != nil || stepLimit >= maxStepLimit {
+		return false
+	}
+	stepLimit += stepLimit / 2
+	if stepLimit > maxStepLimit {
+		stepLimit = maxStepLimit
+	}
+	tx.txParam.StepLimit = NewHexInt(stepLimit)
+	tx.txHashParam = nil
+	return true
+}
+
 func (tx *relayTx) Send(ctx context.Context) error {
 	tx.cl.log.WithFields(log.Fields{
 		"prev": tx.Prev}).Debug("handleRelayMessage: send tx")
@@ -317,8 +353,14 @@ func (tx *relayTx) Receipt(ctx context.Context) (blockHeight uint64, err error)
 			}
 			return 0, mapErrorWithTransactionResult(txr, err)
 		}
+		if txr.Status != ResultStatusSuccess {
+			return 0, mapErrorWithTransactionResult(txr, nil)
+		}
 		tx.cl.log.WithFields(log.Fields{
 			"txh": tx.txHashParam.Hash}).Debug("handleRelayMessage: success")
+		if stepUsed, err := txr.StepUsed.Value(); err == nil {
+			tx.stepUsed = uint64(stepUsed)
+		}
 		height, _ := txr.BlockHeight.Value()
 		return uint64(height), nil
 	}
@@ -364,11 +406,17 @@ func mapErrorWithTransactionResult(txr *TransactionResult, err error) error {
 	err = mapError(err)
 	if err == nil && txr != nil && txr.Status != ResultStatusSuccess {
 		fc, _ := txr.Failure.CodeValue.Value()
-		if fc < ResultStatusFailureCodeRevert || fc > ResultStatusFailureCodeEnd {
+		if fc == ResultStatusFailureCodeOutOfStep {
+			err = chain.ErrGasLimitExceeded
+		} else if fc < ResultStatusFailureCodeRevert || fc > ResultStatusFailureCodeEnd {
 			err = fmt.Errorf("failure with code:%s, message:%s",
 				txr.Failure.CodeValue, txr.Failure.MessageValue)
 		} else {
 			err = NewRevertError(int(fc - ResultStatusFailureCodeRevert))
+			switch errors.CodeOf(err) {
+			case BMVRevertInvalidSequence, BMVRevertInvalidSequenceHigher:
+				err = fmt.Errorf("%w: %v", chain.ErrBMCRevertInvalidSeqNumber, err)
+			}
 		}
 	}
 	return err
@@ -57,9 +57,10 @@ const (
 )
 
 const (
-	ResultStatusSuccess           = "0x1"
-	ResultStatusFailureCodeRevert = 32
-	ResultStatusFailureCodeEnd    = 99
+	ResultStatusSuccess              = "0x1"
+	ResultStatusFailureCodeOutOfStep = 10
+	ResultStatusFailureCodeRevert    = 32
+	ResultStatusFailureCodeEnd       = 99
 )
 
 const (
@@ -161,6 +161,25 @@ func (vr *Verifier) Update(blockHeader *BlockHeader, nextValidators []common.Add
 	return nil
 }
 
+// verifierSnapshot ...
+// is the trusted state of Verifier, which is persisted to resume
+// verification from there on restart
+type verifierSnapshot struct {
+	Next               int64            `json:"next"`
+	NextValidatorsHash common.HexHash   `json:"nextValidatorsHash"`
+	Validators         []common.Address `json:"validators"`
+}
+
+func (vr *Verifier) snapshot() *verifierSnapshot {
+	vr.mu.RLock()
+	defer vr.mu.RUnlock()
+	return &verifierSnapshot{
+		Next:               vr.next,
+		NextValidatorsHash: vr.nextValidatorsHash,
+		Validators:         vr.validators[vr.nextValidatorsHash.String()],
+	}
+}
+
 func (vr *Verifier) Validators(nextValidatorsHash common.HexBytes) []common.Address {
 	vr.mu.RLock()
 	defer vr.mu.RUnlock()
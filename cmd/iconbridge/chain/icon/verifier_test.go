@@ -9,7 +9,10 @@ import (
 	ethc "github.com/ethereum/go-ethereum/common"
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
 	"github.com/icon-project/icon-bridge/common/crypto"
+	"github.com/icon-project/icon-bridge/common/db"
+	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/stretchr/testify/require"
 )
 
@@ -251,4 +254,53 @@ func TestVerifier_GetValidators_NotFound(t *testing.T) {
 	address := vr.Validators([]byte("Unknown validator address"))
 
 	require.Nil(t, address)
-}
\ No newline at end of file
+}
+
+func TestReceiver_SaveVerifier(t *testing.T) {
+	store, err := db.NewMapDB().GetBucket("verifier")
+	require.NoError(t, err)
+	r := &receiver{log: log.New(), store: store}
+
+	vr := NewSampleTestVerifier()
+	next := vr.next
+	r.saveVerifier(vr)
+
+	// same validators within VerifierSnapshotInterval: not saved again, the
+	// stored snapshot is still the first one
+	header := &BlockHeader{Height: vr.next, NextValidatorsHash: vr.nextValidatorsHash}
+	require.NoError(t, vr.Update(header, getSampleValidators()))
+	r.saveVerifier(vr)
+
+	ss := &verifierSnapshot{}
+	ok, err := chain.LoadSnapshot(store, chain.VerifierSnapshotKey, ss)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, next, ss.Next)
+	require.Less(t, ss.Next, vr.next)
+	require.EqualValues(t, vr.nextValidatorsHash, ss.NextValidatorsHash)
+	require.Equal(t, getSampleValidators(), ss.Validators)
+
+	// restored verifier verifies the same votes
+	restored := &Verifier{
+		next:               ss.Next,
+		nextValidatorsHash: ss.NextValidatorsHash,
+		validators: map[string][]common.Address{
+			ss.NextValidatorsHash.String(): ss.Validators,
+		},
+	}
+	rawVotes, err := codec.BC.MarshalToBytes(getSampleCommitVoteList())
+	require.NoError(t, err)
+	ok, err = restored.Verify(getSampleHeader(), rawVotes)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// validators changed: saved
+	header = &BlockHeader{Height: vr.next, NextValidatorsHash: crypto.SHA3Sum256([]byte("New"))}
+	require.NoError(t, vr.Update(header, getSampleValidators()[:2]))
+	r.saveVerifier(vr)
+	ok, err = chain.LoadSnapshot(store, chain.VerifierSnapshotKey, ss)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, header.Height+1, ss.Next)
+	require.Len(t, ss.Validators, 2)
+}
@@ -0,0 +1,262 @@
+// Package mock simulates chains with a BMC in memory, to run relays
+// offline. Build with the "mock" tag to register it as the "mock" chain,
+// e.g. "btp://0x1.mock/bmc"; see example.mock.config.json.
+package mock
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+)
+
+var (
+	chainsMu sync.Mutex
+	chains   = map[string]*Chain{}
+)
+
+// GetChain ...
+// returns the simulated chain of the network address, e.g. "0x1.mock",
+// creating it if it doesn't exist. Senders and receivers of the same
+// network address share the chain.
+func GetChain(network string) *Chain {
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	c, ok := chains[network]
+	if !ok {
+		c = &Chain{
+			network:   network,
+			balance:   big.NewInt(defaultBalance),
+			newBlock:  make(chan struct{}),
+			txSeq:     map[string]uint64{},
+			links:     map[chain.BTPAddress]*chain.BMCLinkStatus{},
+			delivered: map[chain.BTPAddress][]*chain.Event{},
+		}
+		chains[network] = c
+	}
+	return c
+}
+
+// Reset removes all simulated chains
+func Reset() {
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	chains = map[string]*Chain{}
+}
+
+// Chain simulates a blockchain with a BMC
+type Chain struct {
+	network string
+
+	mu      sync.Mutex
+	height  uint64
+	balance *big.Int
+	// newBlock is closed when a block is mined
+	newBlock chan struct{}
+	// receipts of outgoing messages to all links, and TxSeq by network
+	// address of dst
+	receipts []*chain.Receipt
+	txSeq    map[string]uint64
+	// link status and events of incoming links, by src
+	links     map[chain.BTPAddress]*chain.BMCLinkStatus
+	delivered map[chain.BTPAddress][]*chain.Event
+	failures  []*Failure
+}
+
+func (c *Chain) init(opts *Options) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.height < opts.Height {
+		c.height = opts.Height
+	}
+	if opts.Balance.Sign() > 0 {
+		c.balance = new(big.Int).Set(&opts.Balance.Int)
+	}
+	for _, f := range opts.Failures {
+		c.inject(f)
+	}
+}
+
+func (c *Chain) Height() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.height
+}
+
+func (c *Chain) SetBalance(balance *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balance = new(big.Int).Set(balance)
+}
+
+func (c *Chain) balanceIsZero() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.balance.Sign() <= 0
+}
+
+// Inject adds a failure to the chain
+func (c *Chain) Inject(f *Failure) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inject(f)
+}
+
+func (c *Chain) inject(f *Failure) {
+	fc := *f
+	c.failures = append(c.failures, &fc)
+}
+
+// Mine adds n empty blocks
+func (c *Chain) Mine(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mine(n)
+}
+
+func (c *Chain) mine(n int) {
+	for i := 0; i < n; i++ {
+		c.height++
+		if f := c.takeFailure(FailureReorg); f != nil {
+			c.reorg(f.Depth)
+		}
+	}
+	close(c.newBlock)
+	c.newBlock = make(chan struct{})
+}
+
+// reorg moves the receipts of the last `depth` blocks to the next blocks
+func (c *Chain) reorg(depth uint64) {
+	if depth > c.height {
+		depth = c.height
+	}
+	from := c.height - depth
+	for _, receipt := range c.receipts {
+		if receipt.Height > from {
+			receipt.Height += depth
+		}
+	}
+	c.height += depth
+}
+
+// SendMessage ...
+// emits a BTP message to dst in a new block and returns its sequence number
+func (c *Chain) SendMessage(dst chain.BTPAddress, msg []byte) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	network := dst.NetworkAddress()
+	c.txSeq[network]++
+	seq := c.txSeq[network]
+	c.receipts = append(c.receipts, &chain.Receipt{
+		Height: c.height + 1,
+		Events: []*chain.Event{{Next: dst, Sequence: seq, Message: msg}},
+	})
+	c.mine(1)
+	return seq
+}
+
+// Status returns the status of the link from src
+func (c *Chain) Status(src chain.BTPAddress) *chain.BMCLinkStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	link := *c.link(src)
+	link.CurrentHeight = c.height
+	return &link
+}
+
+func (c *Chain) link(src chain.BTPAddress) *chain.BMCLinkStatus {
+	link, ok := c.links[src]
+	if !ok {
+		link = &chain.BMCLinkStatus{}
+		c.links[src] = link
+	}
+	return link
+}
+
+// Delivered returns the events relayed from src to the chain
+func (c *Chain) Delivered(src chain.BTPAddress) []*chain.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*chain.Event{}, c.delivered[src]...)
+}
+
+// handleRelayMessage ...
+// executes a relay tx in a new block and returns the height of the block
+func (c *Chain) handleRelayMessage(src chain.BTPAddress, receipts []*chain.Receipt) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mine(1)
+	if f := c.takeFailure(FailureRevert); f != nil {
+		return c.height, f.revertError()
+	}
+	link := c.link(src)
+	seq := link.RxSeq
+	for _, receipt := range receipts {
+		for _, event := range receipt.Events {
+			if seq++; event.Sequence != seq {
+				return c.height, chain.ErrBMCRevertInvalidSeqNumber
+			}
+		}
+	}
+	for _, receipt := range receipts {
+		c.delivered[src] = append(c.delivered[src], receipt.Events...)
+		link.RxHeight = receipt.Height
+	}
+	link.RxSeq = seq
+	return c.height, nil
+}
+
+// receiptsIn returns copies of the receipts in blocks [from, to]
+func (c *Chain) receiptsIn(from, to uint64) []*chain.Receipt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var receipts []*chain.Receipt
+	for _, receipt := range c.receipts {
+		if receipt.Height >= from && receipt.Height <= to {
+			rc := *receipt
+			rc.Events = append([]*chain.Event{}, receipt.Events...)
+			receipts = append(receipts, &rc)
+		}
+	}
+	return receipts
+}
+
+// waitBlock waits for a block higher than `height`
+func (c *Chain) waitBlock(ctx context.Context, height uint64) error {
+	c.mu.Lock()
+	if c.height > height {
+		c.mu.Unlock()
+		return nil
+	}
+	newBlock := c.newBlock
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-newBlock:
+		return nil
+	}
+}
+
+// takeFailure ...
+// returns the first active failure of the kind, if any, and counts it;
+// c.mu must be held
+func (c *Chain) takeFailure(kind FailureKind) *Failure {
+	for i, f := range c.failures {
+		if f.Kind != kind || f.At > c.height {
+			continue
+		}
+		if f.Count--; f.Count <= 0 {
+			c.failures = append(c.failures[:i], c.failures[i+1:]...)
+		}
+		return f
+	}
+	return nil
+}
+
+func (c *Chain) failure(kind FailureKind) *Failure {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.takeFailure(kind)
+}
@@ -0,0 +1,224 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSrc = chain.BTPAddress("btp://0x1.mock/bmc")
+	testDst = chain.BTPAddress("btp://0x2.mock/bmc")
+)
+
+type testRelay struct {
+	src, dst *Chain
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// startTestRelay ...
+// starts a relay from testSrc to testDst, restarting it on failure like
+// relay.multiRelay, until the test ends
+func startTestRelay(t *testing.T, srcOpts, dstOpts *Options) *testRelay {
+	Reset()
+	l := log.New()
+	l.SetLevel(log.WarnLevel)
+	rawOpts := func(opts *Options) json.RawMessage {
+		if opts == nil {
+			return nil
+		}
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+		return b
+	}
+	src, err := NewReceiver(testSrc, testDst, nil, rawOpts(srcOpts), l)
+	require.NoError(t, err)
+	dst, err := NewSender(testSrc, testDst, nil, nil, rawOpts(dstOpts), l)
+	require.NoError(t, err)
+
+	r, err := relay.NewRelay(&relay.RelayConfig{
+		Name: t.Name(),
+		Src:  relay.SrcConfig{ChainConfig: relay.ChainConfig{Address: testSrc}},
+		Dst:  relay.DstConfig{ChainConfig: relay.ChainConfig{Address: testDst}},
+		Policy: &relay.PolicyConfig{
+			TickerInterval:                  relay.Duration(10 * time.Millisecond),
+			TxSendWaitInterval:              relay.Duration(time.Millisecond),
+			TxReceiptWaitInterval:           relay.Duration(time.Millisecond),
+			TxReceiptMaxRetries:             3,
+			InsufficientBalanceWaitInterval: relay.Duration(10 * time.Millisecond),
+		},
+	}, src, dst, l)
+	require.NoError(t, err)
+
+	tr := &testRelay{src: GetChain(testSrc.NetworkAddress()), dst: GetChain(testDst.NetworkAddress())}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if err := r.Start(ctx); ctx.Err() == nil {
+				tr.mu.Lock()
+				tr.errs = append(tr.errs, err)
+				tr.mu.Unlock()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return tr
+}
+
+func (tr *testRelay) restarts() int {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return len(tr.errs)
+}
+
+func (tr *testRelay) sendMessages(n int) {
+	for i := 0; i < n; i++ {
+		tr.src.SendMessage(testDst, []byte(fmt.Sprintf("message %d", i)))
+	}
+}
+
+// waitDelivered waits until n messages are delivered and checks that they
+// were delivered in order, exactly once
+func (tr *testRelay) waitDelivered(t *testing.T, n int) {
+	require.Eventually(t, func() bool {
+		return tr.dst.Status(testSrc).RxSeq >= uint64(n)
+	}, 5*time.Second, 5*time.Millisecond)
+	events := tr.dst.Delivered(testSrc)
+	require.Len(t, events, n)
+	for i, event := range events {
+		assert.EqualValues(t, i+1, event.Sequence)
+		assert.Equal(t, fmt.Sprintf("message %d", i), string(event.Message))
+	}
+}
+
+func TestChain_Subscribe(t *testing.T) {
+	Reset()
+	rc, err := NewReceiver(testSrc, testDst, nil,
+		json.RawMessage(`{"height":10,"confirmations":2}`), log.New())
+	require.NoError(t, err)
+	c := GetChain(testSrc.NetworkAddress())
+	assert.EqualValues(t, 10, c.Height())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgCh := make(chan *chain.Message)
+	_, err = rc.Subscribe(ctx, msgCh, chain.SubscribeOptions{Height: 11})
+	require.NoError(t, err)
+
+	seq := c.SendMessage(testDst, []byte("hello"))
+	assert.EqualValues(t, 1, seq)
+	c.Mine(1)
+	select {
+	case msg := <-msgCh:
+		t.Fatalf("unconfirmed message: %+v", msg)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Mine(1)
+	msg := <-msgCh
+	require.Len(t, msg.Receipts, 1)
+	assert.EqualValues(t, 11, msg.Receipts[0].Height)
+	assert.EqualValues(t, 11, msg.Height)
+	assert.Equal(t, "hello", string(msg.Receipts[0].Events[0].Message))
+}
+
+func TestChain_InvalidOptions(t *testing.T) {
+	for _, opts := range []string{
+		`{"block_interval":"1 sec"}`,
+		`{"event_interval":2}`,
+		`{"failures":[{"kind":"meteor"}]}`,
+		`{"failures":[{"kind":"reorg"}]}`,
+	} {
+		_, err := NewReceiver(testSrc, testDst, nil, json.RawMessage(opts), log.New())
+		assert.Error(t, err, opts)
+	}
+}
+
+func TestRelay_Deliver(t *testing.T) {
+	tr := startTestRelay(t, nil, &Options{MaxReceipts: 2})
+	tr.sendMessages(5)
+	tr.waitDelivered(t, 5)
+	assert.Zero(t, tr.restarts())
+}
+
+func TestRelay_Failures(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     []*Failure
+		dst     []*Failure
+		restart bool
+	}{
+		{name: "revert", dst: []*Failure{{Kind: FailureRevert, Reason: "Unreachable"}}},
+		{name: "invalid seq", dst: []*Failure{{Kind: FailureRevert, Reason: "InvalidSeqNumber"}}},
+		{name: "send timeout", dst: []*Failure{{Kind: FailureSendTimeout, Count: 3}}},
+		{name: "receipt timeout", dst: []*Failure{{Kind: FailureReceiptTimeout, Count: 2}}},
+		{name: "insufficient balance", dst: []*Failure{{Kind: FailureInsufficientBalance}}},
+		{name: "reorg", src: []*Failure{{Kind: FailureReorg, At: 3, Depth: 2}}},
+		{name: "gap", src: []*Failure{{Kind: FailureGap}}, restart: true},
+		{name: "subscribe timeout", src: []*Failure{{Kind: FailureSubscribeTimeout, At: 2}}, restart: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := startTestRelay(t, &Options{Failures: tc.src}, &Options{Failures: tc.dst})
+			tr.sendMessages(5)
+			tr.waitDelivered(t, 5)
+			if tc.restart {
+				assert.NotZero(t, tr.restarts())
+			}
+		})
+	}
+}
+
+func TestRelay_RandomFailures(t *testing.T) {
+	kinds := []FailureKind{
+		FailureRevert, FailureSendTimeout, FailureReceiptTimeout,
+		FailureInsufficientBalance, FailureGap, FailureReorg, FailureSubscribeTimeout,
+	}
+	for seed := int64(1); seed <= 5; seed++ {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(seed))
+			tr := startTestRelay(t, nil, &Options{MaxReceipts: 1 + rnd.Intn(3)})
+			const n = 20
+			for i := 0; i < n; i++ {
+				if rnd.Intn(3) == 0 {
+					f := &Failure{Kind: kinds[rnd.Intn(len(kinds))], Depth: 1 + uint64(rnd.Intn(3))}
+					switch f.Kind {
+					case FailureGap, FailureReorg, FailureSubscribeTimeout:
+						tr.src.Inject(f)
+					default:
+						tr.dst.Inject(f)
+					}
+				}
+				tr.src.SendMessage(testDst, []byte(fmt.Sprintf("message %d", i)))
+				time.Sleep(time.Duration(rnd.Intn(5)) * time.Millisecond)
+			}
+			tr.waitDelivered(t, n)
+		})
+	}
+}
+
+func TestRelay_Routing(t *testing.T) {
+	tr := startTestRelay(t, nil, nil)
+	other := chain.BTPAddress("btp://0x3.mock/bmc")
+	for i := 0; i < 3; i++ {
+		tr.src.SendMessage(other, []byte("other"))
+		tr.src.SendMessage(testDst, []byte(fmt.Sprintf("message %d", i)))
+	}
+	tr.waitDelivered(t, 3)
+}
@@ -0,0 +1,139 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// NewReceiver ...
+// returns a receiver that subscribes to messages from the simulated
+// chain of src to dst
+func NewReceiver(
+	src, dst chain.BTPAddress, urls []string,
+	rawOpts json.RawMessage, l log.Logger) (chain.Receiver, error) {
+	r := &receiver{log: l, src: src, dst: dst}
+	if len(rawOpts) > 0 {
+		if err := json.Unmarshal(rawOpts, &r.opts); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.opts.validate(); err != nil {
+		return nil, err
+	}
+	r.c = GetChain(src.NetworkAddress())
+	r.c.init(&r.opts)
+	r.c.mu.Lock()
+	if _, ok := r.c.txSeq[dst.NetworkAddress()]; !ok {
+		r.c.txSeq[dst.NetworkAddress()] = r.opts.Seq
+	}
+	r.c.mu.Unlock()
+	return r, nil
+}
+
+type receiver struct {
+	log  log.Logger
+	src  chain.BTPAddress
+	dst  chain.BTPAddress
+	opts Options
+	c    *Chain
+}
+
+func (r *receiver) Subscribe(
+	ctx context.Context, msgCh chan<- *chain.Message,
+	opts chain.SubscribeOptions) (errCh <-chan error, err error) {
+
+	if r.opts.blockInterval > 0 {
+		go r.mineLoop(ctx)
+	}
+
+	_errCh := make(chan error)
+	go func() {
+		if err := r.receiveLoop(ctx, msgCh, opts); err != nil && ctx.Err() == nil {
+			r.log.Errorf("receiveLoop terminated: %v", err)
+			_errCh <- err
+			close(_errCh)
+		}
+	}()
+	return _errCh, nil
+}
+
+func (r *receiver) receiveLoop(
+	ctx context.Context, msgCh chan<- *chain.Message, opts chain.SubscribeOptions) error {
+	next, seq := opts.Height, opts.Seq
+	if next == 0 {
+		next = 1
+	}
+	for {
+		height := r.c.Height()
+		if height >= next+r.opts.Confirmations {
+			if r.c.failure(FailureSubscribeTimeout) != nil {
+				return fmt.Errorf("subscribe: %w", context.DeadlineExceeded)
+			}
+			to := height - r.opts.Confirmations
+			msg := &chain.Message{From: r.src, Height: to}
+			for _, receipt := range r.c.receiptsIn(next, to) {
+				events := receipt.Events[:0]
+				for _, event := range receipt.Events {
+					if !event.Next.Equal(r.dst) {
+						// sequence of another link, routed by the relay
+						events = append(events, event)
+						continue
+					}
+					if event.Sequence <= seq {
+						continue // delivered before a reorg
+					}
+					seq = event.Sequence
+					if r.c.failure(FailureGap) != nil {
+						r.log.WithFields(log.Fields{"seq": seq}).Debug("skipped event")
+						continue
+					}
+					events = append(events, event)
+				}
+				if receipt.Events = events; len(events) > 0 {
+					msg.Receipts = append(msg.Receipts, receipt)
+				}
+			}
+			if len(msg.Receipts) > 0 {
+				select {
+				case msgCh <- msg:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			} else {
+				select { // report progress, but don't block on it
+				case msgCh <- msg:
+				default:
+				}
+			}
+			next = to + 1
+		}
+		if err := r.c.waitBlock(ctx, height); err != nil {
+			return err
+		}
+	}
+}
+
+// mineLoop mines blocks every block interval and sends an event to dst
+// every event interval blocks
+func (r *receiver) mineLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.blockInterval)
+	defer ticker.Stop()
+	for i := uint64(1); ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if r.opts.EventInterval > 0 && i%r.opts.EventInterval == 0 {
+			seq := r.c.SendMessage(r.dst, []byte(fmt.Sprintf("message %d", i)))
+			r.log.WithFields(log.Fields{"seq": seq}).Debug("sent message")
+		} else {
+			r.c.Mine(1)
+		}
+	}
+}
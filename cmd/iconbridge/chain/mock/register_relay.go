@@ -0,0 +1,13 @@
+//go:build mock
+// +build mock
+
+package mock
+
+import "github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+
+func init() {
+	relay.Senders["mock"] = NewSender
+	relay.Receivers["mock"] = NewReceiver
+	relay.SenderOptionValidators["mock"] = ValidateOptions
+	relay.ReceiverOptionValidators["mock"] = ValidateOptions
+}
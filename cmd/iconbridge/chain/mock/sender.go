@@ -0,0 +1,142 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+)
+
+var lastTxID uint64
+
+// NewSender ...
+// returns a sender that relays messages to the simulated chain of dst
+func NewSender(
+	src, dst chain.BTPAddress,
+	urls []string, w wallet.Wallet,
+	rawOpts json.RawMessage, l log.Logger) (chain.Sender, error) {
+	s := &sender{log: l, src: src, dst: dst}
+	if len(rawOpts) > 0 {
+		if err := json.Unmarshal(rawOpts, &s.opts); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.opts.validate(); err != nil {
+		return nil, err
+	}
+	s.c = GetChain(dst.NetworkAddress())
+	s.c.init(&s.opts)
+	s.c.mu.Lock()
+	if _, ok := s.c.links[src]; !ok {
+		s.c.link(src).RxSeq = s.opts.Seq
+	}
+	s.c.mu.Unlock()
+	return s, nil
+}
+
+type sender struct {
+	log  log.Logger
+	src  chain.BTPAddress
+	dst  chain.BTPAddress
+	opts Options
+	c    *Chain
+}
+
+func (s *sender) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return s.c.Status(s.src), nil
+}
+
+func (s *sender) Segment(
+	ctx context.Context, msg *chain.Message,
+) (tx chain.RelayTx, newMsg *chain.Message, err error) {
+	if ctx.Err() != nil {
+		return nil, msg, ctx.Err()
+	}
+	if len(msg.Receipts) == 0 {
+		return nil, msg, nil
+	}
+	n := len(msg.Receipts)
+	if s.opts.MaxReceipts > 0 && n > s.opts.MaxReceipts {
+		n = s.opts.MaxReceipts
+	}
+	rtx := &relayTx{c: s.c, src: s.src, log: s.log}
+	for _, receipt := range msg.Receipts[:n] {
+		rc := *receipt
+		rc.Events = append([]*chain.Event{}, receipt.Events...)
+		rtx.receipts = append(rtx.receipts, &rc)
+	}
+	newMsg = &chain.Message{
+		From:     msg.From,
+		Receipts: msg.Receipts[n:],
+	}
+	return rtx, newMsg, nil
+}
+
+func (s *sender) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return new(big.Int).Set(s.c.balance), &s.opts.BalanceThreshold.Int, nil
+}
+
+type relayTx struct {
+	log      log.Logger
+	c        *Chain
+	src      chain.BTPAddress
+	receipts []*chain.Receipt
+
+	id     uint64
+	height uint64
+	err    error
+}
+
+func (tx *relayTx) ID() interface{} {
+	if tx.id == 0 {
+		return nil
+	}
+	return tx.id
+}
+
+func (tx *relayTx) Send(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if tx.c.failure(FailureSendTimeout) != nil {
+		return context.DeadlineExceeded
+	}
+	if tx.c.failure(FailureInsufficientBalance) != nil || tx.c.balanceIsZero() {
+		return chain.ErrInsufficientBalance
+	}
+	tx.id = atomic.AddUint64(&lastTxID, 1)
+	tx.height, tx.err = tx.c.handleRelayMessage(tx.src, tx.receipts)
+	l := tx.log.WithFields(log.Fields{"id": tx.id, "height": tx.height})
+	if tx.err != nil {
+		l.WithFields(log.Fields{"error": tx.err}).Debug("handleRelayMessage: reverted")
+	} else {
+		l.WithFields(log.Fields{"receipts": len(tx.receipts)}).Debug("handleRelayMessage: success")
+	}
+	return nil
+}
+
+func (tx *relayTx) Receipt(ctx context.Context) (blockHeight uint64, err error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if tx.id == 0 {
+		return 0, fmt.Errorf("no pending tx")
+	}
+	if tx.c.failure(FailureReceiptTimeout) != nil {
+		return 0, context.DeadlineExceeded
+	}
+	if tx.err != nil {
+		return 0, tx.err
+	}
+	return tx.height, nil
+}
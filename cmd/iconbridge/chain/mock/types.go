@@ -0,0 +1,132 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/intconv"
+)
+
+const (
+	defaultBalance = 1e18
+)
+
+type FailureKind string
+
+const (
+	// FailureRevert reverts the next relay tx with Reason
+	FailureRevert FailureKind = "revert"
+	// FailureSendTimeout times out sending the next relay tx
+	FailureSendTimeout FailureKind = "send_timeout"
+	// FailureReceiptTimeout times out getting the next relay tx receipt
+	FailureReceiptTimeout FailureKind = "receipt_timeout"
+	// FailureSubscribeTimeout terminates subscriptions with a timeout
+	FailureSubscribeTimeout FailureKind = "subscribe_timeout"
+	// FailureInsufficientBalance rejects the next relay tx for lack of balance
+	FailureInsufficientBalance FailureKind = "insufficient_balance"
+	// FailureGap makes subscriptions skip the next event
+	FailureGap FailureKind = "gap"
+	// FailureReorg replaces the last Depth blocks, moving their receipts
+	// to new blocks
+	FailureReorg FailureKind = "reorg"
+)
+
+// Failure ...
+// is injected into a simulated chain to make it fail once it reaches
+// height At, Count times
+type Failure struct {
+	Kind FailureKind `json:"kind"`
+	At   uint64      `json:"at,omitempty"`
+	// Count is the number of times to fail, once if zero
+	Count int `json:"count,omitempty"`
+	// Reason of a revert, e.g. "InvalidSeqNumber" or "GasLimitExceeded"
+	Reason string `json:"reason,omitempty"`
+	// Depth of a reorg in blocks
+	Depth uint64 `json:"depth,omitempty"`
+}
+
+func (f *Failure) revertError() error {
+	for _, err := range []error{
+		chain.ErrInsufficientBalance,
+		chain.ErrGasLimitExceeded,
+		chain.ErrBlockGasLimitExceeded,
+	} {
+		if f.Reason == err.Error() {
+			return err
+		}
+	}
+	if err := chain.RevertError(f.Reason); err != nil && f.Reason != "" {
+		return err
+	}
+	return fmt.Errorf("reverted: %s", f.Reason)
+}
+
+// Options ...
+// configures the simulated chain of a sender or receiver; Height and Seq
+// only apply when the chain or the link is created
+type Options struct {
+	// Height of the chain
+	Height uint64 `json:"height,omitempty"`
+	// Seq of the link, i.e. TxSeq for receivers and RxSeq for senders
+	Seq uint64 `json:"seq,omitempty"`
+
+	// BlockInterval makes subscriptions mine a block every interval, e.g. "1s"
+	BlockInterval string `json:"block_interval,omitempty"`
+	// EventInterval makes subscriptions send an event to dst every
+	// EventInterval blocks they mine
+	EventInterval uint64 `json:"event_interval,omitempty"`
+	// Confirmations is the number of blocks to wait for before
+	// delivering receipts of a block
+	Confirmations uint64 `json:"confirmations,omitempty"`
+
+	// MaxReceipts is the maximum number of receipts per relay tx
+	MaxReceipts      int            `json:"max_receipts,omitempty"`
+	Balance          intconv.BigInt `json:"balance,omitempty"`
+	BalanceThreshold intconv.BigInt `json:"balance_threshold,omitempty"`
+
+	Failures []*Failure `json:"failures,omitempty"`
+
+	blockInterval time.Duration
+}
+
+// ValidateOptions checks the options of a sender or receiver, rejecting
+// unknown keys
+func ValidateOptions(rawOpts json.RawMessage) error {
+	if len(rawOpts) == 0 {
+		return nil
+	}
+	opts := &Options{}
+	if err := chain.UnmarshalOptionsStrict(rawOpts, opts); err != nil {
+		return err
+	}
+	return opts.validate()
+}
+
+func (opts *Options) validate() error {
+	if opts.BlockInterval != "" {
+		d, err := time.ParseDuration(opts.BlockInterval)
+		if err != nil {
+			return fmt.Errorf("invalid block_interval: %v", err)
+		}
+		opts.blockInterval = d
+	}
+	if opts.EventInterval > 0 && opts.blockInterval <= 0 {
+		return errors.New("event_interval requires block_interval")
+	}
+	for _, f := range opts.Failures {
+		switch f.Kind {
+		case FailureRevert, FailureSendTimeout, FailureReceiptTimeout,
+			FailureSubscribeTimeout, FailureInsufficientBalance, FailureGap:
+		case FailureReorg:
+			if f.Depth == 0 {
+				return errors.New("reorg failure requires depth")
+			}
+		default:
+			return fmt.Errorf("unknown failure kind: %q", f.Kind)
+		}
+	}
+	return nil
+}
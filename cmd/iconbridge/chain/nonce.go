@@ -0,0 +1,41 @@
+package chain
+
+import "sync"
+
+// NonceTracker ...
+// tracks the nonce of the next tx of an account, so that txs sent in a row
+// don't reuse the nonce of a tx that is still pending on the chain
+type NonceTracker struct {
+	mu sync.Mutex
+	// next is the nonce after the last tx sent, zero if unknown
+	next uint64
+}
+
+// Next returns the nonce of the next tx, given the nonce of the account in
+// the latest block: the greater of it and the nonce after the last tx sent
+func (n *NonceTracker) Next(latest uint64) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.next > latest {
+		return n.next
+	}
+	n.next = 0
+	return latest
+}
+
+// Sent records that a tx of nonce has been sent
+func (n *NonceTracker) Sent(nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if nonce+1 > n.next {
+		n.next = nonce + 1
+	}
+}
+
+// Reset forgets the txs sent, e.g. when one of them is dropped by the
+// chain, so that Next starts over from the nonce of the chain
+func (n *NonceTracker) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.next = 0
+}
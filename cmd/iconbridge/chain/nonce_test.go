@@ -0,0 +1,22 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceTracker(t *testing.T) {
+	var n NonceTracker
+	assert.EqualValues(t, 5, n.Next(5))
+	n.Sent(5)
+	// tx 5 is pending, the latest block still has 5
+	assert.EqualValues(t, 6, n.Next(5))
+	n.Sent(6)
+	assert.EqualValues(t, 7, n.Next(5))
+	// txs are mined
+	assert.EqualValues(t, 9, n.Next(9))
+	n.Sent(9)
+	n.Reset()
+	assert.EqualValues(t, 9, n.Next(9))
+}
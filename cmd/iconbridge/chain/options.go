@@ -0,0 +1,26 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UnmarshalOptionsStrict ...
+// decodes the raw options of a sender or receiver into v like
+// json.Unmarshal, but rejects unknown keys and trailing data
+func UnmarshalOptionsStrict(rawOpts json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(rawOpts)) == 0 {
+		return errors.New("missing options")
+	}
+	d := json.NewDecoder(bytes.NewReader(rawOpts))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.More() {
+		return fmt.Errorf("invalid options: trailing data")
+	}
+	return nil
+}
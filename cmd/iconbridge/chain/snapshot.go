@@ -0,0 +1,47 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/icon-project/icon-bridge/common/db"
+)
+
+// VerifierSnapshotKey is the key under which receivers store the trusted
+// state of their verifier
+const VerifierSnapshotKey = "verifier"
+
+// SaveSnapshot ...
+// stores the JSON encoding of `v` under `key`, prefixed with its checksum
+func SaveSnapshot(store db.Bucket, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	return store.Set([]byte(key), append(sum[:], b...))
+}
+
+// LoadSnapshot ...
+// decodes the snapshot stored under `key` into `v`; returns false if there is
+// no snapshot and ErrInvalidSnapshot if the stored snapshot is corrupted
+func LoadSnapshot(store db.Bucket, key string, v interface{}) (ok bool, err error) {
+	b, err := store.Get([]byte(key))
+	if err != nil {
+		return false, err
+	} else if len(b) == 0 {
+		return false, nil
+	}
+	if len(b) < sha256.Size {
+		return false, ErrInvalidSnapshot
+	}
+	if sum := sha256.Sum256(b[sha256.Size:]); !bytes.Equal(sum[:], b[:sha256.Size]) {
+		return false, ErrInvalidSnapshot
+	}
+	if err := json.Unmarshal(b[sha256.Size:], v); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package chain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/common/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	database := db.NewMapDB()
+	defer database.Close()
+	store, err := database.GetBucket("test")
+	require.NoError(t, err)
+
+	type state struct {
+		Height     uint64
+		Validators []string
+	}
+
+	var got state
+	ok, err := LoadSnapshot(store, VerifierSnapshotKey, &got)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	want := state{Height: 10, Validators: []string{"a", "b"}}
+	require.NoError(t, SaveSnapshot(store, VerifierSnapshotKey, want))
+	ok, err = LoadSnapshot(store, VerifierSnapshotKey, &got)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+
+	// corrupt the stored snapshot
+	b, err := store.Get([]byte(VerifierSnapshotKey))
+	require.NoError(t, err)
+	b[len(b)-2] ^= 0xff
+	require.NoError(t, store.Set([]byte(VerifierSnapshotKey), b))
+	ok, err = LoadSnapshot(store, VerifierSnapshotKey, &got)
+	assert.False(t, ok)
+	assert.True(t, errors.Is(err, ErrInvalidSnapshot))
+
+	require.NoError(t, store.Set([]byte(VerifierSnapshotKey), []byte{0x01}))
+	_, err = LoadSnapshot(store, VerifierSnapshotKey, &got)
+	assert.True(t, errors.Is(err, ErrInvalidSnapshot))
+}
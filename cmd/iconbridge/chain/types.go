@@ -3,6 +3,8 @@ package chain
 import (
 	"context"
 	"math/big"
+
+	"github.com/icon-project/icon-bridge/common/db"
 )
 
 // RelayMessage is encoded
@@ -29,8 +31,20 @@ type Receipt struct {
 }
 
 type Message struct {
-	From     BTPAddress
+	From BTPAddress
+	// Receipts ...
+	// may include events to other BMCs than dst, as told by Event.Next;
+	// only the events to dst are in sequence
 	Receipts []*Receipt
+	// Height ...
+	// is the src chain height upto which all receipts have been delivered;
+	// receivers may send messages without receipts just to report progress
+	Height uint64
+	// Rollback ...
+	// withdraws the receipts above Height delivered before, whose blocks
+	// were orphaned by a reorg of the src chain; the receipts of the blocks
+	// of the new chain follow
+	Rollback bool
 	// Headers  []interface{}
 }
 
@@ -56,6 +70,25 @@ type RelayTx interface {
 	Receipt(ctx context.Context) (blockHeight uint64, err error)
 }
 
+// MeteredRelayTx ...
+// is a RelayTx that reports the gas (or step) it has consumed once
+// its receipt is known
+type MeteredRelayTx interface {
+	RelayTx
+	GasUsed() uint64
+}
+
+// GasLimitRelayTx ...
+// is a RelayTx whose gas (or step) limit can be raised, so that it can be
+// sent again after failing with ErrGasLimitExceeded
+type GasLimitRelayTx interface {
+	RelayTx
+	// IncreaseGasLimit ...
+	// raises the limit for the next Send and returns false if the limit
+	// cannot be raised any further
+	IncreaseGasLimit() bool
+}
+
 type SubscribeOptions struct {
 	Seq    uint64
 	Height uint64
@@ -69,6 +102,28 @@ type Receiver interface {
 	Subscribe(ctx context.Context, msgCh chan<- *Message, opts SubscribeOptions) (errCh <-chan error, err error)
 }
 
+type ReceiverStatus struct {
+	// Height is the latest height of the src chain seen by the receiver
+	Height uint64
+	// VerifiedHeight is the height upto which src blocks are verified
+	VerifiedHeight uint64
+}
+
+// StatusReceiver ...
+// is a Receiver that reports the progress of its subscription
+type StatusReceiver interface {
+	Receiver
+	ReceiverStatus() *ReceiverStatus
+}
+
+// StatefulReceiver ...
+// is a Receiver that persists the trusted state of its verifier in `store`
+// so that it can resume from there instead of the config anchor on restart
+type StatefulReceiver interface {
+	Receiver
+	SetStateStore(store db.Bucket)
+}
+
 type Sender interface {
 	// Status ...
 	// returns current BMCLinkStatus of the dst chain
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/icon-project/icon-bridge/common/cli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newCheckConfigCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-config",
+		Short: "Validate configuration without starting relays",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			probe, _ := cmd.Flags().GetBool("probe")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			report := checkConfig(parentVc, probe)
+			if asJSON {
+				cli.JsonPrettyPrintln(os.Stdout, report)
+			} else {
+				printConfigReport(os.Stdout, parentVc.GetString("config"), report)
+			}
+			if !report.OK() {
+				return fmt.Errorf("invalid config: %d error(s)", report.Errors)
+			}
+			return nil
+		},
+	}
+	parentCmd.AddCommand(cmd)
+	flags := cmd.Flags()
+	flags.Bool("probe", false, "Connect to endpoints and query the link status of dst")
+	flags.Bool("json", false, "Print the report in json")
+	return cmd
+}
+
+// checkConfig ...
+// validates the config with the flags and env vars applied, and reports
+// unknown keys of the config file as errors
+func checkConfig(vc *viper.Viper, probe bool) *relay.ConfigReport {
+	report := &relay.ConfigReport{}
+	var cfg *Config
+	err := checkConfigKeys(vc.GetString("config"))
+	if err == nil {
+		cfg, err = loadConfig(vc)
+	}
+	if err != nil {
+		report.Errors++
+		report.Checks = append(report.Checks, &relay.CheckResult{Check: "config", Error: err.Error()})
+		return report
+	}
+	return relay.CheckConfig(context.Background(), &cfg.Config, relay.CheckOptions{Probe: probe})
+}
+
+// checkConfigKeys rejects unknown keys of the config file
+func checkConfigKeys(file string) error {
+	if file == "" {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d := json.NewDecoder(f)
+	d.DisallowUnknownFields()
+	return d.Decode(&Config{})
+}
+
+func printConfigReport(w io.Writer, file string, r *relay.ConfigReport) {
+	printChecks := func(checks []*relay.CheckResult) {
+		for _, c := range checks {
+			switch {
+			case c.Error != "":
+				fmt.Fprintf(w, "  error    %s: %s\n", c.Check, c.Error)
+			case c.Warning != "":
+				fmt.Fprintf(w, "  warning  %s: %s\n", c.Check, c.Warning)
+			default:
+				fmt.Fprintf(w, "  ok       %s\n", c.Check)
+			}
+		}
+	}
+	if len(r.Checks) > 0 {
+		fmt.Fprintf(w, "config %s\n", file)
+		printChecks(r.Checks)
+	}
+	for _, rr := range r.Relays {
+		fmt.Fprintf(w, "relay %s\n", rr.Name)
+		printChecks(rr.Checks)
+	}
+	fmt.Fprintf(w, "%d error(s), %d warning(s)\n", r.Errors, r.Warnings)
+}
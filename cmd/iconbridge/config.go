@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/stat"
+	"github.com/icon-project/icon-bridge/common/cli"
+	"github.com/icon-project/icon-bridge/common/config"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	config.FileConfig `json:",squash"`
+	relay.Config      `json:",squash"`
+	LogLevel          string               `json:"log_level"`
+	ConsoleLevel      string               `json:"console_level"`
+	LogWriter         *log.WriterConfig    `json:"log_writer,omitempty"`
+	LogForwarder      *log.ForwarderConfig `json:"log_forwarder,omitempty"`
+	StatConfig        *stat.StatConfig     `json:"stat_collector,omitempty"`
+	Metrics           *MetricsConfig       `json:"metrics,omitempty"`
+}
+
+type MetricsConfig struct {
+	// Address to serve /metrics on, e.g. "0.0.0.0:9090"
+	Address string `json:"address"`
+	// Pprof serves the net/http/pprof profiles under /debug/pprof/ on the
+	// same address, disabled by default
+	Pprof bool `json:"pprof,omitempty"`
+}
+
+// relayFlags define or override the single relay of the config
+var relayFlags = []string{
+	"src.address", "src.endpoint", "src.options", "offset",
+	"dst.address", "dst.endpoint", "dst.options",
+	"key_store", "key_password", "key_secret",
+}
+
+// loadConfig ...
+// reads the config file given by --config, if any, and overrides it with
+// the flags and env vars which are set; the paths of the config are not
+// resolved yet, see resolvePaths
+func loadConfig(vc *viper.Viper) (*Config, error) {
+	cfg := &Config{}
+	if file := vc.GetString("config"); file != "" {
+		var err error
+		if cfg, err = readConfig(file); err != nil {
+			return nil, err
+		}
+	}
+	if err := overrideConfig(vc, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func readConfig(file string) (*Config, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg := &Config{}
+	if err = json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolvePaths makes the paths of the config relative to base_dir
+func resolvePaths(cfg *Config) {
+	if cfg.Checkpoint != nil && !filepath.IsAbs(cfg.Checkpoint.DBDir) {
+		// relative to base_dir
+		cfg.Checkpoint.DBDir = filepath.Join(cfg.AbsBaseDir(), cfg.Checkpoint.DBDir)
+	}
+	for _, rc := range cfg.Relays {
+		if rc.DryRun != nil && rc.DryRun.Output != "" && !filepath.IsAbs(rc.DryRun.Output) {
+			rc.DryRun.Output = filepath.Join(cfg.AbsBaseDir(), rc.DryRun.Output)
+		}
+		resolveWalletPaths(&rc.Dst.WalletConfig, cfg.AbsBaseDir())
+		for _, wc := range rc.Dst.Wallets {
+			resolveWalletPaths(wc, cfg.AbsBaseDir())
+		}
+	}
+}
+
+// resolveWalletPaths makes the keystore file and the file secret of wc
+// relative to base
+func resolveWalletPaths(wc *relay.WalletConfig, base string) {
+	var keyStore string
+	if err := json.Unmarshal(wc.KeyStore, &keyStore); err == nil &&
+		keyStore != "" && !filepath.IsAbs(keyStore) {
+		wc.KeyStore, _ = json.Marshal(filepath.Join(base, keyStore))
+	}
+	if wc.KeySecret == "" {
+		return
+	}
+	scheme, name := "", wc.KeySecret
+	if i := strings.Index(name, "://"); i >= 0 {
+		scheme, name = name[:i+len("://")], name[i+len("://"):]
+	}
+	if (scheme == "" || scheme == "file://") && !filepath.IsAbs(name) {
+		wc.KeySecret = scheme + filepath.Join(base, name)
+	}
+}
+
+// overrideConfig ...
+// applies the flags and env vars which are set to cfg. The log levels
+// fall back to the defaults of the flags if the config has none.
+func overrideConfig(vc *viper.Viper, cfg *Config) error {
+	if vc.IsSet("base_dir") {
+		cfg.BaseDir = vc.GetString("base_dir")
+	}
+	if vc.IsSet("log_level") || cfg.LogLevel == "" {
+		cfg.LogLevel = vc.GetString("log_level")
+	}
+	if vc.IsSet("console_level") || cfg.ConsoleLevel == "" {
+		cfg.ConsoleLevel = vc.GetString("console_level")
+	}
+
+	if isSetAny(vc, "log_writer.") {
+		if cfg.LogWriter == nil {
+			cfg.LogWriter = &log.WriterConfig{MaxSize: vc.GetInt("log_writer.maxsize")}
+		}
+		lw := cfg.LogWriter
+		setString(vc, "log_writer.filename", &lw.Filename)
+		setInt(vc, "log_writer.maxsize", &lw.MaxSize)
+		setInt(vc, "log_writer.maxage", &lw.MaxAge)
+		setInt(vc, "log_writer.maxbackups", &lw.MaxBackups)
+		setBool(vc, "log_writer.localtime", &lw.LocalTime)
+		setBool(vc, "log_writer.compress", &lw.Compress)
+	}
+	if isSetAny(vc, "log_forwarder.") {
+		if cfg.LogForwarder == nil {
+			cfg.LogForwarder = &log.ForwarderConfig{Level: vc.GetString("log_forwarder.level")}
+		}
+		lf := cfg.LogForwarder
+		setString(vc, "log_forwarder.vendor", &lf.Vendor)
+		setString(vc, "log_forwarder.address", &lf.Address)
+		setString(vc, "log_forwarder.level", &lf.Level)
+		setString(vc, "log_forwarder.name", &lf.Name)
+		if vc.IsSet("log_forwarder.options") {
+			opts, err := getStringMap(vc, "log_forwarder.options")
+			if err != nil {
+				return fmt.Errorf("log_forwarder.options: %v", err)
+			}
+			if lf.Options == nil {
+				lf.Options = map[string]interface{}{}
+			}
+			for k, v := range opts {
+				lf.Options[k] = v
+			}
+		}
+	}
+	return overrideRelay(vc, cfg)
+}
+
+// overrideRelay ...
+// applies the relay flags to the relay of the config, or to a new one if
+// the config has no relays; they are ambiguous for multiple relays
+func overrideRelay(vc *viper.Viper, cfg *Config) error {
+	set := false
+	for _, key := range relayFlags {
+		set = set || vc.IsSet(key)
+	}
+	if !set {
+		return nil
+	}
+	switch len(cfg.Relays) {
+	case 0:
+		cfg.Relays = []*relay.RelayConfig{{}}
+	case 1:
+	default:
+		return fmt.Errorf("relay flags can't be applied to %d relays of the config", len(cfg.Relays))
+	}
+	rc := cfg.Relays[0]
+
+	for _, c := range []struct {
+		prefix string
+		cfg    *relay.ChainConfig
+	}{
+		{"src.", &rc.Src.ChainConfig},
+		{"dst.", &rc.Dst.ChainConfig},
+	} {
+		if key := c.prefix + "address"; vc.IsSet(key) {
+			c.cfg.Address = chain.BTPAddress(vc.GetString(key))
+		}
+		if key := c.prefix + "endpoint"; vc.IsSet(key) {
+			c.cfg.Endpoint = splitList(vc.GetStringSlice(key))
+		}
+		if key := c.prefix + "options"; vc.IsSet(key) {
+			kvs, err := getStringMap(vc, key)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			opts, err := mergeOptions(c.cfg.Options, kvs)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			c.cfg.Options = opts
+		}
+	}
+	if vc.IsSet("offset") {
+		rc.Src.Offset = uint64(vc.GetInt64("offset"))
+	}
+	if vc.IsSet("key_store") {
+		// referenced by path, not to be inlined on save
+		b, err := json.Marshal(vc.GetString("key_store"))
+		if err != nil {
+			return err
+		}
+		rc.Dst.KeyStore = b
+	}
+	if vc.IsSet("key_password") {
+		rc.Dst.KeyPassword, rc.Dst.KeySecret = vc.GetString("key_password"), ""
+	}
+	if vc.IsSet("key_secret") {
+		rc.Dst.KeySecret, rc.Dst.KeyPassword = vc.GetString("key_secret"), ""
+	}
+	if rc.Name == "" {
+		rc.Name = fmt.Sprintf("%s2%s", rc.Src.Address.BlockChain(), rc.Dst.Address.BlockChain())
+	}
+	return nil
+}
+
+// getStringMap parses the comma-separated 'key=value' options, or a json
+// object, of the flag or env var; viper would take numbers as strings
+func getStringMap(vc *viper.Viper, key string) (map[string]interface{}, error) {
+	if fs, ok := vc.Get("pflags").(*pflag.FlagSet); ok {
+		if f := fs.Lookup(key); f != nil && f.Changed {
+			return cli.GetStringMap(f)
+		}
+	}
+	return cli.StringToStringConv(vc.GetString(key))
+}
+
+// mergeOptions adds the options to the raw options of the config
+func mergeOptions(opts json.RawMessage, kvs map[string]interface{}) (json.RawMessage, error) {
+	m := map[string]json.RawMessage{}
+	if len(opts) > 0 {
+		if err := json.Unmarshal(opts, &m); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range kvs {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = b
+	}
+	return json.Marshal(m)
+}
+
+// splitList splits comma-separated values, as env vars are split by spaces
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+func isSetAny(vc *viper.Viper, prefix string) bool {
+	for _, key := range vc.AllKeys() {
+		if strings.HasPrefix(key, prefix) && vc.IsSet(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func setString(vc *viper.Viper, key string, v *string) {
+	if vc.IsSet(key) {
+		*v = vc.GetString(key)
+	}
+}
+
+func setInt(vc *viper.Viper, key string, v *int) {
+	if vc.IsSet(key) {
+		*v = vc.GetInt(key)
+	}
+}
+
+func setBool(vc *viper.Viper, key string, v *bool) {
+	if vc.IsSet(key) {
+		*v = vc.GetBool(key)
+	}
+}
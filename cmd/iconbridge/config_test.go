@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Override(t *testing.T) {
+	dir, err := ioutil.TempDir("", "iconbridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{
+		"log_level": "warn",
+		"relays": [{
+			"name": "i2b",
+			"src": {"address": "btp://0x1.icon/cx1", "endpoint": ["http://icon"], "options": {"verifier": {"blockHeight": 1}}},
+			"dst": {"address": "btp://0x2.bsc/0x2", "endpoint": ["http://bsc"]}
+		}]
+	}`), 0644))
+	secret := filepath.Join(dir, "secret")
+	require.NoError(t, ioutil.WriteFile(secret, []byte("password\n"), 0600))
+
+	os.Setenv("ICONBRIDGE_DST_ADDRESS", "btp://0x3.bsc/0x3")
+	defer os.Unsetenv("ICONBRIDGE_DST_ADDRESS")
+	rootCmd, rootVc := newRootCommand()
+	require.NoError(t, rootCmd.PersistentFlags().Parse([]string{
+		"--config", file,
+		"--src.options", "syncConcurrency=10",
+		"--dst.endpoint", "http://a,http://b",
+		"--key_secret", secret,
+		"--offset", "100",
+	}))
+
+	cfg, err := loadConfig(rootVc)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, "trace", cfg.ConsoleLevel, "defaults to the flag")
+	assert.Nil(t, cfg.LogWriter)
+	require.Len(t, cfg.Relays, 1)
+	rc := cfg.Relays[0]
+	assert.Equal(t, "i2b", rc.Name)
+	assert.EqualValues(t, "btp://0x3.bsc/0x3", rc.Dst.Address)
+	assert.Equal(t, []string{"http://a", "http://b"}, rc.Dst.Endpoint)
+	assert.Equal(t, []string{"http://icon"}, rc.Src.Endpoint)
+	assert.EqualValues(t, 100, rc.Src.Offset)
+	assert.Equal(t, secret, rc.Dst.KeySecret)
+	var opts map[string]interface{}
+	require.NoError(t, json.Unmarshal(rc.Src.Options, &opts))
+	assert.EqualValues(t, 10, opts["syncConcurrency"])
+	assert.Contains(t, opts, "verifier")
+}
+
+func TestLoadConfig_Flags(t *testing.T) {
+	rootCmd, rootVc := newRootCommand()
+	require.NoError(t, rootCmd.PersistentFlags().Parse([]string{
+		"--src.address", "btp://0x1.icon/cx1",
+		"--dst.address", "btp://0x2.bsc/0x2",
+		"--log_writer.filename", "relay.log",
+	}))
+	cfg, err := loadConfig(rootVc)
+	require.NoError(t, err)
+	require.Len(t, cfg.Relays, 1)
+	assert.Equal(t, "icon2bsc", cfg.Relays[0].Name)
+	require.NotNil(t, cfg.LogWriter)
+	assert.Equal(t, "relay.log", cfg.LogWriter.Filename)
+	assert.Equal(t, 100, cfg.LogWriter.MaxSize)
+}
+
+func TestResolvePaths(t *testing.T) {
+	cfg := &Config{}
+	cfg.BaseDir = "/base"
+	rc := &relay.RelayConfig{Name: "r"}
+	rc.Dst.KeyStore = json.RawMessage(`"keys/ks.json"`)
+	rc.Dst.KeySecret = "secret"
+	rc.Dst.Wallets = []*relay.WalletConfig{
+		{KeyStore: json.RawMessage(`"/keys/ks.json"`), KeySecret: "file://secrets/pw"},
+		{KeyStore: json.RawMessage(`{"address":"hx1"}`), KeySecret: "env://KEY_PASSWORD"},
+	}
+	cfg.Relays = []*relay.RelayConfig{rc}
+
+	resolvePaths(cfg)
+	assert.JSONEq(t, `"/base/keys/ks.json"`, string(rc.Dst.KeyStore))
+	assert.Equal(t, "/base/secret", rc.Dst.KeySecret)
+	assert.JSONEq(t, `"/keys/ks.json"`, string(rc.Dst.Wallets[0].KeyStore), "absolute")
+	assert.Equal(t, "file:///base/secrets/pw", rc.Dst.Wallets[0].KeySecret)
+	assert.JSONEq(t, `{"address":"hx1"}`, string(rc.Dst.Wallets[1].KeyStore), "inline")
+	assert.Equal(t, "env://KEY_PASSWORD", rc.Dst.Wallets[1].KeySecret)
+}
+
+func TestLegacyArgs(t *testing.T) {
+	rootCmd, _ := newRootCommand()
+	for _, tc := range []struct {
+		args, want []string
+	}{
+		{[]string{"-config", "c.json"}, []string{"start", "--config", "c.json"}},
+		{[]string{"-config=c.json", "-watch", "10s"}, []string{"start", "--config=c.json", "--watch", "10s"}},
+		{[]string{"-config", "c.json", "check-config", "-probe"}, []string{"--config", "c.json", "check-config", "--probe"}},
+		{[]string{"-c", "c.json"}, []string{"start", "-c", "c.json"}},
+		{[]string{"start", "--config", "c.json"}, []string{"start", "--config", "c.json"}},
+		{[]string{"--help"}, []string{"--help"}},
+		{[]string{}, []string{}},
+	} {
+		assert.Equal(t, tc.want, legacyArgs(rootCmd, tc.args), "%v", tc.args)
+	}
+}
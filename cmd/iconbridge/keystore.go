@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/icon-project/icon-bridge/common/cli"
+	"github.com/icon-project/icon-bridge/common/crypto"
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newKeyStoreCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	rootCmd := &cobra.Command{Use: "keystore", Short: "Keystore management"}
+	parentCmd.AddCommand(rootCmd)
+
+	newCmd := &cobra.Command{
+		Use:   "new FILE",
+		Short: "Create a keystore with a new key",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pw, err := keyStorePassword(cmd)
+			if err != nil {
+				return err
+			}
+			var ks []byte
+			switch coin, _ := cmd.Flags().GetString("type"); coin {
+			case "icx":
+				sk, _ := crypto.GenerateKeyPair()
+				ks, err = wallet.EncryptKeyAsKeyStore(sk, pw)
+			case "evm":
+				sk, gerr := ethcrypto.GenerateKey()
+				if gerr != nil {
+					return gerr
+				}
+				ks, err = wallet.EncryptEvmKeyAsKeyStore(sk, pw)
+			default:
+				return fmt.Errorf("unsupported keystore type: %q", coin)
+			}
+			if err != nil {
+				return err
+			}
+			if err = ioutil.WriteFile(args[0], ks, 0600); err != nil {
+				return err
+			}
+			w, err := wallet.DecryptKeyStore(ks, pw)
+			if err != nil {
+				return err
+			}
+			cmd.Println(w.Address(), "==>", args[0])
+			return nil
+		},
+	}
+	rootCmd.AddCommand(newCmd)
+	newFlags := newCmd.Flags()
+	newFlags.String("type", "icx", "Type of keystore (icx,evm)")
+	newFlags.String("password", "", "Password of the keystore")
+	newFlags.String("secret", "", "Secret(password) of the keystore, a file or \"env://NAME\"")
+
+	addressCmd := &cobra.Command{
+		Use:   "address FILE",
+		Short: "Decrypt a keystore and print its address",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pw, err := keyStorePassword(cmd)
+			if err != nil {
+				return err
+			}
+			ks, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			w, err := wallet.DecryptKeyStore(ks, pw)
+			if err != nil {
+				return err
+			}
+			cmd.Println(w.Address())
+			return nil
+		},
+	}
+	rootCmd.AddCommand(addressCmd)
+	addressFlags := addressCmd.Flags()
+	addressFlags.String("password", "", "Password of the keystore")
+	addressFlags.String("secret", "", "Secret(password) of the keystore, a file or \"env://NAME\"")
+	return rootCmd
+}
+
+// keyStorePassword resolves the password of --secret or --password
+func keyStorePassword(cmd *cobra.Command) ([]byte, error) {
+	if secret, _ := cmd.Flags().GetString("secret"); secret != "" {
+		return relay.ResolveSecret(secret)
+	}
+	if password, _ := cmd.Flags().GetString("password"); password != "" {
+		return []byte(password), nil
+	}
+	return nil, fmt.Errorf("password is required, use --password or --secret")
+}
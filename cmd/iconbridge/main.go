@@ -2,56 +2,216 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"flag"
+	"fmt"
 	stdlog "log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
-	_ "net/http/pprof"
+	"net/http/pprof"
 
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/stat"
-	"github.com/icon-project/icon-bridge/common/config"
+	"github.com/icon-project/icon-bridge/common"
+	"github.com/icon-project/icon-bridge/common/cli"
 	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 
 	_ "github.com/icon-project/icon-bridge/cmd/iconbridge/chain/bsc"
+	_ "github.com/icon-project/icon-bridge/cmd/iconbridge/chain/eth"
 	_ "github.com/icon-project/icon-bridge/cmd/iconbridge/chain/hmny"
 	_ "github.com/icon-project/icon-bridge/cmd/iconbridge/chain/icon"
+	_ "github.com/icon-project/icon-bridge/cmd/iconbridge/chain/mock"
 )
 
 var (
-	cfgFile string
+	version = "unknown"
+	build   = "unknown"
 )
 
-func init() {
-	flag.StringVar(&cfgFile, "config", "", "multi-relay config.json file")
+func main() {
+	rootCmd, _ := newRootCommand()
+	rootCmd.SetArgs(legacyArgs(rootCmd, os.Args[1:]))
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
 
-type Config struct {
-	config.FileConfig `json:",squash"`
-	relay.Config      `json:",squash"`
-	LogLevel          string               `json:"log_level"`
-	ConsoleLevel      string               `json:"console_level"`
-	LogWriter         *log.WriterConfig    `json:"log_writer,omitempty"`
-	LogForwarder      *log.ForwarderConfig `json:"log_forwarder,omitempty"`
-	StatConfig        *stat.StatConfig     `json:"stat_collector,omitempty"`
+func newRootCommand() (*cobra.Command, *viper.Viper) {
+	rootCmd, rootVc := cli.NewCommand(nil, nil, "iconbridge", "BTP Relay CLI")
+	rootCmd.Long = "Command Line Interface of Relay for Blockchain Transmission Protocol"
+	rootCmd.SilenceUsage = true
+	cli.SetEnvKeyReplacer(rootVc, strings.NewReplacer(".", "_"))
+
+	rootPFlags := rootCmd.PersistentFlags()
+	rootPFlags.String("src.address", "", "BTP Address of source blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
+	rootPFlags.StringSlice("src.endpoint", nil, "Endpoint of source blockchain")
+	rootPFlags.StringToString("src.options", nil, "Options, comma-separated 'key=value'")
+	rootPFlags.String("dst.address", "", "BTP Address of destination blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
+	rootPFlags.StringSlice("dst.endpoint", nil, "Endpoint of destination blockchain")
+	rootPFlags.StringToString("dst.options", nil, "Options, comma-separated 'key=value'")
+	rootPFlags.String("key_store", "", "KeyStore file")
+	rootPFlags.String("key_password", "", "Password of KeyStore")
+	rootPFlags.String("key_secret", "", "Secret(password) file for KeyStore, or \"env://NAME\" of the environment variable")
+	rootPFlags.String("base_dir", "", "Base directory for data")
+	rootPFlags.StringP("config", "c", "", "Parsing configuration file")
+	rootPFlags.Int64("offset", 0, "Offset of MTA")
+	rootPFlags.String("log_level", "debug", "Global log level (trace,debug,info,warn,error,fatal,panic)")
+	rootPFlags.String("console_level", "trace", "Console log level (trace,debug,info,warn,error,fatal,panic)")
+	rootPFlags.String("log_forwarder.vendor", "", "LogForwarder vendor (fluentd,logstash)")
+	rootPFlags.String("log_forwarder.address", "", "LogForwarder address")
+	rootPFlags.String("log_forwarder.level", "info", "LogForwarder level")
+	rootPFlags.String("log_forwarder.name", "", "LogForwarder name")
+	rootPFlags.StringToString("log_forwarder.options", nil, "LogForwarder options, comma-separated 'key=value'")
+	rootPFlags.String("log_writer.filename", "", "Log file name (rotated files resides in same directory)")
+	rootPFlags.Int("log_writer.maxsize", 100, "Maximum log file size in MiB")
+	rootPFlags.Int("log_writer.maxage", 0, "Maximum age of log file in day")
+	rootPFlags.Int("log_writer.maxbackups", 0, "Maximum number of backups")
+	rootPFlags.Bool("log_writer.localtime", false, "Use localtime on rotated log file instead of UTC")
+	rootPFlags.Bool("log_writer.compress", false, "Use gzip on rotated log file")
+	cli.BindPFlags(rootVc, rootPFlags)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print iconbridge version",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("iconbridge version", version, build)
+		},
+	})
+
+	saveCmd := &cobra.Command{
+		Use:   "save [file]",
+		Short: "Save configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(rootVc)
+			if err != nil {
+				return err
+			}
+			saveFilePath := args[0]
+			if err := cli.JsonPrettySaveFile(saveFilePath, 0644, cfg); err != nil {
+				return err
+			}
+			cmd.Println("Save configuration to", saveFilePath)
+			if saveKeyStore, _ := cmd.Flags().GetString("save_key_store"); saveKeyStore != "" {
+				if len(cfg.Relays) != 1 {
+					return fmt.Errorf("save_key_store needs a single relay, config has %d", len(cfg.Relays))
+				}
+				ks, err := cfg.Relays[0].Dst.ReadKeyStore()
+				if err != nil {
+					return err
+				}
+				if err := cli.JsonPrettySaveFile(saveKeyStore, 0600, ks); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	rootCmd.AddCommand(saveCmd)
+	saveCmd.Flags().String("save_key_store", "", "KeyStore File path to save")
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cpuProfile, _ := cmd.Flags().GetString("cpuprofile"); cpuProfile != "" {
+				if err := cli.StartCPUProfile(cpuProfile); err != nil {
+					return fmt.Errorf("fail to start cpu profiling err=%+v", err)
+				}
+			}
+			if memProfile, _ := cmd.Flags().GetString("memprofile"); memProfile != "" {
+				if err := cli.StartMemoryProfile(memProfile); err != nil {
+					return fmt.Errorf("fail to start memory profiling err=%+v", err)
+				}
+			}
+			watch, _ := cmd.Flags().GetDuration("watch")
+			return start(rootVc, watch)
+		},
+	}
+	rootCmd.AddCommand(startCmd)
+	startFlags := startCmd.Flags()
+	startFlags.String("cpuprofile", "", "CPU Profiling data file")
+	startFlags.String("memprofile", "", "Memory Profiling data file")
+	startFlags.Duration("watch", 0,
+		"Interval to check the config file for changes and reload relays, e.g. 10s; also reloaded on SIGHUP")
+
+	newStatusCommand(rootCmd, rootVc)
+	newKeyStoreCommand(rootCmd, rootVc)
+	newCheckConfigCommand(rootCmd, rootVc)
+
+	genMdCmd := cli.NewGenerateMarkdownCommand(rootCmd, rootVc)
+	genMdCmd.Hidden = true
+	return rootCmd, rootVc
 }
 
-func main() {
-	flag.Parse()
+// legacyArgs ...
+// translates the arguments of the former cli, which was based on the std
+// flag package, e.g. `iconbridge -config <file> [check-config -probe]`:
+// the long flags with a single dash get two, and the command defaults
+// to start
+func legacyArgs(rootCmd *cobra.Command, args []string) []string {
+	args = append([]string{}, args...)
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+			continue
+		}
+		name := strings.SplitN(arg[1:], "=", 2)[0]
+		if lookupFlag(rootCmd, name) != nil {
+			args[i] = "-" + arg
+		}
+	}
+	if cmd, _, err := rootCmd.Find(args); err == nil && cmd == rootCmd &&
+		len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		for _, arg := range args {
+			if arg == "-h" || arg == "--help" {
+				return args
+			}
+		}
+		return append([]string{"start"}, args...)
+	}
+	return args
+}
+
+// lookupFlag finds the long flag of the command or its children
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	if f := cmd.PersistentFlags().Lookup(name); f != nil {
+		return f
+	}
+	for _, c := range cmd.Commands() {
+		if f := lookupFlag(c, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
 
-	cfg, err := loadConfig(cfgFile)
+func start(vc *viper.Viper, watch time.Duration) error {
+	cfg, err := loadConfig(vc)
 	if err != nil {
-		log.Fatalf("failed to load config: file=%q, err=%q", cfgFile, err)
+		return fmt.Errorf("failed to load config: file=%q, err=%q", vc.GetString("config"), err)
 	}
+	resolvePaths(cfg)
 
 	l := setLogger(cfg)
-	relay, err := relay.NewMultiRelay(&cfg.Config, l)
+	mr, err := relay.NewMultiRelay(&cfg.Config, l)
 	if err != nil {
-		log.Fatalf("failed to create MultiRelay: %v", err)
+		return fmt.Errorf("failed to create MultiRelay: %v", err)
 	}
 	scollector, err := stat.NewService(
 		cfg.StatConfig,
@@ -61,12 +221,24 @@ func main() {
 	if err != nil {
 		log.Error("failed to create StatCollector for MultiRelay: %v", err)
 	}
-	// for net/http/pprof
-	go func() { http.ListenAndServe("0.0.0.0:6060", nil) }()
-	runRelay(relay, scollector)
+	if cfg.Metrics != nil {
+		go runMetrics(cfg.Metrics, l)
+	}
+	reload := func() (*Config, error) {
+		cfg, err := loadConfig(vc)
+		if err != nil {
+			return nil, err
+		}
+		resolvePaths(cfg)
+		return cfg, nil
+	}
+	runRelay(mr, scollector, l, func(ctx context.Context, rl relay.Reloader) {
+		watchConfig(ctx, rl, vc.GetString("config"), watch, reload, l)
+	})
+	return nil
 }
 
-func runRelay(relay relay.Relay, sc stat.StatCollector) {
+func runRelay(r relay.Relay, sc stat.StatCollector, l log.Logger, watch func(context.Context, relay.Reloader)) {
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -91,23 +263,81 @@ func runRelay(relay relay.Relay, sc stat.StatCollector) {
 		log.Error(err)
 	}
 
-	if err := relay.Start(ctx); err != nil {
+	if rl, ok := r.(relay.Reloader); ok {
+		go watch(ctx, rl)
+	}
+
+	if err := r.Start(ctx); err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
 }
 
-func loadConfig(file string) (*Config, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
+func runMetrics(cfg *MetricsConfig, l log.Logger) {
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	if cfg.Pprof {
+		e.GET("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+		e.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+		e.GET("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+		e.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+		e.GET("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
 	}
-	cfg := &Config{}
-	err = json.NewDecoder(f).Decode(cfg)
-	if err != nil {
-		return nil, err
+	srv := common.NewHttpServer(cfg.Address, e)
+	l.Infof("serving metrics on %s", srv.Address())
+	if err := srv.Start(); err != nil {
+		l.Errorf("metrics server: %v", err)
+	}
+}
+
+// watchConfig ...
+// reloads the relays of the config file on SIGHUP, or when the file is
+// modified if interval is set
+func watchConfig(
+	ctx context.Context, rl relay.Reloader, file string, interval time.Duration,
+	load func() (*Config, error), l log.Logger) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	var tick <-chan time.Time
+	modTime := func() time.Time {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}
+		}
+		return fi.ModTime()
+	}
+	lastMod := modTime()
+	if interval > 0 && file != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			l.Info("reloading config on SIGHUP")
+		case <-tick:
+			mt := modTime()
+			if mt.Equal(lastMod) {
+				continue
+			}
+			lastMod = mt
+			l.Info("reloading modified config")
+		}
+		cfg, err := load()
+		if err == nil {
+			err = rl.Reload(&cfg.Config)
+		}
+		if err != nil {
+			l.Errorf("failed to reload config: file=%q, err=%q", file, err)
+		}
 	}
-	return cfg, nil
 }
 
 func setLogger(cfg *Config) log.Logger {
@@ -0,0 +1,272 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	adminQueryTimeout = 5 * time.Second
+
+	RelayStateStopped = "stopped"
+	RelayStateRunning = "running"
+	RelayStatePaused  = "paused"
+	// RelayStateFailed means the relay exhausted its restart budget
+	RelayStateFailed = "failed"
+	// RelayStateStandby means another relayer of the link is the leader
+	RelayStateStandby = "standby"
+)
+
+type AdminConfig struct {
+	// Address to serve the admin api on, e.g. "127.0.0.1:9091"
+	Address string `json:"address"`
+}
+
+type RelayInfo struct {
+	Name  string           `json:"name"`
+	Src   chain.BTPAddress `json:"src"`
+	Dst   chain.BTPAddress `json:"dst"`
+	State string           `json:"state"`
+	// DryRun is true if the relay doesn't send transactions
+	DryRun bool                 `json:"dry_run,omitempty"`
+	Link   *chain.BMCLinkStatus `json:"link,omitempty"`
+	// Height is the src height upto which receipts have been received
+	Height  uint64      `json:"height"`
+	Pending PendingInfo `json:"pending"`
+	// Wallets are the status of the wallets of a relay with several
+	// wallets on dst
+	Wallets []WalletInfo `json:"wallets,omitempty"`
+	// SrcEndpoints and DstEndpoints are the health of the endpoints of
+	// src and dst, if tracked
+	SrcEndpoints []chain.EndpointStatus `json:"src_endpoints,omitempty"`
+	DstEndpoints []chain.EndpointStatus `json:"dst_endpoints,omitempty"`
+}
+
+// PendingInfo describes the receipts yet to be relayed
+type PendingInfo struct {
+	Receipts int    `json:"receipts"`
+	SeqBegin uint64 `json:"seq_begin,omitempty"`
+	SeqEnd   uint64 `json:"seq_end,omitempty"`
+}
+
+func (r *relay) Info() RelayInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info := r.info
+	info.Name, info.Src, info.Dst = r.cfg.Name, r.cfg.Src.Address, r.cfg.Dst.Address
+	info.DryRun = r.cfg.DryRun != nil
+	if er, ok := r.src.(chain.EndpointReporter); ok {
+		info.SrcEndpoints = er.Endpoints()
+	}
+	if er, ok := r.dst.(chain.EndpointReporter); ok {
+		info.DstEndpoints = er.Endpoints()
+	}
+	switch {
+	case r.failed:
+		info.State = RelayStateFailed
+	case !r.running:
+		info.State = RelayStateStopped
+	case r.paused:
+		info.State = RelayStatePaused
+	case r.standby:
+		info.State = RelayStateStandby
+	default:
+		info.State = RelayStateRunning
+	}
+	return info
+}
+
+// Pause stops relaying messages to dst, while still receiving from src
+func (r *relay) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = true
+}
+
+func (r *relay) Resume() {
+	r.mu.Lock()
+	r.paused = false
+	r.mu.Unlock()
+	r.Signal()
+}
+
+func (r *relay) isPaused() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.paused
+}
+
+// Signal triggers relaying pending messages without waiting for the ticker
+func (r *relay) Signal() {
+	select {
+	case r.relayCh <- struct{}{}:
+	default:
+	}
+}
+
+// Restart stops the running relay, to be started again by multiRelay
+func (r *relay) Restart() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cancel == nil {
+		return false
+	}
+	r.cancel()
+	return true
+}
+
+// Receipts returns a copy of the receipts pending in the relay loop
+func (r *relay) Receipts(ctx context.Context) ([]*chain.Receipt, error) {
+	ch := make(chan []*chain.Receipt, 1)
+	query := func(srcMsg *chain.Message) {
+		receipts := make([]*chain.Receipt, 0, len(srcMsg.Receipts))
+		for _, receipt := range srcMsg.Receipts {
+			rc := *receipt
+			rc.Events = append([]*chain.Event{}, receipt.Events...)
+			receipts = append(receipts, &rc)
+		}
+		ch <- receipts
+	}
+	select {
+	case r.queryCh <- query:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return <-ch, nil
+}
+
+func (r *relay) setRunning(running bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = running
+}
+
+func (r *relay) setFailed(failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = failed
+}
+
+func (r *relay) setLinkInfo(link *chain.BMCLinkStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info.Link = link
+}
+
+func (r *relay) setWalletInfo(wallets []WalletInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info.Wallets = wallets
+}
+
+func (r *relay) setPendingInfo(height uint64, srcMsg *chain.Message) {
+	pending := PendingInfo{Receipts: len(srcMsg.Receipts)}
+	if n := len(srcMsg.Receipts); n > 0 {
+		first, last := srcMsg.Receipts[0], srcMsg.Receipts[n-1]
+		if len(first.Events) > 0 && len(last.Events) > 0 {
+			pending.SeqBegin = first.Events[0].Sequence
+			pending.SeqEnd = last.Events[len(last.Events)-1].Sequence
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.info.Height, r.info.Pending = height, pending
+}
+
+func (mr *multiRelay) relay(name string) (*relay, error) {
+	for _, r := range mr.list() {
+		if r.cfg.Name == name {
+			return r, nil
+		}
+	}
+	return nil, echo.NewHTTPError(http.StatusNotFound, "relay not found: "+name)
+}
+
+// newAdminEcho ...
+// returns the handlers of the admin api:
+//
+//	GET  /relays                   list relays
+//	GET  /relays/:name             get relay
+//	GET  /relays/:name/receipts    dump pending receipts
+//	POST /relays/:name/pause       stop relaying to dst
+//	POST /relays/:name/resume      resume relaying to dst
+//	POST /relays/:name/restart     restart relay, also a failed one
+//	POST /relays/:name/signal      relay pending receipts now
+func (mr *multiRelay) newAdminEcho() *echo.Echo {
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+
+	e.GET("/relays", func(c echo.Context) error {
+		relays := mr.list()
+		infos := make([]RelayInfo, 0, len(relays))
+		for _, r := range relays {
+			infos = append(infos, r.Info())
+		}
+		return c.JSON(http.StatusOK, infos)
+	})
+
+	g := e.Group("/relays/:name")
+	g.GET("", func(c echo.Context) error {
+		r, err := mr.relay(c.Param("name"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, r.Info())
+	})
+	g.GET("/receipts", func(c echo.Context) error {
+		r, err := mr.relay(c.Param("name"))
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Request().Context(), adminQueryTimeout)
+		defer cancel()
+		receipts, err := r.Receipts(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "relay busy or stopped")
+		}
+		return c.JSON(http.StatusOK, receipts)
+	})
+
+	action := func(fn func(r *relay) error) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r, err := mr.relay(c.Param("name"))
+			if err != nil {
+				return err
+			}
+			if err = fn(r); err != nil {
+				return err
+			}
+			return c.JSON(http.StatusOK, r.Info())
+		}
+	}
+	g.POST("/pause", action(func(r *relay) error {
+		r.log.Info("admin: pause")
+		r.Pause()
+		return nil
+	}))
+	g.POST("/resume", action(func(r *relay) error {
+		r.log.Info("admin: resume")
+		r.Resume()
+		return nil
+	}))
+	g.POST("/restart", action(func(r *relay) error {
+		r.log.Info("admin: restart")
+		if mr.requeue(r) {
+			return nil
+		}
+		if !r.Restart() {
+			return echo.NewHTTPError(http.StatusConflict, "relay not started")
+		}
+		return nil
+	}))
+	g.POST("/signal", action(func(r *relay) error {
+		r.log.Debug("admin: signal")
+		r.Signal()
+		return nil
+	}))
+	return e
+}
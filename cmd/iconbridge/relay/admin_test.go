@@ -0,0 +1,125 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAdmin(t *testing.T, names ...string) (*multiRelay, *httptest.Server) {
+	mr := &multiRelay{log: log.New()}
+	for _, name := range names {
+		mr.relays = append(mr.relays, newRelay(&RelayConfig{Name: name}, nil, nil, nil, log.New()))
+	}
+	srv := httptest.NewServer(mr.newAdminEcho())
+	t.Cleanup(srv.Close)
+	return mr, srv
+}
+
+func adminRequest(t *testing.T, method, url string, v interface{}) int {
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp.StatusCode
+}
+
+func TestAdmin_ListAndControl(t *testing.T) {
+	mr, srv := newTestAdmin(t, "r1", "r2")
+
+	var infos []RelayInfo
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodGet, srv.URL+"/relays", &infos))
+	require.Len(t, infos, 2)
+	assert.Equal(t, "r1", infos[0].Name)
+	assert.Equal(t, RelayStateStopped, infos[0].State)
+
+	assert.Equal(t, http.StatusNotFound, adminRequest(t, http.MethodGet, srv.URL+"/relays/r3", nil))
+
+	r1 := mr.relays[0]
+	r1.setRunning(true)
+	r1.setLinkInfo(&chain.BMCLinkStatus{RxSeq: 7})
+
+	var info RelayInfo
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/pause", &info))
+	assert.Equal(t, RelayStatePaused, info.State)
+	assert.EqualValues(t, 7, info.Link.RxSeq)
+	assert.Equal(t, RelayStateStopped, mr.relays[1].Info().State)
+
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/resume", &info))
+	assert.Equal(t, RelayStateRunning, info.State)
+	select {
+	case <-r1.relayCh:
+	default:
+		t.Error("resume did not signal relay")
+	}
+
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/signal", nil))
+	assert.Len(t, r1.relayCh, 1)
+
+	assert.Equal(t, http.StatusConflict, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/restart", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	require.True(t, r1.begin(cancel))
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/restart", nil))
+	assert.Error(t, ctx.Err())
+}
+
+func TestAdmin_Receipts(t *testing.T) {
+	mr, srv := newTestAdmin(t, "r1")
+	r1 := mr.relays[0]
+
+	srcMsg := &chain.Message{Receipts: []*chain.Receipt{newTestReceipt(10, 1, 2)}}
+	r1.setPendingInfo(10, srcMsg)
+	go func() { // relay loop
+		for query := range r1.queryCh {
+			query(srcMsg)
+		}
+	}()
+	defer close(r1.queryCh)
+
+	var receipts []*chain.Receipt
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodGet, srv.URL+"/relays/r1/receipts", &receipts))
+	require.Len(t, receipts, 1)
+	assert.Len(t, receipts[0].Events, 2)
+
+	info := r1.Info()
+	assert.Equal(t, PendingInfo{Receipts: 1, SeqBegin: 1, SeqEnd: 2}, info.Pending)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := newRelay(&RelayConfig{Name: "idle"}, nil, nil, nil, log.New()).Receipts(ctx)
+	assert.Error(t, err)
+}
+
+func TestAdmin_RestartFailed(t *testing.T) {
+	mr, srv := newTestAdmin(t, "r1")
+	r1 := mr.relays[0]
+	r1.policy.Restart.MaxRetries = 1
+	r1.policy.Restart.Delay = Duration(time.Millisecond)
+	mr.rch = make(chan *relay, 1)
+
+	ctx := context.Background()
+	mr.restart(ctx, r1, 0) // first failure within budget
+	require.Len(t, mr.rch, 1)
+	<-mr.rch
+	mr.restart(ctx, r1, 0)
+	assert.Len(t, mr.rch, 0)
+	assert.Equal(t, RelayStateFailed, r1.Info().State)
+
+	var info RelayInfo
+	require.Equal(t, http.StatusOK, adminRequest(t, http.MethodPost, srv.URL+"/relays/r1/restart", &info))
+	assert.Equal(t, RelayStateStopped, info.State)
+	assert.Len(t, mr.rch, 1)
+	assert.Zero(t, r1.restarts)
+}
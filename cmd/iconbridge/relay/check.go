@@ -0,0 +1,244 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+)
+
+const (
+	checkProbeTimeout = 10 * time.Second
+)
+
+// ValidateOptionsFunc checks the raw options of a sender or receiver
+type ValidateOptionsFunc func(opts json.RawMessage) error
+
+var (
+	SenderOptionValidators   = map[string]ValidateOptionsFunc{}
+	ReceiverOptionValidators = map[string]ValidateOptionsFunc{}
+)
+
+// CheckOptions configures CheckConfig
+type CheckOptions struct {
+	// Probe connects to the endpoints and queries the link status of dst
+	Probe bool
+	// ProbeTimeout limits each probe, 10s by default
+	ProbeTimeout time.Duration
+}
+
+// ConfigReport is the result of CheckConfig
+type ConfigReport struct {
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Checks   []*CheckResult `json:"checks,omitempty"`
+	Relays   []*RelayReport `json:"relays"`
+}
+
+// RelayReport has the checks of a relay in the config
+type RelayReport struct {
+	Name   string         `json:"name"`
+	Checks []*CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of a check, e.g. "dst.wallet"; it passed if
+// it has neither an error nor a warning
+type CheckResult struct {
+	Check   string `json:"check"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// OK tells if the config passed all checks, maybe with warnings
+func (r *ConfigReport) OK() bool {
+	return r.Errors == 0
+}
+
+func (r *ConfigReport) add(checks *[]*CheckResult, check string, err error, warn bool) {
+	res := &CheckResult{Check: check}
+	switch {
+	case err == nil:
+	case warn:
+		res.Warning = err.Error()
+		r.Warnings++
+	default:
+		res.Error = err.Error()
+		r.Errors++
+	}
+	*checks = append(*checks, res)
+}
+
+// CheckConfig ...
+// validates the relays of cfg without starting them and reports the
+// result of every check instead of failing on the first error
+func CheckConfig(ctx context.Context, cfg *Config, opts CheckOptions) *ConfigReport {
+	r := &ConfigReport{}
+	if len(cfg.Relays) == 0 {
+		r.add(&r.Checks, "relays", fmt.Errorf("no relays"), false)
+	}
+	if cfg.Checkpoint != nil {
+		var err error
+		if cfg.Checkpoint.DBDir == "" {
+			err = fmt.Errorf("empty db_dir")
+		}
+		r.add(&r.Checks, "checkpoint", err, false)
+	}
+	if cfg.Admin != nil {
+		_, _, err := net.SplitHostPort(cfg.Admin.Address)
+		r.add(&r.Checks, "admin.address", err, false)
+	}
+
+	names := map[string]bool{}
+	for _, rc := range cfg.Relays {
+		rr := &RelayReport{Name: rc.Name}
+		r.Relays = append(r.Relays, rr)
+		add := func(check string, err error) { r.add(&rr.Checks, check, err, false) }
+
+		var err error
+		if rc.Name == "" {
+			err = fmt.Errorf("empty name")
+		} else if names[rc.Name] {
+			err = fmt.Errorf("duplicate name")
+		}
+		names[rc.Name] = true
+		add("name", err)
+
+		srcChain, dstChain := rc.Src.Address.BlockChain(), rc.Dst.Address.BlockChain()
+		add("src.address", checkAddress(rc.Src.Address, Receivers[srcChain] != nil))
+		add("dst.address", checkAddress(rc.Dst.Address, Senders[dstChain] != nil))
+		checkEndpoints := func(check string, endpoints []string) {
+			if len(endpoints) == 0 {
+				// left to the chains that connect to them
+				r.add(&rr.Checks, check, fmt.Errorf("empty endpoint"), true)
+			} else {
+				add(check, validateEndpoints(endpoints))
+			}
+		}
+		checkEndpoints("src.endpoint", rc.Src.Endpoint)
+		checkEndpoints("dst.endpoint", rc.Dst.Endpoint)
+		if validate, ok := ReceiverOptionValidators[srcChain]; ok {
+			add("src.options", validate(rc.Src.Options))
+		}
+		if validate, ok := SenderOptionValidators[dstChain]; ok {
+			add("dst.options", validate(rc.Dst.Options))
+		}
+		add("policy", rc.Policy.Validate())
+		_, err = newCoordinator(rc)
+		add("coordinator", err)
+
+		var w wallet.Wallet
+		ws, err := rc.Dst.WalletPool()
+		if err == nil {
+			w = ws[0]
+		}
+		add("dst.wallet", err)
+
+		var reverse error
+		if !hasRelay(cfg, rc.Dst.Address, rc.Src.Address) {
+			reverse = fmt.Errorf("no relay from %s to %s", rc.Dst.Address, rc.Src.Address)
+		}
+		r.add(&rr.Checks, "reverse", reverse, true)
+
+		if !opts.Probe {
+			continue
+		}
+		timeout := opts.ProbeTimeout
+		if timeout <= 0 {
+			timeout = checkProbeTimeout
+		}
+		add("src.probe", probeEndpoints(ctx, rc.Src.Endpoint, timeout))
+		add("dst.probe", probeEndpoints(ctx, rc.Dst.Endpoint, timeout))
+		if newSender, ok := Senders[dstChain]; ok && w != nil && rr.passed("dst.options") {
+			add("dst.status", probeStatus(ctx, rc, newSender, w, timeout))
+		}
+	}
+	return r
+}
+
+// passed tells if the check of the relay passed
+func (rr *RelayReport) passed(check string) bool {
+	for _, res := range rr.Checks {
+		if res.Check == check {
+			return res.Error == ""
+		}
+	}
+	return true
+}
+
+func checkAddress(addr chain.BTPAddress, supported bool) error {
+	if err := chain.ValidateBtpAddress(addr); err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("unsupported blockchain: %s", addr.BlockChain())
+	}
+	return nil
+}
+
+func validateEndpoints(endpoints []string) error {
+	for _, ep := range endpoints {
+		if u, err := url.Parse(ep); err != nil {
+			return err
+		} else if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid endpoint: %q", ep)
+		}
+	}
+	return nil
+}
+
+func hasRelay(cfg *Config, src, dst chain.BTPAddress) bool {
+	for _, rc := range cfg.Relays {
+		if rc.Src.Address.Equal(src) && rc.Dst.Address.Equal(dst) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeEndpoints connects to the host of every endpoint
+func probeEndpoints(ctx context.Context, endpoints []string, timeout time.Duration) error {
+	for _, ep := range endpoints {
+		u, err := url.Parse(ep)
+		if err != nil {
+			return err
+		}
+		host := u.Host
+		if u.Port() == "" {
+			switch u.Scheme {
+			case "https", "wss":
+				host = net.JoinHostPort(u.Hostname(), "443")
+			default:
+				host = net.JoinHostPort(u.Hostname(), "80")
+			}
+		}
+		d := net.Dialer{Timeout: timeout}
+		conn, err := d.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return fmt.Errorf("%s: %v", ep, err)
+		}
+		conn.Close()
+	}
+	return nil
+}
+
+// probeStatus queries the link status of dst with the sender of the relay
+func probeStatus(
+	ctx context.Context, rc *RelayConfig, newSender NewSenderFunc,
+	w wallet.Wallet, timeout time.Duration) error {
+	l := log.New()
+	l.SetLevel(log.WarnLevel)
+	dst, err := newSender(rc.Src.Address, rc.Dst.Address, rc.Dst.Endpoint, w, rc.Dst.Options, l)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err = dst.Status(ctx)
+	return err
+}
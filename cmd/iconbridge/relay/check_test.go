@@ -0,0 +1,68 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkResult(r *RelayReport, check string) *CheckResult {
+	for _, res := range r.Checks {
+		if res.Check == check {
+			return res
+		}
+	}
+	return nil
+}
+
+func TestCheckConfig(t *testing.T) {
+	keyStore, err := wallet.KeyStoreFromWallet(wallet.New(), []byte(testKeyPassword))
+	require.NoError(t, err)
+	cfg := newTestConfig(keyStore, "a", "b", "b")
+	a, b, c := cfg.Relays[0], cfg.Relays[1], cfg.Relays[2]
+	a.Src.Endpoint = []string{"http://127.0.0.1:1"}
+	a.Src.Options = json.RawMessage(`{"verifier":{},"sync_concurrency":1}`)
+	a.Policy = &PolicyConfig{TickerInterval: -1}
+	b.Src.Address, b.Dst.Address = a.Dst.Address, a.Src.Address
+	b.Src.Options = json.RawMessage(`{"verifier":{}}`)
+	c.Dst.Address = "btp://0x3.unknown/0x3"
+	c.Dst.Endpoint = []string{"127.0.0.1:8080"}
+	c.Dst.KeyPassword = "wrong"
+
+	report := CheckConfig(context.Background(), cfg, CheckOptions{Probe: true})
+	require.Len(t, report.Relays, 3)
+	assert.False(t, report.OK())
+
+	for _, tc := range []struct {
+		relay   int
+		check   string
+		error   bool
+		warning bool
+	}{
+		{0, "name", false, false},
+		{0, "src.address", false, false},
+		{0, "src.endpoint", false, false},
+		{0, "dst.endpoint", false, true},
+		{0, "src.options", true, false},
+		{0, "policy", true, false},
+		{0, "dst.wallet", false, false},
+		{0, "reverse", false, false},
+		{0, "src.probe", true, false},
+		{1, "src.options", false, false},
+		{1, "reverse", false, false},
+		{2, "name", true, false},
+		{2, "dst.address", true, false},
+		{2, "dst.endpoint", true, false},
+		{2, "dst.wallet", true, false},
+		{2, "reverse", false, true},
+	} {
+		res := checkResult(report.Relays[tc.relay], tc.check)
+		require.NotNil(t, res, "%d %s", tc.relay, tc.check)
+		assert.Equal(t, tc.error, res.Error != "", "%d %+v", tc.relay, res)
+		assert.Equal(t, tc.warning, res.Warning != "", "%d %+v", tc.relay, res)
+	}
+}
@@ -0,0 +1,142 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/db"
+)
+
+const (
+	DefaultCheckpointDBType = string(db.GoLevelDBBackend)
+	DefaultCheckpointDBName = "checkpoint"
+
+	checkpointBucket db.BucketID = "checkpoint"
+	verifierBucket   db.BucketID = "verifier"
+)
+
+type CheckpointConfig struct {
+	// DBType is one of goleveldb, badgerdb or mapdb
+	DBType string `json:"db_type"`
+	// DBDir is the directory of the database
+	DBDir  string `json:"db_dir"`
+	DBName string `json:"db_name,omitempty"`
+}
+
+// Checkpoint ...
+// is the state of a relay that is persisted whenever it changes,
+// so that a restarted relay can resume from where it left off instead
+// of rescanning the src chain from link.RxHeight
+type Checkpoint struct {
+	// Height is the src chain height upto which receipts have been received
+	Height uint64 `json:"height"`
+	// Seq is the sequence of the last event received from the src chain
+	Seq uint64 `json:"seq"`
+	// Receipts are received but not yet confirmed on the dst chain
+	Receipts []*chain.Receipt `json:"receipts,omitempty"`
+	// TxIDs are sent to the dst chain but their receipts are not yet known
+	TxIDs []string `json:"tx_ids,omitempty"`
+}
+
+type CheckpointStore interface {
+	// Load returns nil if there is no checkpoint for the relay
+	Load(name string) (*Checkpoint, error)
+	Save(name string, cp *Checkpoint) error
+	// StateStore returns the bucket where the src receiver of the relay
+	// persists its verifier state
+	StateStore(name string) (db.Bucket, error)
+	Close() error
+}
+
+func NewCheckpointStore(cfg *CheckpointConfig) (CheckpointStore, error) {
+	dbType, dbName := cfg.DBType, cfg.DBName
+	if dbType == "" {
+		dbType = DefaultCheckpointDBType
+	}
+	if dbName == "" {
+		dbName = DefaultCheckpointDBName
+	}
+	database, err := db.Open(cfg.DBDir, dbType, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint db: %v", err)
+	}
+	bucket, err := database.GetBucket(checkpointBucket)
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+	return &checkpointStore{db: database, bucket: bucket}, nil
+}
+
+type checkpointStore struct {
+	mu     sync.Mutex
+	db     db.Database
+	bucket db.Bucket
+}
+
+func (s *checkpointStore) Load(name string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket.Get([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	return cp, nil
+}
+
+func (s *checkpointStore) Save(name string, cp *Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bucket.Set([]byte(name), b)
+}
+
+func (s *checkpointStore) StateStore(name string) (db.Bucket, error) {
+	bucket, err := s.db.GetBucket(verifierBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &prefixBucket{Bucket: bucket, prefix: []byte(name + "/")}, nil
+}
+
+func (s *checkpointStore) Close() error {
+	return s.db.Close()
+}
+
+// prefixBucket scopes the keys of a shared bucket to a single relay
+type prefixBucket struct {
+	db.Bucket
+	prefix []byte
+}
+
+func (b *prefixBucket) key(k []byte) []byte {
+	return append(append([]byte{}, b.prefix...), k...)
+}
+
+func (b *prefixBucket) Get(key []byte) ([]byte, error) {
+	return b.Bucket.Get(b.key(key))
+}
+
+func (b *prefixBucket) Has(key []byte) bool {
+	return b.Bucket.Has(b.key(key))
+}
+
+func (b *prefixBucket) Set(key []byte, value []byte) error {
+	return b.Bucket.Set(b.key(key), value)
+}
+
+func (b *prefixBucket) Delete(key []byte) error {
+	return b.Bucket.Delete(b.key(key))
+}
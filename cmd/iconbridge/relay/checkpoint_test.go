@@ -0,0 +1,166 @@
+package relay
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/db"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCheckpointStore(t *testing.T) CheckpointStore {
+	cps, err := NewCheckpointStore(&CheckpointConfig{DBType: string(db.MapDBBackend)})
+	require.NoError(t, err)
+	return cps
+}
+
+func newTestReceipt(height uint64, seqs ...uint64) *chain.Receipt {
+	receipt := &chain.Receipt{Height: height}
+	for _, seq := range seqs {
+		receipt.Events = append(receipt.Events, &chain.Event{Sequence: seq})
+	}
+	return receipt
+}
+
+func TestCheckpointStore_SaveLoad(t *testing.T) {
+	cps := newTestCheckpointStore(t)
+	defer cps.Close()
+
+	cp, err := cps.Load("relay")
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	want := &Checkpoint{
+		Height:   100,
+		Seq:      3,
+		Receipts: []*chain.Receipt{newTestReceipt(99, 2, 3)},
+		TxIDs:    []string{"0x01"},
+	}
+	require.NoError(t, cps.Save("relay", want))
+
+	cp, err = cps.Load("relay")
+	require.NoError(t, err)
+	assert.Equal(t, want, cp)
+
+	cp, err = cps.Load("other")
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+}
+
+func TestCheckpointStore_StateStore(t *testing.T) {
+	cps := newTestCheckpointStore(t)
+	defer cps.Close()
+
+	s1, err := cps.StateStore("r1")
+	require.NoError(t, err)
+	s2, err := cps.StateStore("r2")
+	require.NoError(t, err)
+
+	require.NoError(t, s1.Set([]byte("k"), []byte("v1")))
+	assert.True(t, s1.Has([]byte("k")))
+	assert.False(t, s2.Has([]byte("k")))
+
+	v, err := s1.Get([]byte("k"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+}
+
+func TestRelay_LoadCheckpoint(t *testing.T) {
+	cps := newTestCheckpointStore(t)
+	defer cps.Close()
+
+	r := newRelay(&RelayConfig{Name: "relay"}, nil, nil, cps, log.New())
+
+	testCases := []struct {
+		name  string
+		cp    *Checkpoint
+		rxSeq uint64
+		ok    bool
+		seqs  []uint64
+	}{
+		{
+			name:  "resume",
+			cp:    &Checkpoint{Height: 10, Seq: 4, Receipts: []*chain.Receipt{newTestReceipt(8, 2, 3), newTestReceipt(9, 4)}},
+			rxSeq: 2,
+			ok:    true,
+			seqs:  []uint64{3, 4},
+		},
+		{
+			name:  "caught up",
+			cp:    &Checkpoint{Height: 10, Seq: 4, Receipts: []*chain.Receipt{newTestReceipt(8, 3, 4)}},
+			rxSeq: 4,
+			ok:    true,
+		},
+		{
+			name:  "behind link",
+			cp:    &Checkpoint{Height: 10, Seq: 4},
+			rxSeq: 5,
+		},
+		{
+			name:  "missing events",
+			cp:    &Checkpoint{Height: 10, Seq: 4, Receipts: []*chain.Receipt{newTestReceipt(9, 4)}},
+			rxSeq: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, cps.Save("relay", tc.cp))
+			cp := r.loadCheckpoint(&chain.BMCLinkStatus{RxSeq: tc.rxSeq})
+			if !tc.ok {
+				assert.Nil(t, cp)
+				return
+			}
+			require.NotNil(t, cp)
+			var seqs []uint64
+			for _, receipt := range cp.Receipts {
+				for _, event := range receipt.Events {
+					seqs = append(seqs, event.Sequence)
+				}
+			}
+			assert.Equal(t, tc.seqs, seqs)
+		})
+	}
+}
+
+type closeCountingStore struct {
+	CheckpointStore
+	mu     sync.Mutex
+	closed int
+}
+
+func (s *closeCountingStore) Close() error {
+	s.mu.Lock()
+	s.closed++
+	s.mu.Unlock()
+	return s.CheckpointStore.Close()
+}
+
+func TestMultiRelay_CloseCheckpointStore(t *testing.T) {
+	keyStore, err := wallet.KeyStoreFromWallet(wallet.New(), []byte(testKeyPassword))
+	require.NoError(t, err)
+	cfg := newTestConfig(keyStore, "a")
+	cfg.Checkpoint = &CheckpointConfig{DBType: string(db.MapDBBackend)}
+	r, err := NewMultiRelay(cfg, log.New())
+	require.NoError(t, err)
+	mr := r.(*multiRelay)
+	cps := &closeCountingStore{CheckpointStore: mr.cps}
+	mr.cps = cps
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mr.Start(ctx) }()
+	waitRunning(t, mr, "a")
+	cps.mu.Lock()
+	assert.Zero(t, cps.closed)
+	cps.mu.Unlock()
+	cancel()
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+	assert.Equal(t, 1, cps.closed)
+	assert.NotEqual(t, RelayStateRunning, mr.list()[0].Info().State)
+}
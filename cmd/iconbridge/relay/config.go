@@ -2,6 +2,10 @@ package relay
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
 
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
 	"github.com/icon-project/icon-bridge/common/wallet"
@@ -12,13 +16,21 @@ const (
 )
 
 type Config struct {
-	Relays []*RelayConfig `json:"relays"`
+	Relays     []*RelayConfig    `json:"relays"`
+	Checkpoint *CheckpointConfig `json:"checkpoint,omitempty"`
+	Admin      *AdminConfig      `json:"admin,omitempty"`
 }
 
 type RelayConfig struct {
 	Name string    `json:"name"`
 	Src  SrcConfig `json:"src"`
 	Dst  DstConfig `json:"dst"`
+
+	Policy *PolicyConfig `json:"policy,omitempty"`
+	// DryRun builds relay transactions without sending them
+	DryRun *DryRunConfig `json:"dry_run,omitempty"`
+	// Coordinator elects one of the relayers of the link to send txs
+	Coordinator *CoordinatorConfig `json:"coordinator,omitempty"`
 }
 
 type ChainConfig struct {
@@ -34,29 +46,89 @@ type SrcConfig struct {
 }
 
 type DstConfig struct {
-	ChainConfig `json:",squash"`
+	ChainConfig  `json:",squash"`
+	WalletConfig `json:",squash"`
+
+	// Wallets are more wallets of the relay on dst, which take turns with
+	// the wallet of the key settings above to send txs, one at a time: a
+	// wallet whose balance runs low is failed over to the next one
+	Wallets []*WalletConfig `json:"wallets,omitempty"`
 
+	// TxSizeLimit
+	// is the maximum size of a transaction in bytes
+	TxDataSizeLimit uint64 `json:"tx_data_size_limit"`
+}
+
+// WalletConfig configures a wallet of the relay on dst, of a keystore, a
+// remote signer or an AWS secret
+type WalletConfig struct {
+	// KeyStore is the keystore json, or the path to its file as a string
 	KeyStore    json.RawMessage `json:"key_store"`
 	KeyPassword string          `json:"key_password"`
+	// KeySecret references the password of the keystore instead of
+	// KeyPassword, e.g. "/run/secrets/password" or "env://KEY_PASSWORD";
+	// see ResolveSecret
+	KeySecret string `json:"key_secret,omitempty"`
+	// AllowDefaultPassword allows a keystore of DefaultKeyPassword, which is
+	// refused otherwise
+	AllowDefaultPassword bool `json:"allow_default_password,omitempty"`
+
+	// Signer is the remote signer which holds the key, instead of
+	// KeyStore
+	Signer *wallet.RemoteSignerConfig `json:"signer,omitempty"`
 
 	// AWS
 	AWSSecretName string `json:"aws_secret_name,omitempty"`
 	AWSRegion     string `json:"aws_region,omitempty"`
+}
 
-	// TxSizeLimit
-	// is the maximum size of a transaction in bytes
-	TxDataSizeLimit uint64 `json:"tx_data_size_limit"`
+// WalletPool returns the wallets of the relay on dst: the one of the key
+// settings of cfg first, if any, and then those of cfg.Wallets
+func (cfg *DstConfig) WalletPool() ([]wallet.Wallet, error) {
+	wcs := cfg.Wallets
+	if len(wcs) == 0 || !cfg.WalletConfig.isEmpty() {
+		wcs = append([]*WalletConfig{&cfg.WalletConfig}, wcs...)
+	}
+	var ws []wallet.Wallet
+	addrs := map[string]bool{}
+	for i, wc := range wcs {
+		w, err := wc.Wallet()
+		if err != nil {
+			if len(wcs) > 1 {
+				return nil, fmt.Errorf("wallet %d: %v", i, err)
+			}
+			return nil, err
+		}
+		addr := strings.ToLower(w.Address())
+		if addrs[addr] {
+			return nil, fmt.Errorf("duplicate wallet: %s", w.Address())
+		}
+		addrs[addr] = true
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+func (cfg *WalletConfig) isEmpty() bool {
+	return len(cfg.KeyStore) == 0 && cfg.Signer == nil && cfg.AWSSecretName == ""
 }
 
-func (cfg *DstConfig) Wallet() (wallet.Wallet, error) {
+func (cfg *WalletConfig) Wallet() (wallet.Wallet, error) {
+	if cfg.Signer != nil {
+		return wallet.NewRemoteWallet(cfg.Signer)
+	}
 	keyStore, password, err := cfg.resolveKeyStore()
 	if err != nil {
 		return nil, err
 	}
+	if string(password) == DefaultKeyPassword && !cfg.AllowDefaultPassword {
+		return nil, errors.New("default key password is not allowed, " +
+			"set key_password or key_secret, or allow_default_password")
+	}
 	return wallet.DecryptKeyStore(keyStore, password)
 }
 
-func (cfg *DstConfig) resolveKeyStore() (json.RawMessage, []byte, error) {
+func (cfg *WalletConfig) resolveKeyStore() (json.RawMessage, []byte, error) {
 	if cfg.AWSSecretName != "" && cfg.AWSRegion != "" {
 		result, err := wallet.GetSecret(cfg.AWSSecretName, cfg.AWSRegion)
 		if err != nil {
@@ -74,8 +146,34 @@ func (cfg *DstConfig) resolveKeyStore() (json.RawMessage, []byte, error) {
 			return w.KeyStore, []byte(w.Secret), nil
 		}
 	}
-	if cfg.KeyPassword == "" {
-		return cfg.KeyStore, []byte(DefaultKeyPassword), nil
+	keyStore, err := cfg.ReadKeyStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case cfg.KeySecret != "":
+		password, err := ResolveSecret(cfg.KeySecret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return keyStore, password, nil
+	case cfg.KeyPassword != "":
+		return keyStore, []byte(cfg.KeyPassword), nil
+	default:
+		return keyStore, []byte(DefaultKeyPassword), nil
+	}
+}
+
+// ReadKeyStore returns the keystore json, read from the file if KeyStore
+// is a path
+func (cfg *WalletConfig) ReadKeyStore() (json.RawMessage, error) {
+	var path string
+	if err := json.Unmarshal(cfg.KeyStore, &path); err != nil {
+		return cfg.KeyStore, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("key_store: %v", err)
 	}
-	return cfg.KeyStore, []byte(cfg.KeyPassword), nil
+	return b, nil
 }
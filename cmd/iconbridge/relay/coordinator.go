@@ -0,0 +1,283 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+const (
+	coordinatorLeaseTTL        = time.Minute
+	coordinatorLockRetries     = 10
+	coordinatorLockWait        = 50 * time.Millisecond
+	coordinatorStaleLockPeriod = 10 * time.Second
+)
+
+// Coordinator ...
+// elects the relayer of a link that sends relay txs, so that other
+// relayers of the same link stand by instead of sending duplicate txs
+type Coordinator interface {
+	// IsLeader is called on every relay round with the link status of dst
+	// and the number of receipts pending to be relayed, and tells if the
+	// relayer may send relay txs
+	IsLeader(ctx context.Context, link *chain.BMCLinkStatus, pending int) (bool, error)
+	// Resign gives up the leadership, if held, when the relay stops
+	Resign() error
+}
+
+type NewCoordinatorFunc func(rc *RelayConfig, opts json.RawMessage) (Coordinator, error)
+
+var Coordinators = map[string]NewCoordinatorFunc{
+	"bmc":   newRotationCoordinator,
+	"lease": newLeaseCoordinator,
+}
+
+// CoordinatorConfig ...
+// selects the coordinator of the relayers of a link by type, which is one
+// of Coordinators, e.g. "bmc" or "lease"
+type CoordinatorConfig struct {
+	Type    string          `json:"type"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+func newCoordinator(rc *RelayConfig) (Coordinator, error) {
+	if rc.Coordinator == nil {
+		return nil, nil
+	}
+	newFunc, ok := Coordinators[rc.Coordinator.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported coordinator: %q", rc.Coordinator.Type)
+	}
+	return newFunc(rc, rc.Coordinator.Options)
+}
+
+// rotationCoordinator ...
+// follows the relay rotation of the BMC, which accepts relay messages from
+// one of its registered relays per RotateTerm blocks, in turn
+type rotationCoordinator struct {
+	// Index is the position of the relayer in the relays of the BMC
+	Index uint64 `json:"index"`
+	// Relays is the number of relays registered to the BMC
+	Relays uint64 `json:"relays"`
+}
+
+func newRotationCoordinator(rc *RelayConfig, opts json.RawMessage) (Coordinator, error) {
+	c := &rotationCoordinator{}
+	if len(opts) > 0 {
+		if err := json.Unmarshal(opts, c); err != nil {
+			return nil, err
+		}
+	}
+	if c.Relays == 0 {
+		return nil, errors.New("bmc coordinator: relays must be positive")
+	}
+	if c.Index >= c.Relays {
+		return nil, fmt.Errorf("bmc coordinator: index %d out of relays %d", c.Index, c.Relays)
+	}
+	return c, nil
+}
+
+// IsLeader tells if the relayer is in turn at the current height of dst;
+// every relayer is, if the BMC doesn't rotate relays
+func (c *rotationCoordinator) IsLeader(
+	ctx context.Context, link *chain.BMCLinkStatus, pending int) (bool, error) {
+	term := uint64(link.RotateTerm)
+	if term == 0 || c.Relays == 1 {
+		return true, nil
+	}
+	index := uint64(link.BMRIndex)
+	if link.CurrentHeight > link.RotateHeight {
+		// terms passed since the last rotation recorded by the BMC
+		index += (link.CurrentHeight - link.RotateHeight + term - 1) / term
+	}
+	return index%c.Relays == c.Index, nil
+}
+
+func (c *rotationCoordinator) Resign() error {
+	return nil
+}
+
+// lease is the content of the lease file
+type lease struct {
+	ID      string    `json:"id"`
+	RxSeq   uint64    `json:"rx_seq"`
+	Expires time.Time `json:"expires"`
+}
+
+// leaseCoordinator ...
+// elects the holder of a lease file shared by the relayers of a link, e.g.
+// on a shared volume, for hot standby. The leader renews the lease while
+// the link progresses or nothing is pending; otherwise the lease expires
+// after TTL and a standby relayer takes it over.
+type leaseCoordinator struct {
+	// Path is the lease file; its lock file is Path + ".lock"
+	Path string `json:"path"`
+	// ID identifies the relayer, hostname and pid by default
+	ID  string   `json:"id,omitempty"`
+	TTL Duration `json:"ttl,omitempty"`
+}
+
+func newLeaseCoordinator(rc *RelayConfig, opts json.RawMessage) (Coordinator, error) {
+	c := &leaseCoordinator{}
+	if len(opts) > 0 {
+		if err := json.Unmarshal(opts, c); err != nil {
+			return nil, err
+		}
+	}
+	if c.Path == "" {
+		return nil, errors.New("lease coordinator: path is required")
+	}
+	if c.TTL < 0 {
+		return nil, errors.New("lease coordinator: ttl must not be negative")
+	} else if c.TTL == 0 {
+		c.TTL = Duration(coordinatorLeaseTTL)
+	}
+	if c.ID == "" {
+		hostname, _ := os.Hostname()
+		c.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return c, nil
+}
+
+func (c *leaseCoordinator) IsLeader(
+	ctx context.Context, link *chain.BMCLinkStatus, pending int) (bool, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	l, err := c.read()
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	ttl := time.Duration(c.TTL)
+	expired := now.After(l.Expires)
+	switch {
+	case l.ID == c.ID && !expired:
+		if link.RxSeq > l.RxSeq || pending == 0 {
+			return true, c.write(&lease{ID: c.ID, RxSeq: link.RxSeq, Expires: now.Add(ttl)})
+		}
+		return true, nil
+	case l.ID == c.ID && now.Before(l.Expires.Add(ttl)):
+		// stalled; give a standby relayer a term to take over
+		return false, nil
+	case l.ID == "" || expired:
+		return true, c.write(&lease{ID: c.ID, RxSeq: link.RxSeq, Expires: now.Add(ttl)})
+	default:
+		return false, nil
+	}
+}
+
+// Resign expires the lease, if held
+func (c *leaseCoordinator) Resign() error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	l, err := c.read()
+	if err != nil || l.ID != c.ID {
+		return err
+	}
+	return os.Remove(c.Path)
+}
+
+func (c *leaseCoordinator) read() (*lease, error) {
+	l := &lease{}
+	b, err := ioutil.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("invalid lease %s: %v", c.Path, err)
+	}
+	return l, nil
+}
+
+func (c *leaseCoordinator) write(l *lease) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	tmp := c.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.Path)
+}
+
+// lock ...
+// creates the lock file of the lease exclusively, to read and write the
+// lease atomically; a lock file left by a crashed relayer is removed
+// after coordinatorStaleLockPeriod
+func (c *leaseCoordinator) lock() (unlock func(), err error) {
+	path := c.Path + ".lock"
+	for i := 0; ; i++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		} else if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > coordinatorStaleLockPeriod {
+			os.Remove(path)
+			continue
+		}
+		if i >= coordinatorLockRetries {
+			return nil, fmt.Errorf("lease %s is locked", c.Path)
+		}
+		time.Sleep(coordinatorLockWait)
+	}
+}
+
+// isLeader ...
+// asks the coordinator if the relay may send txs; the relay stands by on
+// errors, not to send duplicate txs
+func (r *relay) isLeader(ctx context.Context, link *chain.BMCLinkStatus, pending int) bool {
+	if r.coordinator == nil {
+		return true
+	}
+	leader, err := r.coordinator.IsLeader(ctx, link, pending)
+	if err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn("coordinator: failed")
+		leader = false
+	}
+	r.mu.Lock()
+	changed := r.standby == leader
+	r.standby = !leader
+	r.mu.Unlock()
+	if changed {
+		l := r.log.WithFields(log.Fields{"rxSeq": link.RxSeq, "pending": pending})
+		if leader {
+			l.Info("coordinator: leader")
+		} else {
+			l.Info("coordinator: standby")
+		}
+	}
+	return leader
+}
+
+// resign gives up the leadership when the relay stops
+func (r *relay) resign() {
+	if r.coordinator == nil {
+		return
+	}
+	if err := r.coordinator.Resign(); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn("coordinator: failed to resign")
+	}
+	r.mu.Lock()
+	r.standby = false
+	r.mu.Unlock()
+}
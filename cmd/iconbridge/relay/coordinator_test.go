@@ -0,0 +1,139 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCoordinator(t *testing.T) {
+	for _, cc := range []*CoordinatorConfig{
+		{Type: "raft"},
+		{Type: "bmc"},
+		{Type: "bmc", Options: json.RawMessage(`{"index":2,"relays":2}`)},
+		{Type: "lease"},
+		{Type: "lease", Options: json.RawMessage(`{"path":"lease.json","ttl":"-1s"}`)},
+	} {
+		_, err := newCoordinator(&RelayConfig{Coordinator: cc})
+		assert.Error(t, err, "%s %s", cc.Type, cc.Options)
+	}
+	c, err := newCoordinator(&RelayConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestRotationCoordinator(t *testing.T) {
+	c := &rotationCoordinator{Index: 1, Relays: 3}
+	for _, tc := range []struct {
+		link   chain.BMCLinkStatus
+		leader bool
+	}{
+		{chain.BMCLinkStatus{RotateTerm: 0, BMRIndex: 0}, true},
+		{chain.BMCLinkStatus{RotateTerm: 10, BMRIndex: 1, RotateHeight: 100, CurrentHeight: 95}, true},
+		{chain.BMCLinkStatus{RotateTerm: 10, BMRIndex: 1, RotateHeight: 100, CurrentHeight: 100}, true},
+		{chain.BMCLinkStatus{RotateTerm: 10, BMRIndex: 1, RotateHeight: 100, CurrentHeight: 101}, false},
+		{chain.BMCLinkStatus{RotateTerm: 10, BMRIndex: 0, RotateHeight: 100, CurrentHeight: 105}, true},
+		{chain.BMCLinkStatus{RotateTerm: 10, BMRIndex: 2, RotateHeight: 100, CurrentHeight: 115}, true},
+	} {
+		leader, err := c.IsLeader(context.Background(), &tc.link, 1)
+		require.NoError(t, err)
+		assert.Equal(t, tc.leader, leader, "%+v", tc.link)
+	}
+}
+
+func TestLeaseCoordinator(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "lease.json")
+	const ttl = 50 * time.Millisecond
+	newLease := func(id string) Coordinator {
+		c, err := newLeaseCoordinator(nil, json.RawMessage(
+			`{"path":"`+path+`","id":"`+id+`","ttl":"50ms"}`))
+		require.NoError(t, err)
+		return c
+	}
+	a, b := newLease("a"), newLease("b")
+	isLeader := func(c Coordinator, rxSeq uint64, pending int) bool {
+		leader, err := c.IsLeader(ctx, &chain.BMCLinkStatus{RxSeq: rxSeq}, pending)
+		require.NoError(t, err)
+		return leader
+	}
+
+	assert.True(t, isLeader(a, 0, 1))
+	assert.False(t, isLeader(b, 0, 1))
+
+	// the leader renews the lease while the link progresses
+	time.Sleep(ttl / 2)
+	assert.True(t, isLeader(a, 1, 1))
+	time.Sleep(ttl / 2)
+	assert.True(t, isLeader(a, 1, 1))
+	assert.False(t, isLeader(b, 1, 1))
+
+	// and standby takes over once it stalls
+	time.Sleep(ttl)
+	assert.False(t, isLeader(a, 1, 1))
+	assert.True(t, isLeader(b, 1, 1))
+	assert.False(t, isLeader(a, 1, 1))
+
+	require.NoError(t, a.Resign())
+	assert.False(t, isLeader(a, 1, 1))
+	require.NoError(t, b.Resign())
+	assert.True(t, isLeader(a, 1, 1))
+}
+
+// stubCoordinator elects the relay when leader is set
+type stubCoordinator struct {
+	mu     sync.Mutex
+	leader bool
+}
+
+func (c *stubCoordinator) IsLeader(
+	ctx context.Context, link *chain.BMCLinkStatus, pending int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leader, nil
+}
+
+func (c *stubCoordinator) Resign() error {
+	return nil
+}
+
+func (c *stubCoordinator) setLeader(leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = leader
+}
+
+func TestRelay_Standby(t *testing.T) {
+	fs := &fakeSender{}
+	fr := &fakeReceiver{msgs: []*chain.Message{newTestMessage(1, 3)}}
+	c := &stubCoordinator{}
+
+	r := newRelay(&RelayConfig{Name: t.Name()}, fr, fs, nil, log.New())
+	r.coordinator = c
+	r.policy.TickerInterval = Duration(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Start(ctx) }()
+	defer func() {
+		cancel()
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	}()
+
+	require.Eventually(t, func() bool {
+		info := r.Info()
+		return info.State == RelayStateStandby && info.Pending.Receipts == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, fs.segments())
+
+	c.setLeader(true)
+	require.Eventually(t, func() bool { return fs.rxSeq() == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, RelayStateRunning, r.Info().State)
+}
@@ -0,0 +1,71 @@
+package relay
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// DryRunConfig ...
+// makes a relay build relay transactions without sending them, to shadow
+// the active relayer of the link
+type DryRunConfig struct {
+	// Output is the file to append the transactions to as json lines;
+	// they are only logged if empty
+	Output string `json:"output,omitempty"`
+}
+
+// DryRunTx is a relay transaction built in dry run
+type DryRunTx struct {
+	Time        time.Time     `json:"time"`
+	Relay       string        `json:"relay"`
+	SeqBegin    uint64        `json:"seq_begin"`
+	SeqEnd      uint64        `json:"seq_end"`
+	HeightBegin uint64        `json:"height_begin"`
+	HeightEnd   uint64        `json:"height_end"`
+	Tx          chain.RelayTx `json:"tx"`
+}
+
+func newDryRunTx(name string, tx chain.RelayTx, receipts []*chain.Receipt) *DryRunTx {
+	dtx := &DryRunTx{Time: time.Now(), Relay: name, Tx: tx}
+	if n := len(receipts); n > 0 {
+		first, last := receipts[0], receipts[n-1]
+		dtx.HeightBegin, dtx.HeightEnd = first.Height, last.Height
+		if len(first.Events) > 0 && len(last.Events) > 0 {
+			dtx.SeqBegin = first.Events[0].Sequence
+			dtx.SeqEnd = last.Events[len(last.Events)-1].Sequence
+		}
+	}
+	return dtx
+}
+
+// dryRun logs the tx of the receipts instead of sending it
+func (r *relay) dryRun(tx chain.RelayTx, receipts []*chain.Receipt) {
+	dtx := newDryRunTx(r.cfg.Name, tx, receipts)
+	b, err := json.Marshal(dtx)
+	if err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("dry run: failed to encode tx")
+		return
+	}
+	l := r.log.WithFields(log.Fields{
+		"seq":    []uint64{dtx.SeqBegin, dtx.SeqEnd},
+		"height": []uint64{dtx.HeightBegin, dtx.HeightEnd},
+	})
+	if r.cfg.DryRun.Output == "" {
+		l.WithFields(log.Fields{"tx": string(b)}).Info("dry run: tx")
+		return
+	}
+	l.Info("dry run: tx")
+	f, err := os.OpenFile(r.cfg.DryRun.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("dry run: failed to open output")
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Error("dry run: failed to write output")
+	}
+}
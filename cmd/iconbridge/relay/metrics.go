@@ -0,0 +1,147 @@
+package relay
+
+import (
+	"math/big"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	metricsNamespace = "iconbridge"
+	metricsSubsystem = "relay"
+)
+
+func newGaugeVec(name, help string, labels ...string) *prometheus.GaugeVec {
+	return promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      name,
+		Help:      help,
+	}, append([]string{"relay"}, labels...))
+}
+
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	return promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      name,
+		Help:      help,
+	}, append([]string{"relay"}, labels...))
+}
+
+var (
+	metricSrcHeight = newGaugeVec("src_height",
+		"Latest height of the src chain seen by the relay")
+	metricSrcVerifiedHeight = newGaugeVec("src_verified_height",
+		"Height upto which src blocks have been verified")
+	metricLinkRxSeq = newGaugeVec("link_rx_seq",
+		"RxSeq of the link on the dst chain")
+	metricLinkTxSeq = newGaugeVec("link_tx_seq",
+		"TxSeq of the link on the dst chain")
+	metricRxSeqLag = newGaugeVec("rx_seq_lag",
+		"Number of events received from src that are not yet relayed to dst")
+	metricPendingReceipts = newGaugeVec("pending_receipts",
+		"Number of receipts pending to be relayed")
+	metricWalletBalance = newGaugeVec("wallet_balance",
+		"Balance of the relay wallet on the dst chain, the total if it has several")
+	metricWalletBalanceThreshold = newGaugeVec("wallet_balance_threshold",
+		"Balance of the relay wallet below which a warning is logged")
+	metricPoolWalletBalance = newGaugeVec("pool_wallet_balance",
+		"Balance of a wallet of the relay with several wallets on the dst chain", "wallet")
+	metricPoolWalletActive = newGaugeVec("pool_wallet_active",
+		"1 if a wallet of the relay is in rotation to send txs, 0 if its balance is not above threshold", "wallet")
+	metricTxSendRetries = newCounterVec("tx_send_retries_total",
+		"Number of retries to send relay transactions")
+	metricTxReceiptFailures = newCounterVec("tx_receipt_failures_total",
+		"Number of failed relay transaction receipts by error", "error")
+	metricTxGasUsed = newCounterVec("tx_gas_used_total",
+		"Gas (or step) spent by relay transactions")
+	metricForeignEvents = newCounterVec("foreign_events_total",
+		"Number of src events to other BMCs than dst, which no relay of the process delivers")
+	metricOrphanedReceipts = newCounterVec("orphaned_receipts_total",
+		"Number of pending src receipts withdrawn as their blocks were orphaned by reorgs")
+)
+
+type relayMetrics struct {
+	srcHeight              prometheus.Gauge
+	srcVerifiedHeight      prometheus.Gauge
+	linkRxSeq              prometheus.Gauge
+	linkTxSeq              prometheus.Gauge
+	rxSeqLag               prometheus.Gauge
+	pendingReceipts        prometheus.Gauge
+	walletBalance          prometheus.Gauge
+	walletBalanceThreshold prometheus.Gauge
+	poolWalletBalance      *prometheus.GaugeVec
+	poolWalletActive       *prometheus.GaugeVec
+	txSendRetries          prometheus.Counter
+	txReceiptFailures      *prometheus.CounterVec
+	txGasUsed              prometheus.Counter
+	foreignEvents          prometheus.Counter
+	orphanedReceipts       prometheus.Counter
+}
+
+func newRelayMetrics(name string) *relayMetrics {
+	l := prometheus.Labels{"relay": name}
+	return &relayMetrics{
+		srcHeight:              metricSrcHeight.With(l),
+		srcVerifiedHeight:      metricSrcVerifiedHeight.With(l),
+		linkRxSeq:              metricLinkRxSeq.With(l),
+		linkTxSeq:              metricLinkTxSeq.With(l),
+		rxSeqLag:               metricRxSeqLag.With(l),
+		pendingReceipts:        metricPendingReceipts.With(l),
+		walletBalance:          metricWalletBalance.With(l),
+		walletBalanceThreshold: metricWalletBalanceThreshold.With(l),
+		poolWalletBalance:      metricPoolWalletBalance.MustCurryWith(l),
+		poolWalletActive:       metricPoolWalletActive.MustCurryWith(l),
+		txSendRetries:          metricTxSendRetries.With(l),
+		txReceiptFailures:      metricTxReceiptFailures.MustCurryWith(l),
+		txGasUsed:              metricTxGasUsed.With(l),
+		foreignEvents:          metricForeignEvents.With(l),
+		orphanedReceipts:       metricOrphanedReceipts.With(l),
+	}
+}
+
+func (m *relayMetrics) setLink(link *chain.BMCLinkStatus, seq uint64) {
+	m.linkRxSeq.Set(float64(link.RxSeq))
+	m.linkTxSeq.Set(float64(link.TxSeq))
+	if seq > link.RxSeq {
+		m.rxSeqLag.Set(float64(seq - link.RxSeq))
+	} else {
+		m.rxSeqLag.Set(0)
+	}
+}
+
+func (m *relayMetrics) setBalance(balance, threshold *big.Int) {
+	if balance != nil {
+		f, _ := new(big.Float).SetInt(balance).Float64()
+		m.walletBalance.Set(f)
+	}
+	if threshold != nil {
+		f, _ := new(big.Float).SetInt(threshold).Float64()
+		m.walletBalanceThreshold.Set(f)
+	}
+}
+
+func (m *relayMetrics) setWallets(wallets []WalletInfo) {
+	for _, w := range wallets {
+		if w.Balance != nil {
+			f, _ := new(big.Float).SetInt(w.Balance).Float64()
+			m.poolWalletBalance.WithLabelValues(w.Address).Set(f)
+		}
+		active := 0.0
+		if w.Active {
+			active = 1
+		}
+		m.poolWalletActive.WithLabelValues(w.Address).Set(active)
+	}
+}
+
+func (m *relayMetrics) addReceiptFailure(err error) {
+	name := chain.ErrorName(err)
+	if name == "" {
+		name = "Other"
+	}
+	m.txReceiptFailures.WithLabelValues(name).Inc()
+}
@@ -0,0 +1,35 @@
+package relay
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayMetrics(t *testing.T) {
+	m := newRelayMetrics("metrics_test")
+	m.txReceiptFailures.Reset() // counters are global, with -count=n
+
+	m.setLink(&chain.BMCLinkStatus{RxSeq: 10, TxSeq: 3}, 15)
+	assert.Equal(t, float64(10), testutil.ToFloat64(m.linkRxSeq))
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.linkTxSeq))
+	assert.Equal(t, float64(5), testutil.ToFloat64(m.rxSeqLag))
+
+	m.setLink(&chain.BMCLinkStatus{RxSeq: 20}, 15)
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.rxSeqLag))
+
+	m.setBalance(big.NewInt(1000), big.NewInt(10))
+	assert.Equal(t, float64(1000), testutil.ToFloat64(m.walletBalance))
+	assert.Equal(t, float64(10), testutil.ToFloat64(m.walletBalanceThreshold))
+
+	m.addReceiptFailure(fmt.Errorf("wrapped: %w", chain.ErrGasLimitExceeded))
+	m.addReceiptFailure(chain.ErrBMCRevertInvalidSeqNumber)
+	m.addReceiptFailure(fmt.Errorf("not found"))
+	for _, name := range []string{"GasLimitExceeded", "BMCRevertInvalidSeqNumber", "Other"} {
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.txReceiptFailures.WithLabelValues(name)), name)
+	}
+}
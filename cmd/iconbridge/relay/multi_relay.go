@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common"
 	"github.com/icon-project/icon-bridge/common/log"
 	"github.com/icon-project/icon-bridge/common/wallet"
 )
@@ -36,104 +39,289 @@ var (
 )
 
 func NewMultiRelay(cfg *Config, l log.Logger) (Relay, error) {
-	mr := &multiRelay{log: l}
+	mr := &multiRelay{log: l, admin: cfg.Admin}
+
+	if cfg.Checkpoint != nil {
+		var err error
+		if mr.cps, err = NewCheckpointStore(cfg.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
 
 	for _, rc := range cfg.Relays {
+		relay, err := mr.newRelay(rc)
+		if err != nil {
+			if mr.cps != nil {
+				mr.cps.Close()
+			}
+			return nil, err
+		}
+		mr.relays = append(mr.relays, relay)
+	}
+
+	return mr, nil
+}
+
+// newRelay creates the relay of rc with its src and dst chains
+func (mr *multiRelay) newRelay(rc *RelayConfig) (*relay, error) {
+	if err := rc.Policy.Validate(); err != nil {
+		return nil, fmt.Errorf("relay %s: %v", rc.Name, err)
+	}
+	coordinator, err := newCoordinator(rc)
+	if err != nil {
+		return nil, fmt.Errorf("relay %s: %v", rc.Name, err)
+	}
 
-		var dst chain.Sender
-		var src chain.Receiver
+	var dst chain.Sender
+	var src chain.Receiver
 
-		w, err := rc.Dst.Wallet()
+	ws, err := rc.Dst.WalletPool()
+	if err != nil {
+		return nil, err
+	}
+	chainName := rc.Dst.Address.BlockChain()
+	srvName := "BMR-"
+	if strings.ToUpper(chainName) == "ICON" {
+		srvName += strings.ToUpper(rc.Src.Address.BlockChain())
+	} else {
+		srvName += strings.ToUpper(chainName)
+	}
+	fields := log.Fields{
+		log.FieldKeyModule:  rc.Name,
+		log.FieldKeyService: srvName,
+	}
+	if len(ws) == 1 {
+		fields[log.FieldKeyWallet] = ws[0].Address()
+	}
+	l := mr.log.WithFields(fields)
+
+	newSender, ok := Senders[chainName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported blockchain: sender=%s", chainName)
+	}
+	tl := l.WithFields(log.Fields{
+		log.FieldKeyPrefix: "tx_",
+		log.FieldKeyChain:  chainName,
+	})
+	var senders []chain.Sender
+	var addresses []string
+	for _, w := range ws {
+		sender, err := newSender(
+			rc.Src.Address,
+			rc.Dst.Address,
+			rc.Dst.Endpoint,
+			w,
+			rc.Dst.Options,
+			tl.WithFields(log.Fields{log.FieldKeyWallet: w.Address()}))
 		if err != nil {
 			return nil, err
 		}
-		chainName := rc.Dst.Address.BlockChain()
-		srvName := "BMR-"
-		if strings.ToUpper(chainName) == "ICON" {
-			srvName += strings.ToUpper(rc.Src.Address.BlockChain())
-		} else {
-			srvName += strings.ToUpper(chainName)
-		}
-		l := l.WithFields(log.Fields{
-			log.FieldKeyModule:  rc.Name,
-			log.FieldKeyWallet:  w.Address(),
-			log.FieldKeyService: srvName,
-		})
-
-		if sender, ok := Senders[chainName]; ok {
-			if dst, err = sender(
-				rc.Src.Address,
-				rc.Dst.Address,
-				rc.Dst.Endpoint,
-				w,
-				rc.Dst.Options,
-				l.WithFields(log.Fields{
-					log.FieldKeyPrefix: "tx_",
-					log.FieldKeyChain:  chainName,
-				})); err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, fmt.Errorf("unsupported blockchain: sender=%s", chainName)
-		}
+		senders = append(senders, sender)
+		addresses = append(addresses, w.Address())
+	}
+	if dst = senders[0]; len(senders) > 1 {
+		dst = newWalletPool(addresses, senders, tl)
+	}
 
-		chainName = rc.Src.Address.BlockChain()
-		if receiver, ok := Receivers[chainName]; ok {
-			if src, err = receiver(
-				rc.Src.Address,
-				rc.Dst.Address,
-				rc.Src.Endpoint,
-				rc.Src.Options,
-				l.WithFields(log.Fields{
-					log.FieldKeyPrefix: "rx_",
-					log.FieldKeyChain:  chainName,
-				}),
-			); err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, fmt.Errorf("unsupported blockchain: receiver=%s", chainName)
+	chainName = rc.Src.Address.BlockChain()
+	if receiver, ok := Receivers[chainName]; ok {
+		if src, err = receiver(
+			rc.Src.Address,
+			rc.Dst.Address,
+			rc.Src.Endpoint,
+			rc.Src.Options,
+			l.WithFields(log.Fields{
+				log.FieldKeyPrefix: "rx_",
+				log.FieldKeyChain:  chainName,
+			}),
+		); err != nil {
+			return nil, err
 		}
+	} else {
+		return nil, fmt.Errorf("unsupported blockchain: receiver=%s", chainName)
+	}
 
-		relay, err := NewRelay(rc, src, dst, l.WithFields(log.Fields{log.FieldKeyChain: "relay"}))
+	if sr, ok := src.(chain.StatefulReceiver); ok && mr.cps != nil {
+		store, err := mr.cps.StateStore(rc.Name)
 		if err != nil {
 			return nil, err
 		}
-		mr.relays = append(mr.relays, relay)
-
+		sr.SetStateStore(store)
 	}
 
-	return mr, nil
+	relay := newRelay(rc, src, dst, mr.cps, l.WithFields(log.Fields{log.FieldKeyChain: "relay"}))
+	relay.hasRoute = mr.hasRoute
+	relay.coordinator = coordinator
+	return relay, nil
 }
 
 type multiRelay struct {
-	log    log.Logger
-	relays []Relay
+	log   log.Logger
+	cps   CheckpointStore
+	admin *AdminConfig
+
+	// protects relays, which are replaced on reload
+	mu       sync.RWMutex
+	relays   []*relay
+	reloadMu sync.Mutex
+
+	// rch queues relays to be started, and done is closed when Start
+	// returns
+	rch  chan *relay
+	done chan struct{}
+}
+
+// hasRoute tells if any relay of the process delivers events from src to
+// next
+func (mr *multiRelay) hasRoute(src, next chain.BTPAddress) bool {
+	for _, r := range mr.list() {
+		if r.cfg.Src.Address.Equal(src) && r.cfg.Dst.Address.Equal(next) {
+			return true
+		}
+	}
+	return false
+}
+
+// requeue starts a failed relay again, if multiRelay is running
+func (mr *multiRelay) requeue(r *relay) bool {
+	if mr.rch == nil {
+		return false
+	}
+	r.mu.Lock()
+	failed := r.failed
+	r.failed = false
+	if failed {
+		r.restarts = 0
+	}
+	r.mu.Unlock()
+	if !failed {
+		return false
+	}
+	return mr.queue(r)
+}
+
+// queue queues the relay to be started; it returns false if multiRelay is
+// not running
+func (mr *multiRelay) queue(r *relay) bool {
+	mr.mu.RLock()
+	rch, done := mr.rch, mr.done
+	mr.mu.RUnlock()
+	if rch == nil {
+		return false
+	}
+	select {
+	case rch <- r:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// restart ...
+// queues the relay to be started again after the delay of its restart
+// policy, or marks it failed if the retry budget is exhausted
+func (mr *multiRelay) restart(ctx context.Context, r *relay, uptime time.Duration) {
+	rp := &r.policy.Restart
+	r.mu.Lock()
+	if uptime > time.Duration(rp.MaxDelay) {
+		r.restarts = 0 // recovered since last failure
+	}
+	r.restarts++
+	restarts := r.restarts
+	r.mu.Unlock()
+	delay, ok := rp.restartDelay(restarts)
+	if !ok {
+		r.log.WithFields(log.Fields{"restarts": restarts - 1}).Error(
+			"relay failed: restart budget exhausted")
+		r.setFailed(true)
+		return
+	}
+	r.log.WithFields(log.Fields{"retry": restarts}).Infof("restarting relay in %v...", delay)
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+		mr.queue(r)
+	}
+}
+
+// list returns the current relays
+func (mr *multiRelay) list() []*relay {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+	return mr.relays
 }
 
 func (mr *multiRelay) Start(ctx context.Context) error {
-	rch := make(chan Relay, len(mr.relays))
+	mr.mu.Lock()
+	mr.rch = make(chan *relay, len(mr.relays))
+	mr.done = make(chan struct{})
 	for _, relay := range mr.relays {
-		rch <- relay
+		mr.rch <- relay
 	}
+	mr.mu.Unlock()
+
+	// the relays must exit before the checkpoints are closed, and
+	// multiRelay is stopped first so that none is queued meanwhile
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		if mr.cps != nil {
+			if err := mr.cps.Close(); err != nil {
+				mr.log.Errorf("failed to close checkpoint store: %v", err)
+			}
+		}
+	}()
+	defer close(mr.done)
+
+	if mr.admin != nil {
+		srv := common.NewHttpServer(mr.admin.Address, mr.newAdminEcho())
+		go func() {
+			mr.log.Infof("serving admin api on %s", srv.Address())
+			if err := srv.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				mr.log.Errorf("admin server: %v", err)
+			}
+		}()
+		defer srv.Stop()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case r := <-rch:
-			go func(relay Relay) {
+		case r := <-mr.rch:
+			rctx, cancel := context.WithCancel(ctx)
+			if !r.begin(cancel) {
+				cancel() // removed on reload
+				continue
+			}
+			wg.Add(1)
+			go func(relay *relay) {
+				defer wg.Done()
+				defer cancel()
+				started := time.Now()
 				defer func() {
 					if r := recover(); r != nil {
+						relay.end()
 						debug.PrintStack()
-						rch <- relay
+						mr.restart(ctx, relay, time.Since(started))
 					}
 				}()
-				if err := relay.Start(ctx); err != nil {
-					if !errors.Is(err, context.Canceled) {
+				err := relay.Start(rctx)
+				relay.end()
+				if err != nil {
+					switch {
+					case ctx.Err() != nil:
+					case relay.isRemoved():
+						relay.log.Info("relay stopped")
+					case errors.Is(err, context.Canceled): // restarted via admin api
+						relay.log.Info("restarting relay...")
+						relay.mu.Lock()
+						relay.restarts = 0
+						relay.mu.Unlock()
+						mr.queue(relay)
+					default:
 						mr.log.Errorf("%v", err)
-						mr.log.Info("restarting relay in 5s...")
-						time.Sleep(5 * time.Second)
-						rch <- relay
+						mr.restart(ctx, relay, time.Since(started))
 					}
 				}
 			}(r)
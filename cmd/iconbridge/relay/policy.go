@@ -0,0 +1,163 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	relayTickerInterval                  = 5 * time.Second
+	relayBalanceCheckInterval            = 60 * time.Second
+	relayTriggerReceiptsCount            = 20
+	relayTxSendWaitInterval              = time.Second / 2
+	relayTxReceiptWaitInterval           = time.Second
+	relayTxReceiptMaxRetries             = 30
+	relayInsufficientBalanceWaitInterval = 30 * time.Second
+	retryWarnThreshold                   = 15
+
+	relayRestartDelay      = 5 * time.Second
+	relayRestartMaxDelay   = 5 * time.Minute
+	relayRestartMultiplier = 2
+)
+
+// Duration is a time.Duration that is encoded in json as a string, e.g. "1m30s"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s: %v", b, err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// PolicyConfig ...
+// sets the timing and batching of a relay; zero values are replaced by
+// the defaults, which suit chains with a few seconds of block interval
+type PolicyConfig struct {
+	// TickerInterval is the interval to relay pending receipts at
+	TickerInterval Duration `json:"ticker_interval,omitempty"`
+	// TriggerReceiptsCount is the number of pending receipts that
+	// triggers relaying them before TickerInterval
+	TriggerReceiptsCount int `json:"trigger_receipts_count,omitempty"`
+
+	TxSendWaitInterval              Duration `json:"tx_send_wait_interval,omitempty"`
+	TxReceiptWaitInterval           Duration `json:"tx_receipt_wait_interval,omitempty"`
+	TxReceiptMaxRetries             int      `json:"tx_receipt_max_retries,omitempty"`
+	InsufficientBalanceWaitInterval Duration `json:"insufficient_balance_wait_interval,omitempty"`
+	BalanceCheckInterval            Duration `json:"balance_check_interval,omitempty"`
+
+	Restart RestartPolicy `json:"restart"`
+}
+
+// RestartPolicy ...
+// restarts a failed relay after Delay, multiplied by Multiplier on every
+// consecutive failure upto MaxDelay. The relay is marked failed once it has
+// been restarted MaxRetries times in a row; 0 means no limit. A relay that
+// runs longer than MaxDelay before failing starts over from Delay.
+type RestartPolicy struct {
+	Delay      Duration `json:"delay,omitempty"`
+	MaxDelay   Duration `json:"max_delay,omitempty"`
+	Multiplier float64  `json:"multiplier,omitempty"`
+	MaxRetries int      `json:"max_retries,omitempty"`
+}
+
+func (p *PolicyConfig) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for name, v := range map[string]Duration{
+		"ticker_interval":                    p.TickerInterval,
+		"tx_send_wait_interval":              p.TxSendWaitInterval,
+		"tx_receipt_wait_interval":           p.TxReceiptWaitInterval,
+		"insufficient_balance_wait_interval": p.InsufficientBalanceWaitInterval,
+		"balance_check_interval":             p.BalanceCheckInterval,
+		"restart.delay":                      p.Restart.Delay,
+		"restart.max_delay":                  p.Restart.MaxDelay,
+	} {
+		if v < 0 {
+			return fmt.Errorf("invalid policy: %s=%v: must not be negative", name, time.Duration(v))
+		}
+	}
+	if p.TickerInterval > 0 && p.TickerInterval < Duration(100*time.Millisecond) {
+		return fmt.Errorf("invalid policy: ticker_interval=%v: must be at least 100ms",
+			time.Duration(p.TickerInterval))
+	}
+	if p.TriggerReceiptsCount < 0 {
+		return fmt.Errorf("invalid policy: trigger_receipts_count=%d: must not be negative", p.TriggerReceiptsCount)
+	}
+	if p.TxReceiptMaxRetries < 0 {
+		return fmt.Errorf("invalid policy: tx_receipt_max_retries=%d: must not be negative", p.TxReceiptMaxRetries)
+	}
+	if p.Restart.Multiplier != 0 && p.Restart.Multiplier < 1 {
+		return fmt.Errorf("invalid policy: restart.multiplier=%v: must be at least 1", p.Restart.Multiplier)
+	}
+	if p.Restart.MaxRetries < 0 {
+		return fmt.Errorf("invalid policy: restart.max_retries=%d: must not be negative", p.Restart.MaxRetries)
+	}
+	if p.Restart.Delay > 0 && p.Restart.MaxDelay > 0 && p.Restart.MaxDelay < p.Restart.Delay {
+		return fmt.Errorf("invalid policy: restart.max_delay=%v: must not be less than restart.delay=%v",
+			time.Duration(p.Restart.MaxDelay), time.Duration(p.Restart.Delay))
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the policy with zero values set to defaults
+func (p *PolicyConfig) withDefaults() *PolicyConfig {
+	np := &PolicyConfig{}
+	if p != nil {
+		*np = *p
+	}
+	setDuration := func(d *Duration, v time.Duration) {
+		if *d <= 0 {
+			*d = Duration(v)
+		}
+	}
+	setDuration(&np.TickerInterval, relayTickerInterval)
+	setDuration(&np.TxSendWaitInterval, relayTxSendWaitInterval)
+	setDuration(&np.TxReceiptWaitInterval, relayTxReceiptWaitInterval)
+	setDuration(&np.InsufficientBalanceWaitInterval, relayInsufficientBalanceWaitInterval)
+	setDuration(&np.BalanceCheckInterval, relayBalanceCheckInterval)
+	setDuration(&np.Restart.Delay, relayRestartDelay)
+	setDuration(&np.Restart.MaxDelay, relayRestartMaxDelay)
+	if np.Restart.MaxDelay < np.Restart.Delay {
+		np.Restart.MaxDelay = np.Restart.Delay
+	}
+	if np.TriggerReceiptsCount <= 0 {
+		np.TriggerReceiptsCount = relayTriggerReceiptsCount
+	}
+	if np.TxReceiptMaxRetries <= 0 {
+		np.TxReceiptMaxRetries = relayTxReceiptMaxRetries
+	}
+	if np.Restart.Multiplier < 1 {
+		np.Restart.Multiplier = relayRestartMultiplier
+	}
+	return np
+}
+
+// restartDelay ...
+// returns the delay before the n-th consecutive restart (n >= 1), or false
+// if the retry budget is exhausted
+func (rp *RestartPolicy) restartDelay(n int) (time.Duration, bool) {
+	if rp.MaxRetries > 0 && n > rp.MaxRetries {
+		return 0, false
+	}
+	delay := float64(rp.Delay)
+	for i := 1; i < n && delay < float64(rp.MaxDelay); i++ {
+		delay *= rp.Multiplier
+	}
+	if delay > float64(rp.MaxDelay) {
+		delay = float64(rp.MaxDelay)
+	}
+	return time.Duration(delay), true
+}
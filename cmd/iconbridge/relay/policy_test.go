@@ -0,0 +1,80 @@
+package relay
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyConfig_Defaults(t *testing.T) {
+	var rc RelayConfig
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "r1",
+		"policy": {
+			"ticker_interval": "2s",
+			"tx_receipt_max_retries": 60,
+			"restart": {"delay": "1s", "max_retries": 3}
+		}
+	}`), &rc))
+	require.NoError(t, rc.Policy.Validate())
+
+	p := rc.Policy.withDefaults()
+	assert.Equal(t, Duration(2*time.Second), p.TickerInterval)
+	assert.Equal(t, 60, p.TxReceiptMaxRetries)
+	assert.Equal(t, relayTriggerReceiptsCount, p.TriggerReceiptsCount)
+	assert.Equal(t, Duration(relayInsufficientBalanceWaitInterval), p.InsufficientBalanceWaitInterval)
+	assert.Equal(t, Duration(time.Second), p.Restart.Delay)
+	assert.Equal(t, Duration(relayRestartMaxDelay), p.Restart.MaxDelay)
+	assert.EqualValues(t, relayRestartMultiplier, p.Restart.Multiplier)
+
+	var nilPolicy *PolicyConfig
+	assert.NoError(t, nilPolicy.Validate())
+	assert.Equal(t, Duration(relayTickerInterval), nilPolicy.withDefaults().TickerInterval)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"ticker_interval": "5 sec"}`), &PolicyConfig{}))
+	assert.Error(t, json.Unmarshal([]byte(`{"ticker_interval": 5}`), &PolicyConfig{}))
+}
+
+func TestPolicyConfig_Validate(t *testing.T) {
+	invalid := []*PolicyConfig{
+		{TickerInterval: Duration(time.Millisecond)},
+		{TxSendWaitInterval: Duration(-time.Second)},
+		{TriggerReceiptsCount: -1},
+		{TxReceiptMaxRetries: -1},
+		{Restart: RestartPolicy{Multiplier: 0.5}},
+		{Restart: RestartPolicy{MaxRetries: -1}},
+		{Restart: RestartPolicy{Delay: Duration(time.Minute), MaxDelay: Duration(time.Second)}},
+	}
+	for _, p := range invalid {
+		assert.Error(t, p.Validate(), "%+v", p)
+	}
+}
+
+func TestRestartPolicy_Delay(t *testing.T) {
+	rp := &RestartPolicy{
+		Delay:      Duration(time.Second),
+		MaxDelay:   Duration(10 * time.Second),
+		Multiplier: 2,
+		MaxRetries: 6,
+	}
+	var delays []time.Duration
+	for n := 1; ; n++ {
+		delay, ok := rp.restartDelay(n)
+		if !ok {
+			break
+		}
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		10 * time.Second, 10 * time.Second,
+	}, delays)
+
+	rp.MaxRetries = 0
+	delay, ok := rp.restartDelay(1000)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, delay)
+}
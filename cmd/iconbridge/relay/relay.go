@@ -3,6 +3,7 @@ package relay
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
@@ -10,35 +11,68 @@ import (
 	"github.com/icon-project/icon-bridge/common/log"
 )
 
-const (
-	relayTickerInterval                  = 5 * time.Second
-	relayBalanceCheckInterval            = 60 * time.Second
-	relayTriggerReceiptsCount            = 20
-	relayTxSendWaitInterval              = time.Second / 2
-	relayTxReceiptWaitInterval           = time.Second
-	relayInsufficientBalanceWaitInterval = 30 * time.Second
-	retryWarnThreshold                   = 15
-)
-
 type Relay interface {
 	Start(ctx context.Context) (err error)
 }
 
 func NewRelay(cfg *RelayConfig, src chain.Receiver, dst chain.Sender, log log.Logger) (Relay, error) {
-	r := &relay{
-		cfg: cfg,
-		log: log,
-		src: src,
-		dst: dst,
+	coordinator, err := newCoordinator(cfg)
+	if err != nil {
+		return nil, err
 	}
+	r := newRelay(cfg, src, dst, nil, log)
+	r.coordinator = coordinator
 	return r, nil
 }
 
+func newRelay(cfg *RelayConfig, src chain.Receiver, dst chain.Sender, cps CheckpointStore, log log.Logger) *relay {
+	return &relay{
+		cfg:     cfg,
+		log:     log,
+		src:     src,
+		dst:     dst,
+		cps:     cps,
+		policy:  cfg.Policy.withDefaults(),
+		m:       newRelayMetrics(cfg.Name),
+		relayCh: make(chan struct{}, 1),
+		queryCh: make(chan func(srcMsg *chain.Message)),
+	}
+}
+
 type relay struct {
 	cfg *RelayConfig
 	log log.Logger
 	src chain.Receiver
 	dst chain.Sender
+	cps CheckpointStore
+	m   *relayMetrics
+
+	policy *PolicyConfig
+	// coordinator elects the relayer of the link that sends txs, if any
+	coordinator Coordinator
+	// hasRoute tells if another relay of the process delivers events from
+	// src to next
+	hasRoute func(src, next chain.BTPAddress) bool
+	// restarts is the number of consecutive restarts after failure
+	restarts int
+
+	// relayCh triggers relaying pending messages to dst
+	relayCh chan struct{}
+	// queryCh runs functions on srcMsg within the relay loop
+	queryCh chan func(srcMsg *chain.Message)
+
+	// protects the state exposed through the admin api
+	mu      sync.RWMutex
+	running bool
+	paused  bool
+	failed  bool
+	standby bool
+	// removed is set when the relay is removed on reload, and done is
+	// closed when its current run exits
+	removed bool
+	done    chan struct{}
+	info    RelayInfo
+	cancel  context.CancelFunc
 }
 
 func (r *relay) rxHeight(linkRxHeight uint64) uint64 {
@@ -49,7 +83,184 @@ func (r *relay) rxHeight(linkRxHeight uint64) uint64 {
 	return height
 }
 
+func (r *relay) updateSrcMetrics(height uint64, pendingReceipts int) {
+	if sr, ok := r.src.(chain.StatusReceiver); ok {
+		st := sr.ReceiverStatus()
+		r.m.srcHeight.Set(float64(st.Height))
+		r.m.srcVerifiedHeight.Set(float64(st.VerifiedHeight))
+	} else {
+		r.m.srcHeight.Set(float64(height))
+		r.m.srcVerifiedHeight.Set(float64(height))
+	}
+	r.m.pendingReceipts.Set(float64(pendingReceipts))
+}
+
+// routeEvents ...
+// removes the events to other BMCs than dst from msg; they are not handed
+// over to other relays. The relays of the links of those BMCs receive them
+// from src themselves, be they in this process or not: events that no relay
+// of this process delivers are only counted, as they may be relayed by
+// another process.
+func (r *relay) routeEvents(msg *chain.Message) {
+	for _, receipt := range msg.Receipts {
+		events := receipt.Events[:0]
+		for _, event := range receipt.Events {
+			switch {
+			case event.Next == "" || event.Next.Equal(r.cfg.Dst.Address):
+				events = append(events, event)
+			case r.hasRoute != nil && r.hasRoute(r.cfg.Src.Address, event.Next):
+				r.log.WithFields(log.Fields{
+					"next": event.Next, "seq": event.Sequence}).Trace("event routed to another relay")
+			default:
+				r.m.foreignEvents.Inc()
+				r.log.WithFields(log.Fields{
+					"next": event.Next, "seq": event.Sequence, "height": receipt.Height,
+				}).Debug("event to a link no relay of this process delivers")
+			}
+		}
+		receipt.Events = events
+	}
+}
+
+// withdrawReceipts ...
+// drops the pending receipts of srcMsg above height, whose blocks were
+// orphaned by a reorg of the src chain, and rewinds cp to height; it
+// returns the number of receipts dropped
+func (r *relay) withdrawReceipts(srcMsg *chain.Message, cp *Checkpoint, height uint64) int {
+	n := len(srcMsg.Receipts)
+	for n > 0 && srcMsg.Receipts[n-1].Height > height {
+		n--
+	}
+	withdrawn := srcMsg.Receipts[n:]
+	if len(withdrawn) > 0 {
+		cp.Seq = withdrawn[0].Events[0].Sequence - 1
+	}
+	srcMsg.Receipts = srcMsg.Receipts[:n]
+	if cp.Height > height {
+		cp.Height = height
+	}
+	r.m.orphanedReceipts.Add(float64(len(withdrawn)))
+	return len(withdrawn)
+}
+
+// loadCheckpoint ...
+// returns the persisted checkpoint of the relay if it is consistent with
+// the link status of the dst chain, otherwise returns nil
+func (r *relay) loadCheckpoint(link *chain.BMCLinkStatus) *Checkpoint {
+	if r.cps == nil {
+		return nil
+	}
+	cp, err := r.cps.Load(r.cfg.Name)
+	if err != nil {
+		r.log.WithFields(log.Fields{"error": err}).Warn("checkpoint: failed to load")
+		return nil
+	} else if cp == nil {
+		return nil
+	}
+	l := r.log.WithFields(log.Fields{
+		"height": cp.Height, "seq": cp.Seq, "rxSeq": link.RxSeq})
+	if cp.Seq < link.RxSeq {
+		// events upto link.RxSeq were relayed by someone else
+		l.Info("checkpoint: discarded, behind link status")
+		return nil
+	}
+
+	// pending receipts must continue right after link.RxSeq upto cp.Seq
+	rxSeq := link.RxSeq
+	receipts := cp.Receipts[:0]
+	for _, receipt := range cp.Receipts {
+		events := receipt.Events[:0]
+		for _, event := range receipt.Events {
+			if event.Sequence <= link.RxSeq {
+				continue
+			}
+			if rxSeq++; event.Sequence != rxSeq {
+				l.WithFields(log.Fields{"missing": rxSeq}).Warn(
+					"checkpoint: discarded, missing event sequence")
+				return nil
+			}
+			events = append(events, event)
+		}
+		receipt.Events = events
+		if len(receipt.Events) > 0 {
+			receipts = append(receipts, receipt)
+		}
+	}
+	if rxSeq != cp.Seq {
+		l.WithFields(log.Fields{"missing": rxSeq + 1}).Warn(
+			"checkpoint: discarded, missing event sequence")
+		return nil
+	}
+	cp.Receipts = receipts
+
+	if len(cp.TxIDs) > 0 {
+		// their effect, if any, is reflected in link status
+		l.WithFields(log.Fields{"txs": cp.TxIDs}).Info("checkpoint: in-flight txs")
+		cp.TxIDs = nil
+	}
+	l.WithFields(log.Fields{"receipts": len(cp.Receipts)}).Info("checkpoint: resuming")
+	return cp
+}
+
+// segment ...
+// returns a tx of the pending receipts in srcMsg, upto `limit` receipts if
+// `limit` is not zero, and the number of receipts segmented for the tx
+func (r *relay) segment(ctx context.Context, srcMsg *chain.Message, limit int) (
+	tx chain.RelayTx, newMsg *chain.Message, count int, err error) {
+	msg := srcMsg
+	if limit > 0 && len(srcMsg.Receipts) > limit {
+		msg = &chain.Message{
+			From:     srcMsg.From,
+			Receipts: srcMsg.Receipts[:limit:limit],
+			Height:   srcMsg.Height,
+		}
+	}
+	tx, newMsg, err = r.dst.Segment(ctx, msg)
+	if err != nil || tx == nil {
+		return tx, newMsg, 0, err
+	}
+	// newMsg retains all receipts of msg, if they all fit in tx
+	if count = len(msg.Receipts) - len(newMsg.Receipts); count <= 0 {
+		count = len(msg.Receipts)
+	}
+	if msg != srcMsg {
+		newMsg.Receipts = append(newMsg.Receipts, srcMsg.Receipts[limit:]...)
+	}
+	return tx, newMsg, count, nil
+}
+
+// sendTx sends tx, retrying until it is accepted or ctx is canceled
+func (r *relay) sendTx(ctx context.Context, tx chain.RelayTx) error {
+	for i := 1; ; i++ {
+		err := tx.Send(ctx)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, context.Canceled):
+			r.log.WithFields(log.Fields{"id": tx.ID(), "error": err}).Error("tx.Send failed")
+			return err
+		case errors.Is(err, chain.ErrInsufficientBalance):
+			r.m.txSendRetries.Inc()
+			wait := time.Duration(r.policy.InsufficientBalanceWaitInterval)
+			r.log.WithFields(log.Fields{"error": err}).Errorf(
+				"add balance to relay account: waiting for %v", wait)
+			time.Sleep(wait)
+		default:
+			r.m.txSendRetries.Inc()
+			time.Sleep(time.Duration(r.policy.TxSendWaitInterval)) // wait before sending tx
+			if i > retryWarnThreshold {
+				r.log.WithFields(log.Fields{"error": err}).Warnf("tx.Send: retry=%d", i)
+			} else {
+				r.log.WithFields(log.Fields{"error": err}).Debugf("tx.Send: retry=%d", i)
+			}
+		}
+	}
+}
+
 func (r *relay) Start(ctx context.Context) error {
+	// terminates the subscription to src, when the relay stops
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	link, err := r.dst.Status(ctx)
 	if err != nil {
@@ -60,22 +271,50 @@ func (r *relay) Start(ctx context.Context) error {
 		"rxHeight":      link.RxHeight,
 		"currentHeight": link.CurrentHeight,
 	}).Info("link status")
+	r.setRunning(true)
+	defer r.setRunning(false)
+	defer r.resign()
+	r.setLinkInfo(link)
+
+	srcMsg := &chain.Message{
+		From: r.cfg.Src.Address,
+	}
+
+	height, cp := r.rxHeight(link.RxHeight), r.loadCheckpoint(link)
+	if cp != nil {
+		srcMsg.Receipts = cp.Receipts
+		if cp.Height >= height {
+			height = cp.Height + 1
+		}
+	} else {
+		cp = &Checkpoint{Seq: link.RxSeq}
+		if height > 0 {
+			cp.Height = height - 1
+		}
+	}
+
+	cpDirty := false
+	saveCheckpoint := func() {
+		if cpDirty = false; r.cps == nil {
+			return
+		}
+		cp.Receipts = srcMsg.Receipts
+		if err := r.cps.Save(r.cfg.Name, cp); err != nil {
+			r.log.WithFields(log.Fields{"error": err}).Error("checkpoint: failed to save")
+		}
+	}
 
 	srcMsgCh := make(chan *chain.Message)
 	srcErrCh, err := r.src.Subscribe(ctx,
 		srcMsgCh,
 		chain.SubscribeOptions{
-			Seq:    link.RxSeq,
-			Height: r.rxHeight(link.RxHeight),
+			Seq:    cp.Seq,
+			Height: height,
 		})
 	if err != nil {
 		return err
 	}
 
-	srcMsg := &chain.Message{
-		From: r.cfg.Src.Address,
-	}
-
 	filterSrcMsg := func(rxHeight, rxSeq uint64) (missingRxSeq uint64) {
 		receipts := srcMsg.Receipts[:0]
 		for _, receipt := range srcMsg.Receipts {
@@ -101,21 +340,22 @@ func (r *relay) Start(ctx context.Context) error {
 		return 0
 	}
 
-	relayCh := make(chan struct{}, 1)
-	relayTicker := time.NewTicker(relayTickerInterval)
+	relayTicker := time.NewTicker(time.Duration(r.policy.TickerInterval))
 	defer relayTicker.Stop()
 	relaySignal := func() {
-		select {
-		case relayCh <- struct{}{}:
-		default:
-		}
-		relayTicker.Reset(relayTickerInterval)
+		r.Signal()
+		relayTicker.Reset(time.Duration(r.policy.TickerInterval))
 		r.log.Debug("relaySignal")
 	}
+	r.setPendingInfo(cp.Height, srcMsg)
 
 	txBlockHeight := link.CurrentHeight
+	// segmentLimit limits the number of receipts per tx, if not zero
+	segmentLimit := 0
+	// dry run txs are not reflected in link status
+	var dryRxHeight, dryRxSeq uint64
 
-	relayBalanceCheckTicker := time.NewTicker(relayBalanceCheckInterval)
+	relayBalanceCheckTicker := time.NewTicker(time.Duration(r.policy.BalanceCheckInterval))
 	defer relayBalanceCheckTicker.Stop()
 
 	for {
@@ -124,6 +364,10 @@ func (r *relay) Start(ctx context.Context) error {
 			return ctx.Err()
 
 		case <-relayTicker.C:
+			if cpDirty {
+				saveCheckpoint()
+			}
+			r.updateSrcMetrics(cp.Height, len(srcMsg.Receipts))
 			relaySignal()
 
 		case <-relayBalanceCheckTicker.C:
@@ -134,16 +378,41 @@ func (r *relay) Start(ctx context.Context) error {
 				l := r.log.WithFields(log.Fields{"balance": bal, "threshold": thres})
 				if err != nil {
 					l.Error("failed to fetch relay wallet balance")
-				} else if bal.Cmp(thres) <= 0 {
+					return
+				}
+				r.m.setBalance(bal, thres)
+				if bal.Cmp(thres) <= 0 {
 					l.Warn("relay wallet balance below threshold")
 				}
+				if wr, ok := r.dst.(WalletReporter); ok {
+					wallets := wr.Wallets()
+					r.m.setWallets(wallets)
+					r.setWalletInfo(wallets)
+				}
 			}()
 
 		case err := <-srcErrCh:
 			return err
 
+		case query := <-r.queryCh:
+			query(srcMsg)
+
 		case msg := <-srcMsgCh:
+			if msg.Rollback {
+				if link.RxHeight > msg.Height {
+					r.log.WithFields(log.Fields{"height": msg.Height, "rxHeight": link.RxHeight}).Error(
+						"receipts of orphaned src blocks already relayed")
+				}
+				if n := r.withdrawReceipts(srcMsg, cp, msg.Height); n > 0 {
+					r.log.WithFields(log.Fields{"height": msg.Height, "receipts": n, "seq": cp.Seq}).Warn(
+						"srcMsg: receipts of orphaned blocks withdrawn")
+				}
+				saveCheckpoint()
+				r.setPendingInfo(cp.Height, srcMsg)
+				continue
+			}
 
+			r.routeEvents(msg)
 			var seqBegin, seqEnd uint64
 			receipts := msg.Receipts[:0]
 			for _, receipt := range msg.Receipts {
@@ -157,16 +426,29 @@ func (r *relay) Start(ctx context.Context) error {
 			}
 			msg.Receipts = receipts
 
+			if n := len(msg.Receipts); n > 0 && msg.Receipts[n-1].Height > msg.Height {
+				msg.Height = msg.Receipts[n-1].Height
+			}
+			if msg.Height > cp.Height {
+				cp.Height, cpDirty = msg.Height, true
+			}
+
 			if len(msg.Receipts) > 0 {
 				r.log.WithFields(log.Fields{
 					"seq": []uint64{seqBegin, seqEnd}}).Debug("srcMsg added")
 				srcMsg.Receipts = append(srcMsg.Receipts, msg.Receipts...)
-				if len(srcMsg.Receipts) > relayTriggerReceiptsCount {
+				cp.Seq = seqEnd
+				saveCheckpoint()
+				if len(srcMsg.Receipts) > r.policy.TriggerReceiptsCount {
 					relaySignal()
 				}
 			}
+			r.setPendingInfo(cp.Height, srcMsg)
 
-		case <-relayCh:
+		case <-r.relayCh:
+			if r.isPaused() {
+				continue
+			}
 
 			link, err = r.dst.Status(ctx)
 			if err != nil {
@@ -179,72 +461,149 @@ func (r *relay) Start(ctx context.Context) error {
 				continue
 			}
 
+			r.m.setLink(link, cp.Seq)
+			r.setLinkInfo(link)
+
 			if link.CurrentHeight < txBlockHeight {
 				continue // skip until dst.Status is updated
 			}
 
-			if missing := filterSrcMsg(link.RxHeight, link.RxSeq); missing > 0 {
+			rxHeight, rxSeq := link.RxHeight, link.RxSeq
+			if r.cfg.DryRun != nil && dryRxSeq > rxSeq {
+				rxHeight, rxSeq = dryRxHeight, dryRxSeq
+			}
+			if missing := filterSrcMsg(rxHeight, rxSeq); missing > 0 {
 				r.log.WithFields(log.Fields{"rxSeq": missing}).Error("missing event sequence")
 				return fmt.Errorf("missing event sequence")
 			}
+			saveCheckpoint()
+			r.setPendingInfo(cp.Height, srcMsg)
 
-			tx, newMsg, err := r.dst.Segment(ctx, srcMsg)
+			if r.cfg.DryRun == nil && !r.isLeader(ctx, link, len(srcMsg.Receipts)) {
+				continue // standby
+			}
+
+			tx, newMsg, count, err := r.segment(ctx, srcMsg, segmentLimit)
 			if err != nil {
 				return err
 			} else if tx == nil { // ignore if tx is nil
 				continue
 			}
 
-		sendLoop:
-			for i, err := 1, tx.Send(ctx); true; i, err = i+1, tx.Send(ctx) {
-				switch {
-				case err == nil:
-					break sendLoop
-				case errors.Is(err, context.Canceled):
-					r.log.WithFields(log.Fields{"id": tx.ID(), "error": err}).Error("tx.Send failed")
-					return err
-				case errors.Is(err, chain.ErrInsufficientBalance):
-					r.log.WithFields(log.Fields{"error": err}).Errorf(
-						"add balance to relay account: waiting for %v", relayInsufficientBalanceWaitInterval)
-					time.Sleep(relayInsufficientBalanceWaitInterval)
-				default:
-					time.Sleep(relayTxSendWaitInterval) // wait before sending tx
-					if i > retryWarnThreshold {
-						r.log.WithFields(log.Fields{"error": err}).Warnf("tx.Send: retry=%d", i)
-					} else {
-						r.log.WithFields(log.Fields{"error": err}).Debugf("tx.Send: retry=%d", i)
-					}
+			if r.cfg.DryRun != nil {
+				receipts := srcMsg.Receipts[:count]
+				r.dryRun(tx, receipts)
+				last := receipts[count-1]
+				dryRxHeight, dryRxSeq = last.Height, last.Events[len(last.Events)-1].Sequence
+				if count < len(srcMsg.Receipts) {
+					r.Signal()
 				}
+				newMsg.From = srcMsg.From
+				srcMsg = newMsg
+				r.setPendingInfo(cp.Height, srcMsg)
+				continue
 			}
 
+			if err := r.sendTx(ctx, tx); err != nil {
+				return err
+			}
+			cp.TxIDs = []string{fmt.Sprint(tx.ID())}
+			saveCheckpoint()
+
 			retryCount := 0
 		waitLoop:
-			for blockHeight, err := tx.Receipt(ctx); retryCount < 30; _, err = tx.Receipt(ctx) {
+			for {
+				blockHeight, err := tx.Receipt(ctx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					r.m.addReceiptFailure(err)
+				}
 				switch {
 				case err == nil:
 					newMsg.From = srcMsg.From
 					srcMsg = newMsg
 					txBlockHeight = blockHeight
+					cp.TxIDs = nil
+					saveCheckpoint()
+					r.setPendingInfo(cp.Height, srcMsg)
+					if segmentLimit > 0 {
+						segmentLimit *= 2
+					}
 					break waitLoop
+
 				case errors.Is(err, context.Canceled):
 					r.log.WithFields(log.Fields{"error": err}).Error("tx.Receipt failed")
 					return err
+
 				case errors.Is(err, chain.ErrGasLimitExceeded):
-					// increase transaction gas limit
+					gtx, ok := tx.(chain.GasLimitRelayTx)
+					if !ok || !gtx.IncreaseGasLimit() {
+						r.log.WithFields(log.Fields{"id": tx.ID()}).Warn(
+							"tx.Receipt: gas limit exceeded, unable to raise gas limit")
+						break waitLoop
+					}
+					r.log.WithFields(log.Fields{"id": tx.ID()}).Info(
+						"tx.Receipt: gas limit exceeded, resending with higher gas limit")
+					if err := r.sendTx(ctx, tx); err != nil {
+						return err
+					}
+					cp.TxIDs = append(cp.TxIDs, fmt.Sprint(tx.ID()))
+					saveCheckpoint()
+					retryCount = 0
+
 				case errors.Is(err, chain.ErrBlockGasLimitExceeded):
-					// reduce batch size
+					if count <= 1 {
+						r.log.WithFields(log.Fields{"id": tx.ID()}).Error(
+							"tx.Receipt: block gas limit exceeded by a single receipt")
+						return err
+					}
+					segmentLimit = count / 2
+					r.log.WithFields(log.Fields{"id": tx.ID(), "receipts": segmentLimit}).Info(
+						"tx.Receipt: block gas limit exceeded, resegmenting")
+					r.Signal()
+					break waitLoop
+
 				case errors.Is(err, chain.ErrBMCRevertInvalidSeqNumber):
-					// messages skipped; refetch from source
+					// events were relayed by someone else or skipped; resume from link status
+					link, err = r.dst.Status(ctx)
+					if err != nil {
+						r.log.WithFields(log.Fields{"error": err}).Debug("dst.Status: failed")
+						if errors.Is(err, context.Canceled) {
+							return err
+						}
+						r.Signal()
+						break waitLoop
+					}
+					r.m.setLink(link, cp.Seq)
+					r.setLinkInfo(link)
+					if missing := filterSrcMsg(link.RxHeight, link.RxSeq); missing > 0 {
+						r.log.WithFields(log.Fields{"rxSeq": missing}).Error("missing event sequence")
+						return fmt.Errorf("missing event sequence")
+					}
+					r.log.WithFields(log.Fields{"rxSeq": link.RxSeq}).Info(
+						"tx.Receipt: invalid sequence number, resuming from link status")
+					cp.TxIDs = nil
+					saveCheckpoint()
+					r.setPendingInfo(cp.Height, srcMsg)
+					txBlockHeight = link.CurrentHeight
+					r.Signal()
+					break waitLoop
 
 				default:
-					time.Sleep(relayTxReceiptWaitInterval) // wait before asking for receipt
+					if retryCount++; retryCount >= r.policy.TxReceiptMaxRetries {
+						r.log.WithFields(log.Fields{"id": tx.ID(), "error": err}).Warn(
+							"tx.Receipt: giving up")
+						break waitLoop
+					}
+					time.Sleep(time.Duration(r.policy.TxReceiptWaitInterval)) // wait before asking for receipt
 					if retryCount > retryWarnThreshold {
-						r.log.WithFields(log.Fields{"error": err, "retry": retryCount + 1}).Warn("tx.Receipt: retry")
+						r.log.WithFields(log.Fields{"error": err, "retry": retryCount}).Warn("tx.Receipt: retry")
 					} else {
-						r.log.WithFields(log.Fields{"error": err, "retry": retryCount + 1}).Debug("tx.Receipt: retry")
+						r.log.WithFields(log.Fields{"error": err, "retry": retryCount}).Debug("tx.Receipt: retry")
 					}
 				}
-				retryCount++
+			}
+			if mtx, ok := tx.(chain.MeteredRelayTx); ok {
+				r.m.txGasUsed.Add(float64(mtx.GasUsed()))
 			}
 
 		}
@@ -0,0 +1,330 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registers the fake chain, to create relays with NewMultiRelay
+func init() {
+	Senders["fake"] = func(
+		src, dst chain.BTPAddress, urls []string, w wallet.Wallet,
+		opts json.RawMessage, l log.Logger) (chain.Sender, error) {
+		return &fakeSender{}, nil
+	}
+	Receivers["fake"] = func(
+		src, dst chain.BTPAddress, urls []string,
+		opts json.RawMessage, l log.Logger) (chain.Receiver, error) {
+		return &fakeReceiver{}, nil
+	}
+	ReceiverOptionValidators["fake"] = func(opts json.RawMessage) error {
+		return chain.UnmarshalOptionsStrict(opts, &struct {
+			Verifier json.RawMessage `json:"verifier"`
+		}{})
+	}
+}
+
+type fakeReceiver struct {
+	msgs []*chain.Message
+}
+
+func (fr *fakeReceiver) Subscribe(
+	ctx context.Context, msgCh chan<- *chain.Message,
+	opts chain.SubscribeOptions) (<-chan error, error) {
+	errCh := make(chan error)
+	go func() {
+		for _, msg := range fr.msgs {
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return errCh, nil
+}
+
+// fakeSender simulates the BMC of the dst chain
+type fakeSender struct {
+	mu   sync.Mutex
+	link chain.BMCLinkStatus
+	// gas spent by a tx for each receipt
+	gasPerReceipt uint64
+	gasLimit      uint64
+	blockGasLimit uint64
+	// external receipts relayed by someone else before the next tx
+	external []*chain.Receipt
+	txs      []*fakeTx
+}
+
+type fakeTx struct {
+	s        *fakeSender
+	receipts []*chain.Receipt
+	seqs     []uint64
+	gasLimit uint64
+	sent     int
+}
+
+func (fs *fakeSender) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	link := fs.link
+	return &link, nil
+}
+
+func (fs *fakeSender) Segment(
+	ctx context.Context, msg *chain.Message) (chain.RelayTx, *chain.Message, error) {
+	if len(msg.Receipts) == 0 {
+		return nil, msg, nil
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	tx := &fakeTx{s: fs, receipts: msg.Receipts, gasLimit: fs.gasLimit}
+	for _, receipt := range msg.Receipts {
+		for _, event := range receipt.Events {
+			tx.seqs = append(tx.seqs, event.Sequence)
+		}
+	}
+	fs.txs = append(fs.txs, tx)
+	return tx, &chain.Message{From: msg.From, Receipts: msg.Receipts[len(msg.Receipts):]}, nil
+}
+
+func (fs *fakeSender) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
+	return big.NewInt(1), big.NewInt(0), nil
+}
+
+// apply handles receipts like BMC.handleRelayMessage
+func (fs *fakeSender) apply(receipts []*chain.Receipt) error {
+	for _, receipt := range receipts {
+		for _, event := range receipt.Events {
+			if event.Sequence != fs.link.RxSeq+1 {
+				return chain.ErrBMCRevertInvalidSeqNumber
+			}
+			fs.link.RxSeq = event.Sequence
+		}
+		fs.link.RxHeight = receipt.Height
+	}
+	fs.link.CurrentHeight++
+	return nil
+}
+
+func (fs *fakeSender) rxSeq() uint64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.link.RxSeq
+}
+
+func (fs *fakeSender) segments() (segments [][]uint64) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, tx := range fs.txs {
+		segments = append(segments, tx.seqs)
+	}
+	return segments
+}
+
+func (tx *fakeTx) ID() interface{} {
+	return tx
+}
+
+func (tx *fakeTx) Send(ctx context.Context) error {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+	tx.sent++
+	return nil
+}
+
+func (tx *fakeTx) Receipt(ctx context.Context) (uint64, error) {
+	fs := tx.s
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if len(fs.external) > 0 {
+		if err := fs.apply(fs.external); err != nil {
+			return 0, err
+		}
+		fs.external = nil
+	}
+	gas := fs.gasPerReceipt * uint64(len(tx.receipts))
+	if fs.blockGasLimit > 0 && gas > fs.blockGasLimit {
+		return 0, chain.ErrBlockGasLimitExceeded
+	}
+	if tx.gasLimit > 0 && gas > tx.gasLimit {
+		return 0, chain.ErrGasLimitExceeded
+	}
+	if err := fs.apply(tx.receipts); err != nil {
+		return 0, err
+	}
+	return fs.link.CurrentHeight, nil
+}
+
+func (tx *fakeTx) IncreaseGasLimit() bool {
+	tx.gasLimit *= 2
+	return true
+}
+
+func newTestMessage(seqBegin, seqEnd uint64) *chain.Message {
+	msg := &chain.Message{}
+	for seq := seqBegin; seq <= seqEnd; seq++ {
+		msg.Receipts = append(msg.Receipts, newTestReceipt(10+seq, seq))
+	}
+	return msg
+}
+
+// runTestRelay runs a relay until `done` returns true
+func runTestRelay(t *testing.T, fs *fakeSender, fr *fakeReceiver, done func() bool) {
+	runTestRelayConfig(t, &RelayConfig{Name: t.Name()}, fs, fr, done)
+}
+
+func runTestRelayConfig(t *testing.T, cfg *RelayConfig, fs *fakeSender, fr *fakeReceiver, done func() bool) {
+	r := newRelay(cfg, fr, fs, nil, log.New())
+	r.policy.TickerInterval = Duration(10 * time.Millisecond)
+	r.policy.TxSendWaitInterval = Duration(time.Millisecond)
+	r.policy.TxReceiptWaitInterval = Duration(time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Start(ctx) }()
+	defer func() {
+		cancel()
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	}()
+
+	require.Eventually(t, done, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRelay_GasLimitExceeded(t *testing.T) {
+	fs := &fakeSender{gasPerReceipt: 10, gasLimit: 20}
+	fr := &fakeReceiver{msgs: []*chain.Message{newTestMessage(1, 3)}}
+
+	runTestRelay(t, fs, fr, func() bool { return fs.rxSeq() == 3 })
+
+	require.Len(t, fs.txs, 1)
+	assert.Equal(t, 2, fs.txs[0].sent)
+	assert.EqualValues(t, 40, fs.txs[0].gasLimit)
+}
+
+func TestRelay_BlockGasLimitExceeded(t *testing.T) {
+	fs := &fakeSender{gasPerReceipt: 10, blockGasLimit: 20}
+	fr := &fakeReceiver{msgs: []*chain.Message{newTestMessage(1, 4)}}
+
+	runTestRelay(t, fs, fr, func() bool { return fs.rxSeq() == 4 })
+
+	assert.Equal(t, [][]uint64{{1, 2, 3, 4}, {1, 2}, {3, 4}}, fs.segments())
+}
+
+func TestRelay_InvalidSeqNumber(t *testing.T) {
+	fs := &fakeSender{external: newTestMessage(1, 2).Receipts}
+	fr := &fakeReceiver{msgs: []*chain.Message{newTestMessage(1, 3)}}
+
+	runTestRelay(t, fs, fr, func() bool { return fs.rxSeq() == 3 })
+
+	assert.Equal(t, [][]uint64{{1, 2, 3}, {3}}, fs.segments())
+}
+
+func TestRelay_RouteEvents(t *testing.T) {
+	mr := &multiRelay{log: log.New()}
+	for _, dst := range []chain.BTPAddress{"btp://0x2.bsc/0xAbC", "btp://0x3.icon/cx01"} {
+		r := newRelay(&RelayConfig{
+			Name: t.Name() + dst.BlockChain(),
+			Src:  SrcConfig{ChainConfig: ChainConfig{Address: "btp://0x1.hmny/0x01"}},
+			Dst:  DstConfig{ChainConfig: ChainConfig{Address: dst}},
+		}, nil, nil, nil, log.New())
+		r.hasRoute = mr.hasRoute
+		mr.relays = append(mr.relays, r)
+	}
+	r := mr.relays[0]
+
+	msg := &chain.Message{Receipts: []*chain.Receipt{{
+		Height: 10,
+		Events: []*chain.Event{
+			{Next: "btp://0x2.bsc/0xabc", Sequence: 1},
+			{Next: "btp://0x3.icon/cx01", Sequence: 1},
+			{Next: "btp://0x4.icon/cx02", Sequence: 1},
+			{Sequence: 2},
+		},
+	}}}
+	foreign := testutil.ToFloat64(r.m.foreignEvents)
+	r.routeEvents(msg)
+	assert.Equal(t, []*chain.Event{
+		{Next: "btp://0x2.bsc/0xabc", Sequence: 1},
+		{Sequence: 2},
+	}, msg.Receipts[0].Events)
+	assert.Equal(t, foreign+1, testutil.ToFloat64(r.m.foreignEvents))
+}
+
+func TestRelay_WithdrawReceipts(t *testing.T) {
+	r := newRelay(&RelayConfig{Name: t.Name()}, nil, nil, nil, log.New())
+	srcMsg := newTestMessage(1, 4)
+	cp := &Checkpoint{Height: 15, Seq: 4}
+
+	orphaned := testutil.ToFloat64(r.m.orphanedReceipts)
+	assert.Equal(t, 2, r.withdrawReceipts(srcMsg, cp, 12))
+	require.Len(t, srcMsg.Receipts, 2)
+	assert.EqualValues(t, 12, srcMsg.Receipts[1].Height)
+	assert.Equal(t, &Checkpoint{Height: 12, Seq: 2}, cp)
+	assert.Equal(t, orphaned+2, testutil.ToFloat64(r.m.orphanedReceipts))
+
+	// no pending receipts above height
+	assert.Zero(t, r.withdrawReceipts(srcMsg, cp, 14))
+	assert.Len(t, srcMsg.Receipts, 2)
+	assert.Equal(t, &Checkpoint{Height: 12, Seq: 2}, cp)
+}
+
+func TestRelay_DryRun(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "dryrun.json")
+	fs := &fakeSender{}
+	fr := &fakeReceiver{msgs: []*chain.Message{newTestMessage(1, 3), newTestMessage(4, 5)}}
+
+	type dryRunTx struct {
+		DryRunTx
+		Tx json.RawMessage `json:"tx"`
+	}
+	readTxs := func() (txs []*dryRunTx) {
+		f, err := os.Open(output)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			tx := &dryRunTx{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), tx))
+			txs = append(txs, tx)
+		}
+		return txs
+	}
+	runTestRelayConfig(t, &RelayConfig{
+		Name:   t.Name(),
+		DryRun: &DryRunConfig{Output: output},
+	}, fs, fr, func() bool {
+		txs := readTxs()
+		return len(txs) > 0 && txs[len(txs)-1].SeqEnd == 5
+	})
+	time.Sleep(50 * time.Millisecond) // no txs after the last one
+
+	var seq uint64
+	for _, tx := range readTxs() {
+		assert.Equal(t, t.Name(), tx.Relay)
+		assert.Equal(t, seq+1, tx.SeqBegin)
+		seq = tx.SeqEnd
+	}
+	assert.EqualValues(t, 5, seq)
+	for _, tx := range fs.txs {
+		assert.Zero(t, tx.sent)
+	}
+	assert.Zero(t, fs.rxSeq())
+}
@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// Reloader is a Relay that applies a new config while running
+type Reloader interface {
+	Relay
+	Reload(cfg *Config) error
+}
+
+// Reload ...
+// applies the relays of cfg by name: it starts the added relays, stops
+// the removed ones and restarts those whose config changed, while the
+// others keep running. The config is applied only if all relays of cfg
+// can be created. Checkpoint and admin configs are not reloaded.
+func (mr *multiRelay) Reload(cfg *Config) error {
+	mr.reloadMu.Lock()
+	defer mr.reloadMu.Unlock()
+
+	current := map[string]*relay{}
+	for _, r := range mr.list() {
+		current[r.cfg.Name] = r
+	}
+	var relays, added []*relay
+	names := map[string]bool{}
+	for _, rc := range cfg.Relays {
+		if names[rc.Name] {
+			return fmt.Errorf("relay %s: duplicate name", rc.Name)
+		}
+		names[rc.Name] = true
+		if r, ok := current[rc.Name]; ok && sameRelayConfig(r.cfg, rc) {
+			relays = append(relays, r)
+			delete(current, rc.Name)
+			continue
+		}
+		r, err := mr.newRelay(rc)
+		if err != nil {
+			return err
+		}
+		relays = append(relays, r)
+		added = append(added, r)
+	}
+
+	mr.mu.Lock()
+	mr.relays = relays
+	mr.mu.Unlock()
+
+	// the stopped relays must exit before they are replaced, not to
+	// share checkpoints with the new ones
+	for name, r := range current {
+		if names[name] {
+			r.log.Info("reload: config changed, restarting relay")
+		} else {
+			r.log.Info("reload: relay removed")
+		}
+		r.stop()
+	}
+	for _, r := range added {
+		if _, ok := current[r.cfg.Name]; !ok {
+			r.log.Info("reload: relay added")
+		}
+		mr.queue(r)
+	}
+	mr.log.WithFields(log.Fields{
+		"relays": len(relays), "started": len(added), "stopped": len(current),
+	}).Info("reload: done")
+	return nil
+}
+
+// sameRelayConfig compares relay configs in json, to ignore the formatting
+// of raw options
+func sameRelayConfig(a, b *RelayConfig) bool {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ab, bb)
+}
+
+// begin records the cancel func of a new run of the relay; it returns
+// false if the relay was removed
+func (r *relay) begin(cancel context.CancelFunc) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.removed {
+		return false
+	}
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	return true
+}
+
+// end marks the current run of the relay done
+func (r *relay) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	close(r.done)
+}
+
+func (r *relay) isRemoved() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.removed
+}
+
+// stop stops the relay for good and waits for its current run to exit
+func (r *relay) stop() {
+	r.mu.Lock()
+	r.removed = true
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
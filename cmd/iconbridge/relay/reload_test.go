@@ -0,0 +1,110 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyPassword = "test"
+
+func newTestConfig(keyStore json.RawMessage, names ...string) *Config {
+	cfg := &Config{}
+	for _, name := range names {
+		rc := &RelayConfig{Name: name}
+		rc.Src.Address = "btp://0x1.fake/0x1"
+		rc.Dst.Address = "btp://0x2.fake/0x2"
+		rc.Dst.KeyStore = keyStore
+		rc.Dst.KeyPassword = testKeyPassword
+		cfg.Relays = append(cfg.Relays, rc)
+	}
+	return cfg
+}
+
+func waitRunning(t *testing.T, mr *multiRelay, names ...string) {
+	require.Eventually(t, func() bool {
+		relays := mr.list()
+		if len(relays) != len(names) {
+			return false
+		}
+		for i, r := range relays {
+			if r.cfg.Name != names[i] || r.Info().State != RelayStateRunning {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMultiRelay_Reload(t *testing.T) {
+	keyStore, err := wallet.KeyStoreFromWallet(wallet.New(), []byte(testKeyPassword))
+	require.NoError(t, err)
+	cfg := newTestConfig(keyStore, "a", "b")
+	r, err := NewMultiRelay(cfg, log.New())
+	require.NoError(t, err)
+	mr := r.(*multiRelay)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mr.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+	waitRunning(t, mr, "a", "b")
+	a, b := mr.list()[0], mr.list()[1]
+
+	// a unchanged, b changed, c added
+	newCfg := newTestConfig(keyStore, "a", "b", "c")
+	newCfg.Relays[0] = cfg.Relays[0]
+	newCfg.Relays[1].Policy = &PolicyConfig{TriggerReceiptsCount: 5}
+	require.NoError(t, mr.Reload(newCfg))
+	waitRunning(t, mr, "a", "b", "c")
+	assert.Same(t, a, mr.list()[0])
+	assert.NotSame(t, b, mr.list()[1])
+	assert.Equal(t, RelayStateStopped, b.Info().State)
+
+	// invalid configs are not applied
+	invalid := newTestConfig(keyStore, "a", "a")
+	assert.Error(t, mr.Reload(invalid))
+	invalid = newTestConfig(keyStore, "a", "d")
+	invalid.Relays[1].Dst.Address = "btp://0x2.unknown/0x2"
+	assert.Error(t, mr.Reload(invalid))
+	waitRunning(t, mr, "a", "b", "c")
+
+	// b and c removed
+	require.NoError(t, mr.Reload(&Config{Relays: newCfg.Relays[:1]}))
+	waitRunning(t, mr, "a")
+	assert.Same(t, a, mr.list()[0])
+}
+
+func TestMultiRelay_ReloadAfterStop(t *testing.T) {
+	keyStore, err := wallet.KeyStoreFromWallet(wallet.New(), []byte(testKeyPassword))
+	require.NoError(t, err)
+	r, err := NewMultiRelay(newTestConfig(keyStore, "a"), log.New())
+	require.NoError(t, err)
+	mr := r.(*multiRelay)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mr.Start(ctx) }()
+	waitRunning(t, mr, "a")
+	cancel()
+	<-errCh
+
+	// the relays added once stopped are not queued, more than rch holds
+	done := make(chan error, 1)
+	go func() { done <- mr.Reload(newTestConfig(keyStore, "b", "c")) }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("reload blocked after stop")
+	}
+}
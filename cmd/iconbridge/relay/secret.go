@@ -0,0 +1,64 @@
+package relay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// SecretProvider resolves a named secret, e.g. the password of a keystore,
+// from a secret store
+type SecretProvider interface {
+	Secret(name string) ([]byte, error)
+}
+
+// SecretProviderFunc is a SecretProvider of a function
+type SecretProviderFunc func(name string) ([]byte, error)
+
+func (f SecretProviderFunc) Secret(name string) ([]byte, error) {
+	return f(name)
+}
+
+// SecretProviders are the secret stores by the scheme of the references
+// to their secrets, e.g. "env://RELAY_KEY_PASSWORD"
+var SecretProviders = map[string]SecretProvider{
+	"file": SecretProviderFunc(fileSecret),
+	"env":  SecretProviderFunc(envSecret),
+}
+
+// ResolveSecret ...
+// returns the secret referenced as "<provider>://<name>", where provider is
+// one of SecretProviders; a reference without a scheme is a file path
+func ResolveSecret(ref string) ([]byte, error) {
+	scheme, name := "file", ref
+	if i := strings.Index(ref, "://"); i >= 0 {
+		scheme, name = ref[:i], ref[i+len("://"):]
+	}
+	p, ok := SecretProviders[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported secret provider: %q", scheme)
+	}
+	secret, err := p.Secret(name)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: %v", ref, err)
+	}
+	return secret, nil
+}
+
+// fileSecret reads the secret from the file, without the trailing newline
+func fileSecret(name string) ([]byte, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(b), "\r\n")), nil
+}
+
+func envSecret(name string) ([]byte, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable not set")
+	}
+	return []byte(v), nil
+}
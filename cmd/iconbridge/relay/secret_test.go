@@ -0,0 +1,125 @@
+package relay
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/common/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "password")
+	require.NoError(t, ioutil.WriteFile(file, []byte("from file\n"), 0600))
+	os.Setenv("RELAY_TEST_SECRET", "from env")
+	defer os.Unsetenv("RELAY_TEST_SECRET")
+	SecretProviders["test"] = SecretProviderFunc(func(name string) ([]byte, error) {
+		return []byte("vault " + name), nil
+	})
+	defer delete(SecretProviders, "test")
+
+	for _, tc := range []struct {
+		ref, secret string
+		err         bool
+	}{
+		{ref: file, secret: "from file"},
+		{ref: "file://" + file, secret: "from file"},
+		{ref: "env://RELAY_TEST_SECRET", secret: "from env"},
+		{ref: "test://relay/password", secret: "vault relay/password"},
+		{ref: filepath.Join(dir, "none"), err: true},
+		{ref: "env://RELAY_TEST_NONE", err: true},
+		{ref: "unknown://secret", err: true},
+	} {
+		secret, err := ResolveSecret(tc.ref)
+		if tc.err {
+			assert.Error(t, err, tc.ref)
+			continue
+		}
+		require.NoError(t, err, tc.ref)
+		assert.Equal(t, tc.secret, string(secret), tc.ref)
+	}
+}
+
+func TestWalletConfig_Wallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	w := wallet.New()
+	keyStore, err := wallet.KeyStoreFromWallet(w, []byte(testKeyPassword))
+	require.NoError(t, err)
+	ksFile := filepath.Join(dir, "keystore.json")
+	require.NoError(t, ioutil.WriteFile(ksFile, keyStore, 0600))
+	ksPath, _ := json.Marshal(ksFile)
+	os.Setenv("RELAY_TEST_PASSWORD", testKeyPassword)
+	defer os.Unsetenv("RELAY_TEST_PASSWORD")
+	defaultKeyStore, err := wallet.KeyStoreFromWallet(w, []byte(DefaultKeyPassword))
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		cfg WalletConfig
+		err bool
+	}{
+		"inline":            {cfg: WalletConfig{KeyStore: keyStore, KeyPassword: testKeyPassword}},
+		"path":              {cfg: WalletConfig{KeyStore: ksPath, KeyPassword: testKeyPassword}},
+		"secret":            {cfg: WalletConfig{KeyStore: ksPath, KeySecret: "env://RELAY_TEST_PASSWORD"}},
+		"secret first":      {cfg: WalletConfig{KeyStore: ksPath, KeyPassword: "wrong", KeySecret: "env://RELAY_TEST_PASSWORD"}},
+		"missing file":      {cfg: WalletConfig{KeyStore: json.RawMessage(`"none.json"`), KeyPassword: testKeyPassword}, err: true},
+		"default":           {cfg: WalletConfig{KeyStore: defaultKeyStore}, err: true},
+		"explicit default":  {cfg: WalletConfig{KeyStore: defaultKeyStore, KeyPassword: DefaultKeyPassword}, err: true},
+		"allowed default":   {cfg: WalletConfig{KeyStore: defaultKeyStore, AllowDefaultPassword: true}},
+		"remote signer":     {cfg: WalletConfig{Signer: &wallet.RemoteSignerConfig{URL: "http://127.0.0.1:1", Address: w.Address()}}},
+		"wrong password":    {cfg: WalletConfig{KeyStore: keyStore, KeyPassword: "wrong"}, err: true},
+		"unresolved secret": {cfg: WalletConfig{KeyStore: keyStore, KeySecret: "env://RELAY_TEST_NONE"}, err: true},
+	} {
+		got, err := tc.cfg.Wallet()
+		if tc.err {
+			assert.Error(t, err, name)
+			continue
+		}
+		require.NoError(t, err, name)
+		assert.Equal(t, w.Address(), got.Address(), name)
+	}
+}
+
+func TestDstConfig_WalletPool(t *testing.T) {
+	newKeyStore := func() (wallet.Wallet, json.RawMessage) {
+		w := wallet.New()
+		ks, err := wallet.KeyStoreFromWallet(w, []byte(testKeyPassword))
+		require.NoError(t, err)
+		return w, ks
+	}
+	w1, ks1 := newKeyStore()
+	w2, ks2 := newKeyStore()
+	wc1 := WalletConfig{KeyStore: ks1, KeyPassword: testKeyPassword}
+	wc2 := WalletConfig{KeyStore: ks2, KeyPassword: testKeyPassword}
+
+	for name, tc := range map[string]struct {
+		cfg  DstConfig
+		want []wallet.Wallet
+	}{
+		"single":     {cfg: DstConfig{WalletConfig: wc1}, want: []wallet.Wallet{w1}},
+		"key first":  {cfg: DstConfig{WalletConfig: wc1, Wallets: []*WalletConfig{&wc2}}, want: []wallet.Wallet{w1, w2}},
+		"only list":  {cfg: DstConfig{Wallets: []*WalletConfig{&wc2, &wc1}}, want: []wallet.Wallet{w2, w1}},
+		"duplicate":  {cfg: DstConfig{WalletConfig: wc1, Wallets: []*WalletConfig{&wc1}}},
+		"bad wallet": {cfg: DstConfig{WalletConfig: wc1, Wallets: []*WalletConfig{{KeyStore: ks2}}}},
+		"no wallet":  {cfg: DstConfig{}},
+	} {
+		ws, err := tc.cfg.WalletPool()
+		if tc.want == nil {
+			assert.Error(t, err, name)
+			continue
+		}
+		require.NoError(t, err, name)
+		require.Len(t, ws, len(tc.want), name)
+		for i, w := range tc.want {
+			assert.Equal(t, w.Address(), ws[i].Address(), name)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/errors"
+	"github.com/icon-project/icon-bridge/common/log"
+)
+
+// WalletInfo is the balance status of a wallet of a relay
+type WalletInfo struct {
+	Address   string   `json:"address"`
+	Balance   *big.Int `json:"balance,omitempty"`
+	Threshold *big.Int `json:"threshold,omitempty"`
+	// Active is false while the wallet is out of rotation, as its balance
+	// is not above the threshold
+	Active bool   `json:"active"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WalletReporter ...
+// is a Sender of several wallets that reports their balance status
+type WalletReporter interface {
+	Wallets() []WalletInfo
+}
+
+// walletPool ...
+// is a Sender of several wallets, with a sender of each wallet, for balance
+// failover. Relay txs are still sent one at a time, as the relay waits for
+// the receipt of a tx before the next one, by the wallets in turn, skipping
+// those whose balance is not above the threshold until it is refilled; a
+// tx that fails for the lack of balance is sent again by the next wallet.
+// Each sender tracks the nonces of its own wallet.
+type walletPool struct {
+	log     log.Logger
+	wallets []*poolWallet
+
+	mu sync.Mutex
+	// next is the index of the wallet to send the next tx
+	next int
+	// checked is set once the balances have been fetched
+	checked bool
+	// drained is set while no wallet is in rotation
+	drained bool
+}
+
+type poolWallet struct {
+	address string
+	sender  chain.Sender
+	info    WalletInfo
+}
+
+func newWalletPool(addresses []string, senders []chain.Sender, l log.Logger) *walletPool {
+	p := &walletPool{log: l}
+	for i, s := range senders {
+		p.wallets = append(p.wallets, &poolWallet{
+			address: addresses[i],
+			sender:  s,
+			info:    WalletInfo{Address: addresses[i], Active: true},
+		})
+	}
+	return p
+}
+
+// Endpoints implements chain.EndpointReporter with the endpoints of the
+// senders, shared by them
+func (p *walletPool) Endpoints() []chain.EndpointStatus {
+	if er, ok := p.wallets[0].sender.(chain.EndpointReporter); ok {
+		return er.Endpoints()
+	}
+	return nil
+}
+
+func (p *walletPool) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
+	return p.wallets[0].sender.Status(ctx)
+}
+
+func (p *walletPool) Segment(ctx context.Context, msg *chain.Message) (chain.RelayTx, *chain.Message, error) {
+	p.mu.Lock()
+	checked := p.checked
+	p.mu.Unlock()
+	if !checked {
+		p.refresh(ctx)
+	}
+	w, ok := p.pick(nil)
+	if !ok {
+		// the tx may still go through, the balance being only above zero
+		p.log.WithFields(log.Fields{log.FieldKeyWallet: w.address}).Debug(
+			"no wallet above threshold: send with the one of the most balance")
+	}
+	tx, newMsg, err := w.sender.Segment(ctx, msg)
+	if err != nil || tx == nil {
+		return tx, newMsg, err
+	}
+	return &poolTx{RelayTx: tx, p: p, w: w, msg: msg, rest: len(newMsg.Receipts)}, newMsg, nil
+}
+
+// Balance returns the total balance of the wallets, and the threshold of
+// a wallet
+func (p *walletPool) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
+	balance = new(big.Int)
+	for _, info := range p.refresh(ctx) {
+		if info.Balance == nil {
+			continue
+		}
+		balance.Add(balance, info.Balance)
+		threshold = info.Threshold
+	}
+	if threshold == nil {
+		return nil, nil, errors.New("failed to fetch the balance of any wallet")
+	}
+	return balance, threshold, nil
+}
+
+// Wallets returns the last known status of the wallets
+func (p *walletPool) Wallets() []WalletInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	infos := make([]WalletInfo, len(p.wallets))
+	for i, w := range p.wallets {
+		infos[i] = w.info
+	}
+	return infos
+}
+
+// refresh fetches the balances of the wallets, taking those not above the
+// threshold out of rotation and putting the refilled ones back
+func (p *walletPool) refresh(ctx context.Context) []WalletInfo {
+	type result struct {
+		bal, thres *big.Int
+		err        error
+	}
+	results := make([]result, len(p.wallets))
+	for i, w := range p.wallets {
+		bal, thres, err := w.sender.Balance(ctx)
+		results[i] = result{bal, thres, err}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checked = true
+	for i, w := range p.wallets {
+		res := results[i]
+		l := p.log.WithFields(log.Fields{
+			log.FieldKeyWallet: w.address, "balance": res.bal, "threshold": res.thres})
+		if res.err != nil {
+			l.WithFields(log.Fields{"error": res.err}).Warn("failed to fetch wallet balance")
+			w.info.Error = res.err.Error()
+			continue
+		}
+		active := res.bal.Cmp(res.thres) > 0
+		switch {
+		case w.info.Active && !active:
+			l.Warn("wallet out of rotation: balance below threshold")
+		case !w.info.Active && active:
+			l.Info("wallet back in rotation")
+		}
+		w.info = WalletInfo{Address: w.address, Balance: res.bal, Threshold: res.thres, Active: active}
+	}
+	infos := make([]WalletInfo, len(p.wallets))
+	for i, w := range p.wallets {
+		infos[i] = w.info
+	}
+	return infos
+}
+
+// pick returns the next active wallet not in skip, or the one of the most
+// balance and false if there is none, logging when the pool runs dry and
+// when it is refilled
+func (p *walletPool) pick(skip map[*poolWallet]bool) (*poolWallet, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.wallets)
+	for i := 0; i < n; i++ {
+		k := (p.next + i) % n
+		if w := p.wallets[k]; w.info.Active && !skip[w] {
+			p.next = (k + 1) % n
+			if p.drained {
+				p.drained = false
+				p.log.WithFields(log.Fields{log.FieldKeyWallet: w.address}).Info(
+					"wallet above threshold again")
+			}
+			return w, true
+		}
+	}
+	best, active := p.wallets[0], p.wallets[0].info.Active
+	for _, w := range p.wallets[1:] {
+		if w.info.Balance != nil && (best.info.Balance == nil || w.info.Balance.Cmp(best.info.Balance) > 0) {
+			best = w
+		}
+		active = active || w.info.Active
+	}
+	if !active && !p.drained {
+		p.drained = true
+		p.log.WithFields(log.Fields{log.FieldKeyWallet: best.address}).Error(
+			"no wallet above threshold: refill the wallets of the relay")
+	}
+	return best, false
+}
+
+// deactivate takes w out of rotation until its balance is refilled
+func (p *walletPool) deactivate(w *poolWallet) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if w.info.Active {
+		p.log.WithFields(log.Fields{log.FieldKeyWallet: w.address}).Warn(
+			"wallet out of rotation: insufficient balance")
+	}
+	w.info.Active = false
+}
+
+// poolTx ...
+// is a relay tx of a wallet of the pool, which is built again with
+// another wallet if the wallet runs out of balance, until every wallet has
+// been tried
+type poolTx struct {
+	chain.RelayTx
+	p *walletPool
+	w *poolWallet
+	// msg is the message of the tx, and rest is the number of its receipts
+	// that are not in the tx
+	msg  *chain.Message
+	rest int
+}
+
+func (tx *poolTx) Send(ctx context.Context) error {
+	err := tx.RelayTx.Send(ctx)
+	if !errors.Is(err, chain.ErrInsufficientBalance) {
+		return err
+	}
+	tried := map[*poolWallet]bool{}
+	for {
+		tried[tx.w] = true
+		tx.p.refresh(ctx)
+		tx.p.deactivate(tx.w)
+		w, ok := tx.p.pick(tried)
+		if !ok {
+			return err
+		}
+		ntx, newMsg, serr := w.sender.Segment(ctx, tx.msg)
+		if serr != nil || ntx == nil || len(newMsg.Receipts) != tx.rest {
+			return err
+		}
+		tx.p.log.WithFields(log.Fields{
+			"from": tx.w.address, "to": w.address}).Info("switch wallet of relay tx")
+		tx.RelayTx, tx.w = ntx, w
+		if serr = tx.RelayTx.Send(ctx); !errors.Is(serr, chain.ErrInsufficientBalance) {
+			return serr
+		}
+	}
+}
+
+func (tx *poolTx) GasUsed() uint64 {
+	if mtx, ok := tx.RelayTx.(chain.MeteredRelayTx); ok {
+		return mtx.GasUsed()
+	}
+	return 0
+}
+
+func (tx *poolTx) IncreaseGasLimit() bool {
+	if gtx, ok := tx.RelayTx.(chain.GasLimitRelayTx); ok {
+		return gtx.IncreaseGasLimit()
+	}
+	return false
+}
+
+func (tx *poolTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tx.RelayTx)
+}
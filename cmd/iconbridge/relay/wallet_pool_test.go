@@ -0,0 +1,151 @@
+package relay
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/chain"
+	"github.com/icon-project/icon-bridge/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walletSender is a sender of a wallet, whose txs cost testTxCost unless
+// cost is set
+type walletSender struct {
+	mu       sync.Mutex
+	name     string
+	balance  int64
+	cost     int64
+	sent     *[]string
+	segments int
+}
+
+const (
+	testTxCost    = 5
+	testThreshold = 10
+)
+
+type walletTx struct {
+	s *walletSender
+}
+
+func (s *walletSender) Status(ctx context.Context) (*chain.BMCLinkStatus, error) {
+	return &chain.BMCLinkStatus{}, nil
+}
+
+func (s *walletSender) Segment(ctx context.Context, msg *chain.Message) (chain.RelayTx, *chain.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.segments++
+	return &walletTx{s}, &chain.Message{From: msg.From}, nil
+}
+
+func (s *walletSender) Balance(ctx context.Context) (balance, threshold *big.Int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return big.NewInt(s.balance), big.NewInt(testThreshold), nil
+}
+
+func (s *walletSender) setBalance(balance int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balance = balance
+}
+
+func (tx *walletTx) ID() interface{} {
+	return tx
+}
+
+func (tx *walletTx) Send(ctx context.Context) error {
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+	cost := tx.s.cost
+	if cost == 0 {
+		cost = testTxCost
+	}
+	if tx.s.balance < cost {
+		return chain.ErrInsufficientBalance
+	}
+	tx.s.balance -= cost
+	*tx.s.sent = append(*tx.s.sent, tx.s.name)
+	return nil
+}
+
+func (tx *walletTx) Receipt(ctx context.Context) (uint64, error) {
+	return 1, nil
+}
+
+func TestWalletPool(t *testing.T) {
+	var sent []string
+	a := &walletSender{name: "a", balance: 100, sent: &sent}
+	b := &walletSender{name: "b", balance: 100, sent: &sent}
+	c := &walletSender{name: "c", balance: 5, sent: &sent}
+	p := newWalletPool([]string{"a", "b", "c"}, []chain.Sender{a, b, c}, log.New())
+	ctx := context.Background()
+	send := func() error {
+		tx, _, err := p.Segment(ctx, newTestMessage(1, 1))
+		require.NoError(t, err)
+		return tx.Send(ctx)
+	}
+	active := func() (names []string) {
+		for _, w := range p.Wallets() {
+			if w.Active {
+				names = append(names, w.Address)
+			}
+		}
+		return names
+	}
+
+	// c is below the threshold
+	for i := 0; i < 3; i++ {
+		require.NoError(t, send())
+	}
+	assert.Equal(t, []string{"a", "b", "a"}, sent)
+	assert.Equal(t, []string{"a", "b"}, active())
+
+	// b is drained before its turn, the tx is sent by a instead
+	b.setBalance(2)
+	require.NoError(t, send())
+	assert.Equal(t, []string{"a", "b", "a", "a"}, sent)
+	assert.Equal(t, []string{"a"}, active())
+
+	// refilled wallets are back in rotation on the balance check
+	c.setBalance(100)
+	bal, thres, err := p.Balance(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 85+2+100, bal.Int64())
+	assert.EqualValues(t, testThreshold, thres.Int64())
+	assert.Equal(t, []string{"a", "c"}, active())
+	require.NoError(t, send())
+	assert.Equal(t, "c", sent[len(sent)-1])
+
+	// all drained
+	a.setBalance(0)
+	c.setBalance(0)
+	assert.ErrorIs(t, send(), chain.ErrInsufficientBalance)
+	assert.Empty(t, active())
+	assert.True(t, p.drained)
+	a.setBalance(100)
+	require.NoError(t, send(), "refilled")
+	assert.False(t, p.drained)
+	assert.Equal(t, "a", sent[len(sent)-1])
+}
+
+func TestWalletPool_AllTried(t *testing.T) {
+	var sent []string
+	// both above the threshold, but below the cost of the tx
+	a := &walletSender{name: "a", balance: 20, cost: 50, sent: &sent}
+	b := &walletSender{name: "b", balance: 20, cost: 50, sent: &sent}
+	p := newWalletPool([]string{"a", "b"}, []chain.Sender{a, b}, log.New())
+	ctx := context.Background()
+
+	tx, _, err := p.Segment(ctx, newTestMessage(1, 1))
+	require.NoError(t, err)
+	assert.ErrorIs(t, tx.Send(ctx), chain.ErrInsufficientBalance)
+	assert.Empty(t, sent)
+	assert.Equal(t, 2, a.segments+b.segments, "each wallet tried once")
+	assert.False(t, p.drained, "wallets above threshold")
+}
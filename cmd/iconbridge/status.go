@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/icon-project/icon-bridge/cmd/iconbridge/relay"
+	"github.com/icon-project/icon-bridge/common/cli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	statusTimeout = 10 * time.Second
+)
+
+func newStatusCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status [name]",
+		Short: "Print status of relays from admin api",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("admin")
+			if address == "" {
+				cfg, err := loadConfig(parentVc)
+				if err != nil {
+					return err
+				}
+				if cfg.Admin == nil || cfg.Admin.Address == "" {
+					return fmt.Errorf("admin api is not configured, use --admin")
+				}
+				address = cfg.Admin.Address
+			}
+			infos, err := getRelayInfos(address, args)
+			if err != nil {
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return cli.JsonPrettyPrintln(os.Stdout, infos)
+			}
+			printRelayInfos(os.Stdout, infos)
+			return nil
+		},
+	}
+	parentCmd.AddCommand(cmd)
+	flags := cmd.Flags()
+	flags.String("admin", "", "Address of admin api, admin.address of the config by default")
+	flags.Bool("json", false, "Print the status in json")
+	return cmd
+}
+
+// getRelayInfos queries the admin api for all relays, or the named one
+func getRelayInfos(address string, args []string) ([]relay.RelayInfo, error) {
+	url := "http://" + address + "/relays"
+	if len(args) > 0 {
+		url += "/" + args[0]
+	}
+	client := &http.Client{Timeout: statusTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	var infos []relay.RelayInfo
+	d := json.NewDecoder(resp.Body)
+	if len(args) > 0 {
+		var info relay.RelayInfo
+		err = d.Decode(&info)
+		infos = append(infos, info)
+	} else {
+		err = d.Decode(&infos)
+	}
+	return infos, err
+}
+
+func printRelayInfos(w io.Writer, infos []relay.RelayInfo) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tSRC\tDST\tHEIGHT\tRXSEQ\tPENDING")
+	for _, info := range infos {
+		state := info.State
+		if info.DryRun {
+			state += " (dry-run)"
+		}
+		rxSeq := "-"
+		if info.Link != nil {
+			rxSeq = fmt.Sprint(info.Link.RxSeq)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%d\n",
+			info.Name, state, info.Src, info.Dst, info.Height, rxSeq, info.Pending.Receipts)
+	}
+	tw.Flush()
+}
@@ -2,8 +2,11 @@ package wallet
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gofrs/uuid"
 )
 
 func DecryptEvmKeyStore(ksData, pw []byte) (*ecdsa.PrivateKey, error) {
@@ -13,3 +16,27 @@ func DecryptEvmKeyStore(ksData, pw []byte) (*ecdsa.PrivateKey, error) {
 	}
 	return key.PrivateKey, nil
 }
+
+// EncryptEvmKeyAsKeyStore returns the keystore of the key, with coinType
+// "evm" to be decrypted by DecryptKeyStore
+func EncryptEvmKeyAsKeyStore(sk *ecdsa.PrivateKey, pw []byte) ([]byte, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	key := &keystore.Key{
+		Id:         [16]byte(id),
+		Address:    crypto.PubkeyToAddress(sk.PublicKey),
+		PrivateKey: sk,
+	}
+	b, err := keystore.EncryptKey(key, string(pw), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return nil, err
+	}
+	var ks map[string]interface{}
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, err
+	}
+	ks["coinType"] = coinTypeEVM
+	return json.Marshal(ks)
+}
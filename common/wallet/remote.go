@@ -0,0 +1,293 @@
+package wallet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/icon-project/icon-bridge/common"
+	"github.com/icon-project/icon-bridge/common/crypto"
+	"github.com/icon-project/icon-bridge/common/jsonrpc"
+)
+
+const (
+	// RemoteSignerWeb3Signer and RemoteSignerClef are the protocols of the
+	// remote signers of evm keys: the transaction is sent to be signed with
+	// eth_signTransaction of Web3Signer, or account_signTransaction of Clef,
+	// which return it signed in rlp
+	RemoteSignerWeb3Signer = "web3signer"
+	RemoteSignerClef       = "clef"
+
+	// RemoteIconSignMethod is the json-rpc method of remote signers of icon
+	// keys: the params are those of icx_sendTransaction but the signature,
+	// and it returns the signature in base64, as in icx_sendTransaction
+	RemoteIconSignMethod = "icx_signTransaction"
+
+	remoteSignerTimeout = 10 * time.Second
+)
+
+// RemoteSignerConfig configures a wallet of a remote signer
+type RemoteSignerConfig struct {
+	// URL of the json-rpc api of the signer
+	URL string `json:"url"`
+	// Address of the key to sign with, e.g. "hx..." or "0x..."
+	Address string `json:"address"`
+	// Type of the key, coinType of keystores, "icx" or "evm"; by the prefix
+	// of Address if empty
+	Type string `json:"type,omitempty"`
+	// Protocol of the signer of an evm key, RemoteSignerWeb3Signer if empty
+	// or RemoteSignerClef
+	Protocol string `json:"protocol,omitempty"`
+	// Headers are added to requests, e.g. for authorization
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// EvmTxSigner ...
+// is a Wallet that signs evm transactions rather than their hashes, such as
+// a remote signer; see NewEvmTransactor
+type EvmTxSigner interface {
+	SignEvmTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// IconTxSigner ...
+// is a Wallet that signs icon transactions rather than their hashes, such
+// as a remote signer: tx is the json of the params of icx_sendTransaction,
+// whose hash is hash, and it returns the signature in the format of Sign
+type IconTxSigner interface {
+	SignIconTransaction(tx json.RawMessage, hash []byte) ([]byte, error)
+}
+
+// remoteEvmTx ...
+// are the args of the transaction to sign of eth_signTransaction and
+// account_signTransaction
+type remoteEvmTx struct {
+	From                 ethcommon.Address  `json:"from"`
+	To                   *ethcommon.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64     `json:"gas"`
+	GasPrice             *hexutil.Big       `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big       `json:"value"`
+	Nonce                hexutil.Uint64     `json:"nonce"`
+	Data                 hexutil.Bytes      `json:"data"`
+	ChainID              *hexutil.Big       `json:"chainId,omitempty"`
+}
+
+// remoteWallet ...
+// delegates signing to a remote signer, which holds the key. It sends the
+// transactions themselves, so that the signer may check them, and refuses
+// to sign bare hashes. It verifies that the signatures are of the address,
+// and learns the public key from them.
+type remoteWallet struct {
+	address  string
+	coinType string
+	protocol string
+	client   *jsonrpc.Client
+
+	mu     sync.RWMutex
+	pubKey []byte
+}
+
+func NewRemoteWallet(cfg *RemoteSignerConfig) (Wallet, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("remote signer: empty url")
+	}
+	coinType := cfg.Type
+	if coinType == "" {
+		if strings.HasPrefix(cfg.Address, "0x") {
+			coinType = coinTypeEVM
+		} else {
+			coinType = coinTypeICON
+		}
+	}
+	protocol := cfg.Protocol
+	switch coinType {
+	case coinTypeICON:
+		if err := new(common.Address).SetString(cfg.Address); err != nil || !strings.HasPrefix(cfg.Address, "hx") {
+			return nil, fmt.Errorf("remote signer: invalid address %q", cfg.Address)
+		}
+		if protocol != "" {
+			return nil, fmt.Errorf("remote signer: invalid protocol %q of icx key", protocol)
+		}
+	case coinTypeEVM:
+		if !ethcommon.IsHexAddress(cfg.Address) {
+			return nil, fmt.Errorf("remote signer: invalid address %q", cfg.Address)
+		}
+		switch protocol {
+		case "":
+			protocol = RemoteSignerWeb3Signer
+		case RemoteSignerWeb3Signer, RemoteSignerClef:
+		default:
+			return nil, fmt.Errorf("remote signer: invalid protocol %q", protocol)
+		}
+	default:
+		return nil, fmt.Errorf("remote signer: invalid type %q", coinType)
+	}
+	client := jsonrpc.NewJsonRpcClient(&http.Client{Timeout: remoteSignerTimeout}, cfg.URL)
+	for k, v := range cfg.Headers {
+		client.CustomHeader[k] = v
+	}
+	return &remoteWallet{address: cfg.Address, coinType: coinType, protocol: protocol, client: client}, nil
+}
+
+func (w *remoteWallet) Address() string {
+	return w.address
+}
+
+// Sign refuses to have the signer sign data blindly, see SignEvmTransaction
+// and SignIconTransaction
+func (w *remoteWallet) Sign(data []byte) ([]byte, error) {
+	return nil, errors.New("remote signer: only transactions are signed, not hashes")
+}
+
+// SignEvmTransaction implements EvmTxSigner with the protocol of the signer
+func (w *remoteWallet) SignEvmTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if w.coinType != coinTypeEVM {
+		return nil, fmt.Errorf("remote signer: not an evm key: %s", w.address)
+	}
+	method := "eth_signTransaction"
+	if w.protocol == RemoteSignerClef {
+		method = "account_signTransaction"
+	}
+	var result json.RawMessage
+	if _, err := w.client.Do(method, []interface{}{newRemoteEvmTx(w.address, tx, chainID)}, &result); err != nil {
+		return nil, fmt.Errorf("remote signer: %v", err)
+	}
+	// a string of Web3Signer, {"raw": ..., "tx": ...} of Clef
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err != nil {
+		var res struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return nil, fmt.Errorf("remote signer: invalid result: %v", err)
+		}
+		raw = res.Raw
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer: invalid transaction: %v", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	if signer.Hash(signed) != hash {
+		return nil, errors.New("remote signer: signed another transaction")
+	}
+	v, r, s := signed.RawSignatureValues()
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	// v is the recovery id but for legacy txs: 27 + id, or chainID*2 + 35
+	// + id since eip-155
+	if signed.Type() == types.LegacyTxType {
+		v = new(big.Int).Sub(v, big.NewInt(27))
+		if signed.Protected() {
+			v.Sub(v, new(big.Int).Lsh(chainID, 1)).Sub(v, big.NewInt(8))
+		}
+	}
+	sig[64] = byte(v.Uint64())
+	if err := w.verify(hash.Bytes(), sig); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+func newRemoteEvmTx(from string, tx *types.Transaction, chainID *big.Int) *remoteEvmTx {
+	rtx := &remoteEvmTx{
+		From:    ethcommon.HexToAddress(from),
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		rtx.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		rtx.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		rtx.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+	return rtx
+}
+
+// SignIconTransaction implements IconTxSigner with RemoteIconSignMethod
+func (w *remoteWallet) SignIconTransaction(tx json.RawMessage, hash []byte) ([]byte, error) {
+	if w.coinType != coinTypeICON {
+		return nil, fmt.Errorf("remote signer: not an icx key: %s", w.address)
+	}
+	var sig string
+	if _, err := w.client.Do(RemoteIconSignMethod, tx, &sig); err != nil {
+		return nil, fmt.Errorf("remote signer: %v", err)
+	}
+	bs, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: invalid signature: %v", err)
+	}
+	if err := w.verify(hash, bs); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// verify verifies that sig of hash is of the address, and learns the public
+// key from it
+func (w *remoteWallet) verify(hash, sig []byte) error {
+	pubKey, address, err := recoverAddress(w.coinType, hash, sig)
+	if err != nil {
+		return fmt.Errorf("remote signer: invalid signature: %v", err)
+	}
+	if !strings.EqualFold(address, w.address) {
+		return fmt.Errorf("remote signer: signed by %s, not %s", address, w.address)
+	}
+	w.mu.Lock()
+	w.pubKey = pubKey
+	w.mu.Unlock()
+	return nil
+}
+
+// PublicKey returns the public key recovered from the last signature, nil
+// until the first one
+func (w *remoteWallet) PublicKey() []byte {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.pubKey
+}
+
+func (w *remoteWallet) ECDH(pubKey []byte) ([]byte, error) {
+	return nil, errors.New("remote signer: ECDH is not supported")
+}
+
+// recoverAddress returns the public key and the address which signed hash,
+// in the format of the wallets of coinType
+func recoverAddress(coinType string, hash, sig []byte) ([]byte, string, error) {
+	switch coinType {
+	case coinTypeEVM:
+		pub, err := ethcrypto.SigToPub(hash, sig)
+		if err != nil {
+			return nil, "", err
+		}
+		return ethcrypto.FromECDSAPub(pub), ethcrypto.PubkeyToAddress(*pub).Hex(), nil
+	default:
+		s, err := crypto.ParseSignature(sig)
+		if err != nil {
+			return nil, "", err
+		}
+		pub, err := s.RecoverPublicKey(hash)
+		if err != nil {
+			return nil, "", err
+		}
+		return pub.SerializeCompressed(), common.NewAccountAddressFromPublicKey(pub).String(), nil
+	}
+}
@@ -0,0 +1,261 @@
+package wallet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/icon-project/icon-bridge/common/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvmWallet(t *testing.T) *EvmWallet {
+	sk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	w, err := NewEvmWalletFromPrivateKey(sk)
+	require.NoError(t, err)
+	return w
+}
+
+// testIconHash stands in for the hash of icon transactions, which is
+// computed by the icon chain package
+func testIconHash(tx json.RawMessage) []byte {
+	return crypto.Keccak256(tx)
+}
+
+// newSignerHandler ...
+// returns the json-rpc handler of a stand-in remote signer of the wallets,
+// which signs the transactions of eth_signTransaction,
+// account_signTransaction and RemoteIconSignMethod by their hashes
+func newSignerHandler(ws ...Wallet) http.Handler {
+	wallets := map[string]Wallet{}
+	for _, w := range ws {
+		wallets[strings.ToLower(w.Address())] = w
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.Request
+		resp := &jsonrpc.Response{Version: jsonrpc.Version}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp.Error = &jsonrpc.Error{Code: jsonrpc.ErrorCodeJsonParse, Message: err.Error()}
+		} else {
+			resp.ID = req.ID
+			resp.Result, resp.Error = signRequest(wallets, &req)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(resp)
+	})
+}
+
+func signRequest(wallets map[string]Wallet, req *jsonrpc.Request) (interface{}, *jsonrpc.Error) {
+	invalid := func(err error) *jsonrpc.Error {
+		return &jsonrpc.Error{Code: jsonrpc.ErrorCodeInvalidParams, Message: err.Error()}
+	}
+	wallet := func(address string) (Wallet, *jsonrpc.Error) {
+		w, ok := wallets[strings.ToLower(address)]
+		if !ok {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeInvalidParams, Message: "unknown address: " + address}
+		}
+		return w, nil
+	}
+	switch req.Method {
+	case "eth_signTransaction", "account_signTransaction":
+		var args []remoteEvmTx
+		if err := json.Unmarshal(req.Params, &args); err != nil || len(args) == 0 {
+			return nil, invalid(err)
+		}
+		rtx := args[0]
+		w, jerr := wallet(rtx.From.Hex())
+		if jerr != nil {
+			return nil, jerr
+		}
+		var tx *types.Transaction
+		if rtx.MaxFeePerGas != nil {
+			tx = types.NewTx(&types.DynamicFeeTx{
+				ChainID: rtx.ChainID.ToInt(), Nonce: uint64(rtx.Nonce), To: rtx.To, Gas: uint64(rtx.Gas),
+				GasFeeCap: rtx.MaxFeePerGas.ToInt(), GasTipCap: rtx.MaxPriorityFeePerGas.ToInt(),
+				Value: rtx.Value.ToInt(), Data: rtx.Data,
+			})
+		} else {
+			tx = types.NewTx(&types.LegacyTx{
+				Nonce: uint64(rtx.Nonce), To: rtx.To, Gas: uint64(rtx.Gas),
+				GasPrice: rtx.GasPrice.ToInt(), Value: rtx.Value.ToInt(), Data: rtx.Data,
+			})
+		}
+		signer := types.LatestSignerForChainID(rtx.ChainID.ToInt())
+		sig, err := w.Sign(signer.Hash(tx).Bytes())
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeServer, Message: err.Error()}
+		}
+		if tx, err = tx.WithSignature(signer, sig); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeServer, Message: err.Error()}
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeServer, Message: err.Error()}
+		}
+		if req.Method == "account_signTransaction" {
+			return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": tx}, nil
+		}
+		return hexutil.Bytes(raw), nil
+
+	case RemoteIconSignMethod:
+		var params struct {
+			From      string `json:"from"`
+			Signature string `json:"signature"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		w, jerr := wallet(params.From)
+		if jerr != nil {
+			return nil, jerr
+		}
+		sig, err := w.Sign(testIconHash(req.Params))
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeServer, Message: err.Error()}
+		}
+		return base64.StdEncoding.EncodeToString(sig), nil
+
+	default:
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrorCodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func TestRemoteWallet(t *testing.T) {
+	icx, evm := New(), newEvmWallet(t)
+	srv := httptest.NewServer(newSignerHandler(icx, evm))
+	defer srv.Close()
+
+	for _, local := range []Wallet{icx, evm} {
+		w, err := NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: local.Address()})
+		require.NoError(t, err)
+		assert.Equal(t, local.Address(), w.Address())
+		assert.Nil(t, w.PublicKey())
+		_, err = w.Sign(crypto.Keccak256([]byte("relay message")))
+		assert.Error(t, err, "hashes are not signed blindly")
+	}
+
+	w := mustRemoteWallet(t, srv.URL, icx.Address())
+	tx := json.RawMessage(`{"from":"` + icx.Address() + `","to":"cx0000000000000000000000000000000000000001","nid":"0x1"}`)
+	sig, err := w.(IconTxSigner).SignIconTransaction(tx, testIconHash(tx))
+	require.NoError(t, err)
+	assert.Len(t, sig, 65)
+	// verified by the remote wallet, with the recovered public key
+	assert.Equal(t, icx.PublicKey(), w.PublicKey())
+	_, err = w.(IconTxSigner).SignIconTransaction(tx, crypto.Keccak256([]byte("another tx")))
+	assert.Error(t, err, "signature of another tx")
+
+	unknown := New().Address()
+	tx = json.RawMessage(`{"from":"` + unknown + `"}`)
+	_, err = mustRemoteWallet(t, srv.URL, unknown).(IconTxSigner).SignIconTransaction(tx, testIconHash(tx))
+	assert.Error(t, err, "unknown address")
+
+	_, err = NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: "cx0000000000000000000000000000000000000000"})
+	assert.Error(t, err, "contract address")
+	_, err = NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: "0x1234"})
+	assert.Error(t, err, "invalid evm address")
+	_, err = NewRemoteWallet(&RemoteSignerConfig{Address: evm.Address()})
+	assert.Error(t, err, "empty url")
+	_, err = NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: evm.Address(), Protocol: "sign"})
+	assert.Error(t, err, "invalid protocol")
+	_, err = NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: icx.Address(), Protocol: RemoteSignerClef})
+	assert.Error(t, err, "protocol of icx key")
+}
+
+// impostor signs for the address with the key of another wallet
+type impostor struct {
+	Wallet
+	address string
+}
+
+func (w *impostor) Address() string {
+	return w.address
+}
+
+func TestRemoteWallet_WrongSigner(t *testing.T) {
+	w := newEvmWallet(t)
+	srv := httptest.NewServer(newSignerHandler(&impostor{newEvmWallet(t), w.Address()}))
+	defer srv.Close()
+
+	rw := mustRemoteWallet(t, srv.URL, w.Address())
+	tx := types.NewTransaction(1, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1e9), nil)
+	_, err := rw.(EvmTxSigner).SignEvmTransaction(tx, big.NewInt(97))
+	assert.Error(t, err)
+	assert.Nil(t, rw.PublicKey())
+}
+
+func TestRemoteWallet_AnotherTransaction(t *testing.T) {
+	local := newEvmWallet(t)
+	chainID := big.NewInt(97)
+	// the signer signs another tx than the one sent
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		tx := types.NewTransaction(2, common.Address{2}, big.NewInt(100), 21000, big.NewInt(1e9), nil)
+		tx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), local.Skey)
+		require.NoError(t, err)
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(&jsonrpc.Response{Version: jsonrpc.Version, ID: req.ID, Result: hexutil.Bytes(raw)})
+	}))
+	defer srv.Close()
+
+	w := mustRemoteWallet(t, srv.URL, local.Address())
+	tx := types.NewTransaction(1, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1e9), nil)
+	_, err := w.(EvmTxSigner).SignEvmTransaction(tx, chainID)
+	assert.Error(t, err)
+}
+
+func TestNewEvmTransactor(t *testing.T) {
+	local := newEvmWallet(t)
+	srv := httptest.NewServer(newSignerHandler(local))
+	defer srv.Close()
+	chainID := big.NewInt(97)
+	clef, err := NewRemoteWallet(&RemoteSignerConfig{URL: srv.URL, Address: local.Address(), Protocol: RemoteSignerClef})
+	require.NoError(t, err)
+
+	to := common.Address{1}
+	txs := []*types.Transaction{
+		types.NewTransaction(1, to, big.NewInt(1), 21000, big.NewInt(1e9), nil),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 2, To: &to, Gas: 21000,
+			GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9), Value: big.NewInt(1), Data: []byte{1}}),
+	}
+	for _, w := range []Wallet{local, mustRemoteWallet(t, srv.URL, local.Address()), clef} {
+		txo, err := NewEvmTransactor(w, chainID)
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToAddress(local.Address()), txo.From)
+
+		for _, tx := range txs {
+			signed, err := txo.Signer(txo.From, tx)
+			require.NoError(t, err)
+			from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+			require.NoError(t, err)
+			assert.Equal(t, txo.From, from)
+		}
+		if _, ok := w.(EvmTxSigner); ok {
+			assert.Equal(t, local.PublicKey(), w.PublicKey())
+		}
+
+		_, err = txo.Signer(common.Address{2}, txs[0])
+		assert.Error(t, err)
+	}
+
+	_, err = NewEvmTransactor(New(), chainID)
+	assert.Error(t, err, "icon wallet")
+}
+
+func mustRemoteWallet(t *testing.T, url, address string) Wallet {
+	w, err := NewRemoteWallet(&RemoteSignerConfig{URL: url, Address: address})
+	require.NoError(t, err)
+	return w
+}
@@ -1,10 +1,14 @@
 package wallet
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -18,9 +22,10 @@ func (w *EvmWallet) Address() string {
 	return common.BytesToAddress(crypto.Keccak256(pubBytes[1:])[12:]).Hex()
 }
 
+// Sign signs the hash with the key, in the [R || S || V] format where V is
+// 0 or 1
 func (w *EvmWallet) Sign(data []byte) ([]byte, error) {
-	//TODO: Not implemented yet
-	return nil, errors.New("Not implemented yet")
+	return crypto.Sign(data, w.Skey)
 }
 
 func (w *EvmWallet) PublicKey() []byte {
@@ -32,6 +37,39 @@ func (w *EvmWallet) ECDH(pubKey []byte) ([]byte, error) {
 	return nil, nil
 }
 
+// NewEvmTransactor ...
+// returns the transact options of the address of the wallet, which sign
+// transactions with the wallet, for the wallets without raw keys like
+// remote signers: the transactions themselves if it is an EvmTxSigner,
+// or else their hashes
+func NewEvmTransactor(w Wallet, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+	if !common.IsHexAddress(w.Address()) {
+		return nil, errors.New("not an evm address: " + w.Address())
+	}
+	address := common.HexToAddress(w.Address())
+	signer := types.LatestSignerForChainID(chainID)
+	return &bind.TransactOpts{
+		From: address,
+		Signer: func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != address {
+				return nil, bind.ErrNotAuthorized
+			}
+			if ts, ok := w.(EvmTxSigner); ok {
+				return ts.SignEvmTransaction(tx, chainID)
+			}
+			sig, err := w.Sign(signer.Hash(tx).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			return tx.WithSignature(signer, sig)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
 func NewEvmWalletFromPrivateKey(sk *ecdsa.PrivateKey) (*EvmWallet, error) {
 	return &EvmWallet{
 		Skey: sk,